@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/db/postgres"
+	"github.com/blockblu-io/leaderlog-api/pkg/db/sqlite"
+)
+
+// runDeleteEpoch deletes the leader log of the pool given as first argument
+// for the epoch given as second argument from the configured database. This
+// command is meant to be used,
+// if the API isn't reachable. A running server won't be notified about the
+// deletion and thus, should preferably be used over its API.
+func runDeleteEpoch(args []string) {
+	if len(args) != 2 {
+		handleCLIError(fmt.Errorf("you must pass exactly one pool ID and one epoch as arguments"))
+	}
+	poolID := args[0]
+	epoch, err := strconv.Atoi(args[1])
+	if err != nil || epoch < 0 {
+		handleCLIError(fmt.Errorf("the epoch '%s' couldn't be parsed", args[1]))
+	}
+
+	idb, err := newDatabase(databaseName)
+	handleProgramError(err)
+	defer idb.Close()
+
+	found, err := idb.DeleteLeaderLog(context.Background(), poolID,
+		uint(epoch))
+	handleProgramError(err)
+	if !found {
+		fmt.Printf("no leader log is registered for pool %s and epoch %d\n",
+			poolID, epoch)
+		return
+	}
+	fmt.Printf("deleted the leader log of pool %s and epoch %d\n", poolID,
+		epoch)
+}
+
+// runMigrate migrates the configured database to the latest version of the
+// schema. The pending migrations are only listed, if the
+// '--dry-run' flag is passed.
+func runMigrate(args []string) {
+	migrateFlags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := migrateFlags.Bool("dry-run", false,
+		"only lists the pending migrations without applying them.")
+	_ = migrateFlags.Parse(args)
+	if migrateFlags.NArg() != 0 {
+		handleCLIError(fmt.Errorf("the migrate command doesn't expect any arguments"))
+	}
+
+	var versions []uint
+	var names []string
+	switch databaseName {
+	case "sqlite":
+		migrations, err := sqlite.Migrate(dbPath, *dryRun)
+		handleProgramError(err)
+		for _, migration := range migrations {
+			versions = append(versions, migration.Version)
+			names = append(names, migration.Name)
+		}
+	case "postgres":
+		migrations, err := postgres.Migrate(postgresDSN, *dryRun)
+		handleProgramError(err)
+		for _, migration := range migrations {
+			versions = append(versions, migration.Version)
+			names = append(names, migration.Name)
+		}
+	default:
+		handleCLIError(fmt.Errorf("the database '%s' is unknown", databaseName))
+	}
+	if len(versions) == 0 {
+		fmt.Println("the database is up to date")
+		return
+	}
+	for i, version := range versions {
+		if *dryRun {
+			fmt.Printf("pending migration to version %d (%s)\n", version,
+				names[i])
+		} else {
+			fmt.Printf("migrated to version %d (%s)\n", version, names[i])
+		}
+	}
+}
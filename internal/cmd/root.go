@@ -7,16 +7,56 @@ import (
 	"github.com/blockblu-io/leaderlog-api/internal/logging"
 	"github.com/blockblu-io/leaderlog-api/pkg/api"
 	"github.com/blockblu-io/leaderlog-api/pkg/auth"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
 	"github.com/blockblu-io/leaderlog-api/pkg/chain/blockfrost"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain/dbsync"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain/fallback"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain/koios"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain/ogmios"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain/poolcache"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain/ratelimit"
 	"github.com/blockblu-io/leaderlog-api/pkg/chain/syncer"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	"github.com/blockblu-io/leaderlog-api/pkg/db/postgres"
 	"github.com/blockblu-io/leaderlog-api/pkg/db/sqlite"
+	"github.com/blockblu-io/leaderlog-api/pkg/webhook"
+	"os"
+	"strings"
+	"time"
 )
 
 var (
-	hostname     string
-	port         int
-	dbPath       string
-	loggingLevel string
+	hostname       string
+	port           int
+	dbPath         string
+	databaseName   string
+	postgresDSN    string
+	loggingLevel   string
+	networkName    string
+	revealPolicy   string
+	revealRoutes   stringList
+	backendName    string
+	blockfrostURL  string
+	koiosURL       string
+	dbSyncDSN      string
+	ogmiosURL      string
+	ogmiosWindow   int
+	ogmiosFallback string
+	confirmDelay   time.Duration
+	finalityWindow uint
+	backendRate    float64
+	backendBurst   int
+	backendConc    int
+	backendRetries uint
+	maxTipAge      time.Duration
+	crossCheck     bool
+	poolCacheSize  int
+	poolCacheTTL   time.Duration
+	webhooks       stringList
+	webhookRetries uint
+	healthTipAge   time.Duration
+	healthSyncWait time.Duration
 )
 
 func Run() {
@@ -26,13 +66,74 @@ func Run() {
 		"port on which the API shall be served.")
 	flag.StringVar(&dbPath, "db-path", ".db",
 		"path to the directory with the leader log db.")
+	flag.StringVar(&databaseName, "database", "sqlite",
+		"name of the database for storing the leader logs (sqlite or postgres).")
+	flag.StringVar(&postgresDSN, "postgres-dsn", "",
+		"DSN of the postgres database (e.g. 'postgres://user@localhost/leaderlog').")
 	flag.StringVar(&loggingLevel, "level", "info",
 		"level of logging.")
+	flag.StringVar(&networkName, "network", "mainnet",
+		"name of the Cardano network (mainnet, preprod or preview).")
+	flag.StringVar(&revealPolicy, "reveal-policy", "after-slot",
+		"default policy for revealing assigned blocks (after-slot, after-minutes:N, day or after-epoch).")
+	flag.Var(&revealRoutes, "reveal-route",
+		"reveal policy for a specific route in the format '<route>=<policy>' (e.g. 'epoch/:epoch/by/date=day'). It can be specified multiple times.")
+	flag.StringVar(&backendName, "backend", "blockfrost",
+		"name of the backend for querying the chain (blockfrost, koios, db-sync or ogmios). Several backends separated by commas (e.g. 'blockfrost,koios') are failed over in the given order.")
+	flag.StringVar(&blockfrostURL, "blockfrost-url", blockfrost.DefaultURL,
+		"URL of the Blockfrost API.")
+	flag.StringVar(&koiosURL, "koios-url", koios.DefaultURL,
+		"base URL of the Koios API.")
+	flag.StringVar(&dbSyncDSN, "dbsync-dsn", "",
+		"DSN of the cardano-db-sync database (e.g. 'postgres://user@localhost/cexplorer').")
+	flag.StringVar(&ogmiosURL, "ogmios-url", ogmios.DefaultURL,
+		"websocket URL of Ogmios.")
+	flag.IntVar(&ogmiosWindow, "ogmios-window", ogmios.DefaultWindowSize,
+		"number of the latest block headers that are kept in memory by the ogmios backend.")
+	flag.StringVar(&ogmiosFallback, "ogmios-fallback", "",
+		"name of the backend for querying slots outside of the window of the ogmios backend (blockfrost, koios or db-sync).")
+	flag.Float64Var(&backendRate, "backend-rate", ratelimit.DefaultConfig.Rate,
+		"number of requests per second that are sent to the blockfrost or koios API on average (0 disables the limit).")
+	flag.IntVar(&backendBurst, "backend-burst", ratelimit.DefaultConfig.Burst,
+		"number of requests that can be sent at once to the blockfrost or koios API after a quiet period.")
+	flag.IntVar(&backendConc, "backend-concurrency", ratelimit.DefaultConfig.Concurrency,
+		"number of concurrent requests to the blockfrost or koios API (0 disables the bound).")
+	flag.UintVar(&backendRetries, "backend-retries", ratelimit.DefaultConfig.MaxRetries,
+		"number of retries of requests to the blockfrost or koios API that failed temporarily (e.g. with status 429 or 5xx).")
+	flag.DurationVar(&maxTipAge, "fallback-max-tip-age", fallback.DefaultConfig.MaxTipAge,
+		"age of the tip after which a backend is failed over, if several backends are specified (0 disables it).")
+	flag.BoolVar(&crossCheck, "fallback-cross-check", fallback.DefaultConfig.CrossCheck,
+		"whether minted blocks are looked up with two backends, whose answers must agree, if several backends are specified.")
+	flag.IntVar(&poolCacheSize, "pool-cache-size", poolcache.DefaultConfig.Size,
+		"number of pools, whose metadata is kept in memory.")
+	flag.DurationVar(&poolCacheTTL, "pool-cache-ttl", poolcache.DefaultConfig.TTL,
+		"duration after which the metadata of a pool is fetched again.")
+	flag.DurationVar(&confirmDelay, "confirmation-delay", syncer.DefaultSyncerConfig.ConfirmationDelay,
+		"duration the tip must be past the slot of an assigned block, before its status is gathered.")
+	flag.UintVar(&finalityWindow, "finality-window", syncer.DefaultSyncerConfig.FinalityWindow,
+		"number of blocks during which the status of a block is re-verified, because it can still be rolled back (0 disables it).")
+	flag.Var(&webhooks, "webhook",
+		"URL that is notified about the outcome of assigned blocks, optionally prefixed with the events in the format '<event>,...=<url>' (e.g. 'minted,ghosted=https://example.com/hook'). It can be specified multiple times.")
+	flag.UintVar(&webhookRetries, "webhook-retries", webhook.DefaultConfig.RetryMaxAttempts,
+		"number of attempts to notify a webhook, before the notification is given up.")
+	flag.DurationVar(&healthTipAge, "health-max-tip-age", api.DefaultHealthConfig.MaxTipAge,
+		"age of the tip after which the service is reported as degraded by the health route.")
+	flag.DurationVar(&healthSyncWait, "health-max-sync-delay", api.DefaultHealthConfig.MaxSyncDelay,
+		"duration after the slot of an assigned block after which the service is reported as degraded by the health route, if the status of the block hasn't been gathered yet.")
 	flag.Parse()
 
-	poolID := flag.Arg(0)
-	if poolID == "" {
-		handleCLIError(fmt.Errorf("you must pass the pool ID in hex format as argument"))
+	switch flag.Arg(0) {
+	case "delete-epoch":
+		runDeleteEpoch(flag.Args()[1:])
+		return
+	case "migrate":
+		runMigrate(flag.Args()[1:])
+		return
+	}
+
+	poolIDs := flag.Args()
+	if len(poolIDs) == 0 {
+		handleCLIError(fmt.Errorf("you must pass at least one pool ID in hex format as argument"))
 	}
 
 	if port <= 0 || port > 65536 {
@@ -43,22 +144,199 @@ func Run() {
 	err := logging.InitLogging(loggingLevel)
 	handleCLIError(err)
 
-	sqliteDB, err := sqlite.NewSQLiteDB(dbPath)
+	network, err := chain.GetNetwork(networkName)
+	handleCLIError(err)
+
+	revealConfig, err := parseRevealConfiguration(network)
+	handleCLIError(err)
+
+	idb, err := newDatabase(databaseName)
 	handleProgramError(err)
-	defer sqliteDB.Close()
+	defer idb.Close()
 
 	authenticator, err := auth.NewEnvironmentBasedAuthentication()
 	handleProgramError(err)
 
-	backend, err := blockfrost.NewBlockFrostBackend()
+	cacheConfig := *poolcache.DefaultConfig
+	cacheConfig.Size = poolCacheSize
+	cacheConfig.TTL = poolCacheTTL
+	cache := poolcache.NewCache(idb, &cacheConfig)
+
+	backend, err := newBackend(backendName, network, cache)
 	handleProgramError(err)
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer cancelFunc()
-	sync := syncer.NewSyncer(poolID, backend, sqliteDB)
-	defer sync.Close()
-	go sync.Run(ctx)
+	tipUpdater := syncer.NewTipUpdater(syncer.DefaultTipUpdaterConfig)
+	tipUpdater.Run(ctx, backend)
+	syncConfig := *syncer.DefaultSyncerConfig
+	syncConfig.ConfirmationDelay = confirmDelay
+	syncConfig.FinalityWindow = finalityWindow
+	dispatcher, err := newDispatcher(idb)
+	handleCLIError(err)
+	if dispatcher != nil {
+		go dispatcher.Run(ctx)
+	}
+	syncers := make([]*syncer.Syncer, len(poolIDs))
+	for i, poolID := range poolIDs {
+		sync := syncer.NewSyncer(poolID, backend, tipUpdater, idb,
+			&syncConfig)
+		defer sync.Close()
+		go sync.Run(ctx)
+		syncers[i] = sync
+	}
 
-	err = api.Serve(hostname, port, sqliteDB, authenticator)
+	healthConfig := &api.HealthConfiguration{
+		MaxTipAge:    healthTipAge,
+		MaxSyncDelay: healthSyncWait,
+	}
+	err = api.Serve(hostname, port, idb, authenticator, &api.Configuration{
+		PoolID:     poolIDs[0],
+		PoolIDs:    poolIDs,
+		Network:    network,
+		Reveal:     revealConfig,
+		Health:     healthConfig,
+		Backend:    backend,
+		TipUpdater: tipUpdater,
+		Syncers:    syncers,
+	})
 	handleProgramError(err)
 }
+
+// newDatabase opens the db.DB with the given name.
+func newDatabase(name string) (db.DB, error) {
+	switch name {
+	case "sqlite":
+		return sqlite.NewSQLiteDB(dbPath, clock.Real)
+	case "postgres":
+		return postgres.NewPostgresDB(postgresDSN, clock.Real)
+	}
+	return nil, fmt.Errorf("the database '%s' is unknown", name)
+}
+
+// newDispatcher creates the webhook.Dispatcher for the webhooks passed as
+// flags. Nil is returned, if no webhook has been passed. The secret for
+// signing the notifications must be specified in the environment as
+// 'BLU_WEBHOOK_SECRET'.
+func newDispatcher(idb db.DB) (*webhook.Dispatcher, error) {
+	if len(webhooks) == 0 {
+		return nil, nil
+	}
+	config := *webhook.DefaultConfig
+	config.RetryMaxAttempts = webhookRetries
+	config.Secret = os.Getenv("BLU_WEBHOOK_SECRET")
+	if config.Secret == "" {
+		return nil, fmt.Errorf("no secret for signing the webhook notifications specified in the environment (BLU_WEBHOOK_SECRET)")
+	}
+	for _, value := range webhooks {
+		hook, err := webhook.ParseWebhook(value)
+		if err != nil {
+			return nil, err
+		}
+		config.Webhooks = append(config.Webhooks, *hook)
+	}
+	return webhook.NewDispatcher(idb, &config), nil
+}
+
+// newBackend creates the chain.Backend with the given name for the given
+// network. The metadata of pools is cached in the given poolcache.Cache.
+func newBackend(name string, network *chain.Network,
+	cache *poolcache.Cache) (chain.Backend, error) {
+
+	if strings.Contains(name, ",") {
+		return newFallbackBackend(strings.Split(name, ","), network, cache)
+	}
+	switch name {
+	case "blockfrost":
+		return limitBackend(blockfrost.NewBlockFrostBackend(blockfrostURL, cache))
+	case "koios":
+		return limitBackend(koios.NewKoiosBackend(koiosURL, cache))
+	case "db-sync":
+		return dbsync.NewDBSyncBackend(dbSyncDSN)
+	case "ogmios":
+		var fallbackBackend chain.Backend
+		if ogmiosFallback != "" {
+			if ogmiosFallback == "ogmios" {
+				return nil, fmt.Errorf("the ogmios backend can't be its own fallback")
+			}
+			var err error
+			fallbackBackend, err = newBackend(ogmiosFallback, network, cache)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return ogmios.NewOgmiosBackend(ogmiosURL, network, ogmiosWindow,
+			fallbackBackend)
+	}
+	return nil, fmt.Errorf("the backend '%s' is unknown", name)
+}
+
+// newFallbackBackend creates a fallback.Backend failing over between the
+// backends with the given names in the given order.
+func newFallbackBackend(names []string, network *chain.Network,
+	cache *poolcache.Cache) (chain.Backend, error) {
+
+	backends := make([]chain.Backend, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "ogmios" {
+			return nil, fmt.Errorf("the ogmios backend can't be failed over, but it can have a fallback itself")
+		}
+		backend, err := newBackend(name, network, cache)
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, backend)
+	}
+	config := *fallback.DefaultConfig
+	config.MaxTipAge = maxTipAge
+	config.CrossCheck = crossCheck
+	backend, err := fallback.NewBackend(backends, &config)
+	if err != nil {
+		return nil, err
+	}
+	return backend, nil
+}
+
+// limitBackend decorates the given chain.Backend of an API with the limits
+// passed as flags. The given error is passed through, if it isn't nil.
+func limitBackend(backend chain.Backend, err error) (chain.Backend, error) {
+	if err != nil {
+		return nil, err
+	}
+	config := *ratelimit.DefaultConfig
+	config.Rate = backendRate
+	config.Burst = backendBurst
+	config.Concurrency = backendConc
+	config.MaxRetries = backendRetries
+	return ratelimit.NewBackend(backend, &config), nil
+}
+
+// parseRevealConfiguration assembles the reveal configuration of the API from
+// the default policy and the route specific policies passed as flags.
+func parseRevealConfiguration(network *chain.Network) (*api.RevealConfiguration, error) {
+	config := api.NewDefaultRevealConfiguration()
+	policy, err := api.ParseRevealPolicy(revealPolicy, network)
+	if err != nil {
+		return nil, err
+	}
+	config.Default = policy
+	for _, route := range revealRoutes {
+		parts := strings.SplitN(route, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("the reveal route '%s' must be in the format '<route>=<policy>'",
+				route)
+		}
+		path := strings.Trim(parts[0], "/")
+		if !api.IsRevealRoute(path) {
+			return nil, fmt.Errorf("the route '%s' of reveal route '%s' doesn't expose assigned blocks (%s)",
+				path, route, strings.Join(api.RevealRoutes, ", "))
+		}
+		policy, err := api.ParseRevealPolicy(parts[1], network)
+		if err != nil {
+			return nil, err
+		}
+		config.Routes[path] = policy
+	}
+	return config, nil
+}
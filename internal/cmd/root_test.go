@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/api"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+)
+
+func TestParseRevealConfiguration(t *testing.T) {
+	defer func(policy string, routes stringList) {
+		revealPolicy, revealRoutes = policy, routes
+	}(revealPolicy, revealRoutes)
+
+	revealPolicy = "after-epoch"
+	revealRoutes = stringList{"/events=day", "epoch/:epoch/blocks/before/now/=after-minutes:5"}
+	config, err := parseRevealConfiguration(chain.Mainnet)
+	if err != nil {
+		t.Fatalf("expected the reveal routes to be accepted, got %v", err)
+	}
+	if _, ok := config.Routes["events"].(api.DayGranularityPolicy); !ok {
+		t.Errorf("expected the policy day for events, got %v",
+			config.Routes["events"])
+	}
+	if config.Routes["epoch/:epoch/blocks/before/now"] == nil {
+		t.Errorf("expected a policy for the blocks before now, got %v",
+			config.Routes)
+	}
+
+	for _, route := range []string{
+		"epoch/:epoch/by/dates=after-slot",
+		"pool/:poolId/events=after-slot",
+		"metrics=day",
+		"events",
+		"events=later",
+	} {
+		revealRoutes = stringList{route}
+		if _, err := parseRevealConfiguration(chain.Mainnet); err == nil {
+			t.Errorf("expected the reveal route '%s' to be rejected", route)
+		}
+	}
+}
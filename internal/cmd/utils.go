@@ -4,8 +4,22 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 )
 
+// stringList is a flag.Value that collects all the values of a flag, which
+// can be specified multiple times.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
 func printUsageWithError(err error) {
 	fmt.Printf("error: %s", err.Error())
 	printUsage()
@@ -17,7 +31,9 @@ func printUsage() {
 	if len(args) > 0 {
 		name = args[0]
 	}
-	fmt.Printf("\nUsage: %s <pool-id> [options]\n", name)
+	fmt.Printf("\nUsage: %s [options] <pool-id> [<pool-id>...]\n", name)
+	fmt.Printf("       %s [options] delete-epoch <pool-id> <epoch>\n", name)
+	fmt.Printf("       %s [options] migrate [--dry-run]\n", name)
 	flag.PrintDefaults()
 }
 
@@ -0,0 +1,82 @@
+// Package pgtest provides PostgreSQL databases for tests. The tests are only
+// run, if the DSN of a disposable PostgreSQL database is specified in the
+// environment. Otherwise, they are skipped.
+package pgtest
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// DSN returns a DSN for the PostgreSQL database specified in the environment
+// variable with the given name. The search path of the returned DSN is set to
+// a new and empty schema, which is dropped at the end of the test. The test is
+// skipped, if the environment variable isn't set.
+func DSN(t *testing.T, env string) string {
+	t.Helper()
+	dsn := os.Getenv(env)
+	if dsn == "" {
+		t.Skipf("the DSN of a PostgreSQL database isn't specified in '%s'", env)
+	}
+	sqlDB, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("couldn't open the database: %s", err.Error())
+	}
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	_, err = sqlDB.Exec("CREATE SCHEMA " + schema)
+	if err != nil {
+		_ = sqlDB.Close()
+		t.Fatalf("couldn't create the schema '%s': %s", schema, err.Error())
+	}
+	t.Cleanup(func() {
+		_, err := sqlDB.Exec("DROP SCHEMA " + schema + " CASCADE")
+		if err != nil {
+			t.Errorf("couldn't drop the schema '%s': %s", schema, err.Error())
+		}
+		_ = sqlDB.Close()
+	})
+	return withSearchPath(dsn, schema)
+}
+
+// Seed executes the SQL statements of the file at the given path in the
+// database, which is reachable with the given DSN.
+func Seed(t *testing.T, dsn string, path string) {
+	t.Helper()
+	statements, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("couldn't read the fixture '%s': %s", path, err.Error())
+	}
+	sqlDB, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("couldn't open the database: %s", err.Error())
+	}
+	defer sqlDB.Close()
+	_, err = sqlDB.Exec(string(statements))
+	if err != nil {
+		t.Fatalf("couldn't seed the fixture '%s': %s", path, err.Error())
+	}
+}
+
+// withSearchPath adds the given schema as search path to the given DSN, which
+// can either be an URL or a list of key-value pairs.
+func withSearchPath(dsn string, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") ||
+		strings.HasPrefix(dsn, "postgresql://") {
+
+		u, err := url.Parse(dsn)
+		if err == nil {
+			query := u.Query()
+			query.Set("search_path", schema)
+			u.RawQuery = query.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
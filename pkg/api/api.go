@@ -2,8 +2,14 @@ package api
 
 import (
 	"fmt"
+	"net/http"
+	"strings"
+
 	"github.com/blockblu-io/leaderlog-api/internal/logging"
 	"github.com/blockblu-io/leaderlog-api/pkg/auth"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain/syncer"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
 	"github.com/blockblu-io/leaderlog-api/pkg/db"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
@@ -11,33 +17,146 @@ import (
 
 const RootPath = "leaderlog"
 
+// poolPath is the path prefix of routes that are scoped to a certain pool.
+const poolPath = "pool/:poolId"
+
 // getPath assembles the path for api calls given the relative path. This
 // functions returns the complete path that can be passed to the gin framework.
 func getPath(relativePath string) string {
 	return fmt.Sprintf("%s/%s", RootPath, relativePath)
 }
 
+// Configuration configures the behaviour of the API.
+type Configuration struct {
+	// PoolID is the ID in hex format of the default pool, which is served by
+	// the routes that aren't scoped to a pool.
+	PoolID string
+	// PoolIDs are the IDs in hex format of all the pools served by this API.
+	// The default pool is served in any case.
+	PoolIDs []string
+	// Network are the parameters of the network on which the pool operates.
+	Network *chain.Network
+	// Reveal specifies the reveal policies for routes that expose assigned
+	// blocks.
+	Reveal *RevealConfiguration
+	// Health specifies when the components reported by the health route are
+	// considered to be degraded. The default configuration is used, if it is
+	// nil.
+	Health *HealthConfiguration
+	// Backend is the backend for querying the chain, whose requests are
+	// exposed as metrics. It can be nil.
+	Backend chain.Backend
+	// TipUpdater is the updater of the tip, whose age is exposed as metric.
+	// It can be nil.
+	TipUpdater *syncer.TipUpdater
+	// Syncers are the syncers of the served pools, whose metrics are exposed.
+	Syncers []*syncer.Syncer
+	// Clock tells the time, which is "now" for a request. The time of the
+	// system is used, if it is nil.
+	Clock clock.Clock
+}
+
+// pools returns the IDs in hex format of all the pools served by this API
+// starting with the default pool.
+func (config *Configuration) pools() []string {
+	pools := []string{config.PoolID}
+	for _, id := range config.PoolIDs {
+		if !strings.EqualFold(id, config.PoolID) {
+			pools = append(pools, id)
+		}
+	}
+	return pools
+}
+
+// lookupPool looks up the pool served by this API with the given ID. The IDs
+// are compared case-insensitively. The ID of the pool as configured and true
+// are returned, if the pool is served. Otherwise, false is returned.
+func (config *Configuration) lookupPool(poolID string) (string, bool) {
+	if strings.EqualFold(poolID, config.PoolID) {
+		return config.PoolID, true
+	}
+	for _, id := range config.PoolIDs {
+		if strings.EqualFold(poolID, id) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// handlePoolRoute registers the given handler for the given method and
+// relative path. The handler is registered at the root, where it serves the
+// default pool, as well as scoped to a pool, where it serves the pool
+// specified in the path.
+func handlePoolRoute(router *gin.Engine, method, relativePath string,
+	config *Configuration, handler func(c *gin.Context, poolID string)) {
+
+	router.Handle(method, getPath(relativePath), func(c *gin.Context) {
+		handler(c, config.PoolID)
+	})
+	router.Handle(method, getPath(poolPath+"/"+relativePath),
+		func(c *gin.Context) {
+			poolID, found := config.lookupPool(c.Param("poolId"))
+			if !found {
+				c.AbortWithStatusJSON(http.StatusNotFound,
+					errorPayload(c, "the pool isn't served by this API"))
+				return
+			}
+			handler(c, poolID)
+		})
+}
+
 // routes returns a list of all routes for this api.
-func routes(db db.DB, auth auth.Authenticator) []func(router *gin.Engine) {
+func routes(db db.DB, auth auth.Authenticator,
+	config *Configuration) []func(router *gin.Engine) {
+
 	return []func(*gin.Engine){
 		heartbeat,
-		getRegisteredEpochs(db),
-		postLeaderLog(db, auth),
-		getLeaderLogByDate(db),
-		getLeaderLogPerformance(db),
-		getAssignedBlocksBeforeNow(db),
+		getRegisteredEpochs(db, config),
+		postLeaderLog(db, auth, config),
+		deleteLeaderLog(db, auth, config),
+		getLeaderLogByDate(db, config, config.Reveal.policy(byDatePath)),
+		getLeaderLogPerformance(db, config),
+		getPerformanceOfEpochs(db, config),
+		getAssignedBlocksBeforeNow(db, config,
+			config.Reveal.policy(blocksBeforeNowPath)),
+		getStuckBlocks(db, auth, config),
+		getEvents(db, config, config.Reveal.policy(eventsPath)),
+		getWebhookDeliveries(db, auth),
+		getMetrics(db, auth, config),
+		getHealth(db, config, config.Reveal.policy(healthPath)),
 	}
 }
 
-// Serve starts the API at the given hostname and on the given port.
-func Serve(hostname string, port int, db db.DB, auth auth.Authenticator) error {
+// NewRouter creates the router with all the routes of this API, which can be
+// served by a HTTP server.
+func NewRouter(db db.DB, auth auth.Authenticator,
+	config *Configuration) *gin.Engine {
+
+	if config.Reveal == nil {
+		config.Reveal = NewDefaultRevealConfiguration()
+	}
+	if config.Health == nil {
+		config.Health = DefaultHealthConfig
+	}
+	if config.Clock == nil {
+		config.Clock = clock.Real
+	}
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
-	router.Use(logging.GinLoggingHook(), gin.Recovery())
+	router.Use(logging.GinLoggingHook(), gin.Recovery(),
+		stampRequestTime(config.Clock))
 	_ = router.SetTrustedProxies(nil)
-	for _, function := range routes(db, auth) {
+	for _, function := range routes(db, auth, config) {
 		function(router)
 	}
+	return router
+}
+
+// Serve starts the API at the given hostname and on the given port.
+func Serve(hostname string, port int, db db.DB, auth auth.Authenticator,
+	config *Configuration) error {
+
+	router := NewRouter(db, auth, config)
 	address := fmt.Sprintf("%s:%d", hostname, port)
 	log.Infof("starting the API at address '%s'", address)
 	return router.Run(address)
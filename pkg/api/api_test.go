@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/api/dto"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	"github.com/blockblu-io/leaderlog-api/pkg/db/memdb"
+)
+
+const (
+	poolA = "cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214b"
+	// poolB is a further pool, which is served next to the default poolA.
+	poolB = "1d9302a3fb4b3b1935e02b27f0339798d3f08a55fbfdcd43a449a96f"
+	// username and password are the credentials accepted by testAuth.
+	username = "admin"
+	password = "s3cret"
+	// awaitTimeout is the duration to wait for the API to react.
+	awaitTimeout = 5 * time.Second
+)
+
+var (
+	// pastSlot is the slot of the first block of the test leader log, which
+	// has passed at the start time.
+	pastSlot uint = 55904400
+	// futureSlot is the slot of the second block of the test leader log, which
+	// is scheduled for the day after the start time.
+	futureSlot uint = 55911600
+	// startTime is the time to which the clock is set at the start of a test.
+	// It is a quarter hour after the slot of the first block.
+	startTime = time.Date(2022, 3, 16, 23, 0, 0, 0, time.UTC)
+)
+
+// testAuth accepts only the test credentials.
+type testAuth struct{}
+
+func (testAuth) CheckAuthentication(user, pass string) bool {
+	return user == username && pass == password
+}
+
+// testPolicies are the reveal policies, under which the routes are tested.
+var testPolicies = []RevealPolicy{
+	AfterSlotPolicy{},
+	AfterDurationPolicy{Delay: time.Hour},
+	DayGranularityPolicy{},
+	AfterEpochEndPolicy{Network: chain.Mainnet},
+}
+
+// slotTime returns the time of the given slot on the mainnet.
+func slotTime(t *testing.T, slot uint) time.Time {
+	tm, err := chain.Mainnet.SlotTime(slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tm
+}
+
+// newTestDB creates a database with a leader log of poolA in epoch 327, whose
+// first block has been minted in the past slot, and whose second block is
+// scheduled for the future slot.
+func newTestDB(t *testing.T, clk clock.Clock) db.DB {
+	ctx := context.Background()
+	idb := memdb.NewMemDB(clk)
+	_, err := idb.WriteLeaderLog(ctx, &db.LeaderLog{
+		PoolID: poolA,
+		Epoch:  327,
+		Blocks: []db.AssignedBlock{
+			{Epoch: 327, No: 1, EpochSlot: pastSlot - 55900800,
+				Slot: pastSlot, Timestamp: slotTime(t, pastSlot)},
+			{Epoch: 327, No: 2, EpochSlot: futureSlot - 55900800,
+				Slot: futureSlot, Timestamp: slotTime(t, futureSlot)},
+		},
+		ExpectedBlockNumber: 2,
+		MaxPerformance:      1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = idb.UpdateStatusForAssignment(ctx, poolA, 327, 1, db.Minted,
+		&db.MintedBlock{
+			Epoch:     327,
+			EpochSlot: pastSlot - 55900800,
+			Slot:      pastSlot,
+			Hash:      "minted",
+			Height:    7000001,
+			PoolID:    poolA,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idb
+}
+
+// newTestServer serves the API for poolA on the given database, which accepts
+// the credentials of testAuth and reveals assigned blocks under the given
+// policy on all routes.
+func newTestServer(t *testing.T, idb db.DB, clk clock.Clock,
+	policy RevealPolicy) *httptest.Server {
+
+	server := httptest.NewServer(NewRouter(idb, testAuth{}, &Configuration{
+		PoolID:  poolA,
+		Network: chain.Mainnet,
+		Reveal:  &RevealConfiguration{Default: policy},
+		Clock:   clk,
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// getJSON requests the given URL, and decodes the response of the payload into
+// the given value. The status code of the response is returned.
+func getJSON(t *testing.T, url string, v interface{}) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	payload := struct {
+		Response interface{} `json:"response"`
+	}{Response: v}
+	err = json.NewDecoder(resp.Body).Decode(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+func TestPoolRoutes(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	idb := newTestDB(t, clk)
+	_, err := idb.WriteLeaderLog(context.Background(), &db.LeaderLog{
+		PoolID: poolB,
+		Epoch:  327,
+		Blocks: []db.AssignedBlock{
+			{Epoch: 327, No: 1, EpochSlot: pastSlot - 55900800,
+				Slot: pastSlot, Timestamp: slotTime(t, pastSlot)},
+		},
+		ExpectedBlockNumber: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(NewRouter(idb, testAuth{}, &Configuration{
+		PoolID:  poolA,
+		PoolIDs: []string{poolA, poolB},
+		Network: chain.Mainnet,
+		Reveal:  &RevealConfiguration{Default: AfterSlotPolicy{}},
+		Clock:   clk,
+	}))
+	defer server.Close()
+
+	t.Run("performance", func(t *testing.T) {
+		tests := []struct {
+			path string
+			// assignedBlocks is the number of blocks assigned to the served
+			// pool in epoch 327.
+			assignedBlocks int
+		}{
+			{"/leaderlog/epoch/327/performance", 2},
+			{"/leaderlog/pool/" + poolA + "/epoch/327/performance", 2},
+			{"/leaderlog/pool/" + poolB + "/epoch/327/performance", 1},
+			{"/leaderlog/pool/" + strings.ToUpper(poolB) +
+				"/epoch/327/performance", 1},
+		}
+		for _, test := range tests {
+			var performance struct {
+				AssignedBlocks int `json:"assignedBlocks"`
+			}
+			code := getJSON(t, server.URL+test.path, &performance)
+			if code != http.StatusOK {
+				t.Fatalf("%s: expected status 200, got %d", test.path, code)
+			}
+			if performance.AssignedBlocks != test.assignedBlocks {
+				t.Errorf("%s: expected %d assigned blocks, got %d", test.path,
+					test.assignedBlocks, performance.AssignedBlocks)
+			}
+		}
+	})
+
+	t.Run("unknown pool", func(t *testing.T) {
+		unknown := "/leaderlog/pool/" + strings.Repeat("0", 56)
+		for _, path := range []string{"/epoch", "/epoch/327/performance",
+			"/events", "/health"} {
+
+			resp, err := http.Get(server.URL + unknown + path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusNotFound {
+				t.Errorf("%s: expected status 404, got %d", path,
+					resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("post", func(t *testing.T) {
+		log := validLeaderLog(t)
+		var payload struct {
+			Errors []dto.FieldError `json:"errors"`
+		}
+		code := postJSON(t, server.URL+"/leaderlog/pool/"+poolB, log,
+			username, password, &payload)
+		if code != http.StatusUnprocessableEntity || len(payload.Errors) != 1 ||
+			payload.Errors[0].Field != "poolId" {
+			t.Fatalf("expected the log of poolA to be rejected for poolB, got "+
+				"status %d with %v", code, payload.Errors)
+		}
+		log.PoolID = poolB
+		code = postJSON(t, server.URL+"/leaderlog/pool/"+poolB, log,
+			username, password, &struct{}{})
+		if code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", code)
+		}
+		expected := map[string][]uint{
+			"/leaderlog/epoch":                    {327},
+			"/leaderlog/pool/" + poolA + "/epoch": {327},
+			"/leaderlog/pool/" + poolB + "/epoch": {328, 327},
+		}
+		for path, epochs := range expected {
+			var registered []uint
+			code := getJSON(t, server.URL+path, &registered)
+			if code != http.StatusOK {
+				t.Fatalf("%s: expected status 200, got %d", path, code)
+			}
+			if !reflect.DeepEqual(registered, epochs) {
+				t.Errorf("%s: expected the epochs %v, got %v", path, epochs,
+					registered)
+			}
+		}
+	})
+}
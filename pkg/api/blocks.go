@@ -1,29 +1,81 @@
 package api
 
 import (
-	"github.com/blockblu-io/leaderlog-api/pkg/db"
-	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/api/dto"
+	"github.com/blockblu-io/leaderlog-api/pkg/auth"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	"github.com/gin-gonic/gin"
 )
 
-func getAssignedBlocksBeforeNow(idb db.DB) func(router *gin.Engine) {
+const blocksBeforeNowPath = "epoch/:epoch/blocks/before/now"
+
+func getAssignedBlocksBeforeNow(idb db.DB, config *Configuration,
+	policy RevealPolicy) func(router *gin.Engine) {
+
 	return func(router *gin.Engine) {
-		router.GET(getPath("epoch/:epoch/blocks/before/now"),
-			func(c *gin.Context) {
+		handlePoolRoute(router, http.MethodGet, blocksBeforeNowPath, config,
+			func(c *gin.Context, poolID string) {
+				loc, err := time.LoadLocation(c.Query("tz"))
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusBadRequest,
+						errorPayload(c, err.Error()))
+					return
+				}
 				epoch, err := strconv.Atoi(c.Param("epoch"))
 				if err != nil {
 					c.AbortWithStatusJSON(http.StatusBadRequest,
-						errorPayload("epoch couldn't be parsed"))
+						errorPayload(c, "epoch couldn't be parsed"))
+					return
+				}
+				blocks, err := idb.GetAssignedBlocksBeforeNow(c, poolID,
+					uint(epoch))
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusInternalServerError,
+						errorPayload(c, err.Error()))
+					return
+				}
+				revealed := revealBlocks(policy, blocks, requestTime(c), loc)
+				c.JSON(200, okPayload(c, revealed))
+			})
+	}
+}
+
+func getStuckBlocks(idb db.DB, auth auth.Authenticator,
+	config *Configuration) func(router *gin.Engine) {
+
+	return func(router *gin.Engine) {
+		handlePoolRoute(router, http.MethodGet, "blocks/stuck", config,
+			func(c *gin.Context, poolID string) {
+				if !checkAuthentication(c, auth) {
 					return
 				}
-				blocks, err := idb.GetAssignedBlocksBeforeNow(c, uint(epoch))
+				entries, err := idb.GetRetryEntries(c, poolID)
 				if err != nil {
 					c.AbortWithStatusJSON(http.StatusInternalServerError,
-						errorPayload(err.Error()))
+						errorPayload(c, err.Error()))
 					return
 				}
-				c.JSON(200, okPayload(blocks))
+				blocks := make([]dto.StuckBlock, len(entries))
+				for i, entry := range entries {
+					blocks[i] = dto.StuckBlock{
+						Epoch:     entry.Block.Epoch,
+						No:        entry.Block.No,
+						EpochSlot: entry.Block.EpochSlot,
+						Slot:      entry.Block.Slot,
+						Timestamp: entry.Block.Timestamp,
+						Attempts:  entry.Attempts,
+						LastError: entry.LastError,
+					}
+					if !entry.NextAttempt.IsZero() {
+						nextAttempt := entry.NextAttempt
+						blocks[i].NextAttempt = &nextAttempt
+					}
+				}
+				c.JSON(200, okPayload(c, blocks))
 			})
 	}
 }
@@ -0,0 +1,65 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+)
+
+// RevealedBlock is an assigned block as it is exposed by the API. Details that
+// would disclose the scheduled time of the block earlier than permitted by the
+// reveal policy are left empty, and then omitted in the JSON representation.
+type RevealedBlock struct {
+	// Epoch is the epoch for which the block has been scheduled.
+	Epoch uint
+	// No is the unique number of the block in an overall leader log.
+	No uint
+	// EpochSlot is the slot number for which the block has been scheduled. The
+	// number is counted from the start of the epoch.
+	EpochSlot *uint `json:",omitempty"`
+	// Slot is the slot number fow which the block has been scheduled. The
+	// number is counted from the chain`s inception.
+	Slot *uint `json:",omitempty"`
+	// Timestamp is the exact time for which the block has been scheduled.
+	Timestamp *time.Time `json:",omitempty"`
+	// Day is the date (in format YYYY-MM-DD) for which the block has been
+	// scheduled. It is only set, if the exact timestamp mustn't be revealed.
+	Day string `json:",omitempty"`
+	// Status is the current status of this scheduled block.
+	Status db.BlockStatus
+	// RelevantBlock links a minted block to this assigned block, which can
+	// further explain the status of the assigned block.
+	RelevantBlock *MintedBlock
+}
+
+// MintedBlock is a minted block as it is exposed by the API.
+type MintedBlock struct {
+	db.MintedBlock
+	// PoolBech32ID is the unique identifier of the pool that minted the block
+	// in bech32 format (e.g. 'pool1...').
+	PoolBech32ID string `json:",omitempty"`
+}
+
+// StuckBlock is an assigned block, whose status couldn't be gathered, and which
+// is queued to be retried.
+type StuckBlock struct {
+	// Epoch is the epoch for which the block has been scheduled.
+	Epoch uint
+	// No is the unique number of the block in an overall leader log.
+	No uint
+	// EpochSlot is the slot number for which the block has been scheduled. The
+	// number is counted from the start of the epoch.
+	EpochSlot uint
+	// Slot is the slot number fow which the block has been scheduled. The
+	// number is counted from the chain`s inception.
+	Slot uint
+	// Timestamp is the exact time for which the block has been scheduled.
+	Timestamp time.Time
+	// Attempts is the number of failed attempts to gather the status.
+	Attempts uint
+	// NextAttempt is the time after which the next attempt is made. It is
+	// omitted, if no further attempt is made.
+	NextAttempt *time.Time `json:",omitempty"`
+	// LastError describes why the last attempt failed.
+	LastError string
+}
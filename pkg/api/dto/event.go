@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+)
+
+// Event is a change of the leader logs of a pool as it is streamed by the API.
+type Event struct {
+	// ID is the unique identifier of the event. The stream can be resumed
+	// after this event by passing its ID as Last-Event-ID.
+	ID uint
+	// Type is the type of the event.
+	Type db.EventType
+	// Epoch is the epoch to which the event refers.
+	Epoch uint
+	// Timestamp is the time at which the event happened. It is omitted for
+	// block events, if it would reveal the scheduled time of the block more
+	// precisely than the reveal policy allows.
+	Timestamp *time.Time `json:",omitempty"`
+	// Status is the status of the assigned block after the change. It is only
+	// specified for block events, whose block is still registered.
+	Status *db.BlockStatus `json:",omitempty"`
+	// Block is the assigned block to which a block event refers, as it is
+	// currently known. It is omitted, if the block isn't registered anymore.
+	Block *RevealedBlock `json:",omitempty"`
+}
@@ -0,0 +1,104 @@
+package dto
+
+import (
+	"time"
+)
+
+// HealthStatus is the status of a component of the service.
+type HealthStatus string
+
+const (
+	// HealthOK is the status of a component, which works as expected.
+	HealthOK HealthStatus = "ok"
+	// HealthDegraded is the status of a component, which needs attention.
+	HealthDegraded HealthStatus = "degraded"
+)
+
+// Health is the health of the service and its components.
+type Health struct {
+	// Database is the health of the database.
+	Database ComponentHealth
+	// Tip is the health of the tip of the chain, which is fetched regularly.
+	Tip *TipHealth `json:",omitempty"`
+	// Backend is the health of the backend for querying the chain.
+	Backend *BackendHealth `json:",omitempty"`
+	// Pools is the health of the syncing of each served pool.
+	Pools []PoolHealth
+}
+
+// ComponentHealth is the health of a component of the service.
+type ComponentHealth struct {
+	// Status is the status of the component.
+	Status HealthStatus
+	// Error describes why the component is degraded.
+	Error string `json:",omitempty"`
+}
+
+// TipHealth is the health of the tip of the chain.
+type TipHealth struct {
+	ComponentHealth
+	// Epoch is the epoch of the tip.
+	Epoch uint `json:",omitempty"`
+	// Slot is the slot number of the tip. The number is counted from the
+	// chain`s inception.
+	Slot uint `json:",omitempty"`
+	// Hash is the hash of the block at the tip.
+	Hash string `json:",omitempty"`
+	// Timestamp is the time at which the block at the tip has been minted.
+	Timestamp *time.Time `json:",omitempty"`
+	// AgeSeconds is the number of seconds since the block at the tip has been
+	// minted.
+	AgeSeconds *uint `json:",omitempty"`
+}
+
+// BackendHealth is the health of the backend for querying the chain.
+type BackendHealth struct {
+	ComponentHealth
+	// Name is the name of the backend.
+	Name string
+	// LastError describes why the last attempt to fetch the tip failed. It is
+	// omitted, if the last attempt succeeded.
+	LastError string `json:",omitempty"`
+	// Backends is the health of each backend, between which is failed over.
+	Backends []FallbackHealth `json:",omitempty"`
+}
+
+// FallbackHealth is the health of a backend, between which is failed over.
+type FallbackHealth struct {
+	// Name is the name of the backend.
+	Name string
+	// Healthy is true, if the backend neither failed recently nor is stale.
+	Healthy bool
+	// Stale is true, if the tip of the backend was too old, and the backend
+	// hasn't caught up since.
+	Stale bool
+	// LastError describes why the backend failed the last time.
+	LastError string `json:",omitempty"`
+	// LastErrorTime is the time at which the backend failed the last time.
+	LastErrorTime *time.Time `json:",omitempty"`
+}
+
+// PoolHealth is the health of the syncing of a pool.
+type PoolHealth struct {
+	ComponentHealth
+	// PoolID is the id in hex format of the pool.
+	PoolID string
+	// UnsyncedBlocks is the number of blocks assigned before now, whose status
+	// hasn't been gathered yet. Only the blocks are counted, for which the
+	// reveal policy permits to reveal that their slot has passed.
+	UnsyncedBlocks uint
+	// OverdueBlocks is the number of unsynced blocks, whose status should
+	// have been gathered already.
+	OverdueBlocks uint
+	// NextBlock is the next assigned block as permitted by the reveal policy.
+	NextBlock *RevealedBlock `json:",omitempty"`
+	// DaysUntilNextBlock is the number of days (in UTC) until the day of the
+	// next assigned block. It is only set, if at least the day of the block
+	// can be revealed.
+	DaysUntilNextBlock *uint `json:",omitempty"`
+	// SecondsUntilNextBlock is the number of seconds until the next assigned
+	// block. It is only set, if the exact timestamp of the block can be
+	// revealed, which none of the shipped reveal policies permits before the
+	// slot of the block.
+	SecondsUntilNextBlock *uint `json:",omitempty"`
+}
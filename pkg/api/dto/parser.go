@@ -0,0 +1,96 @@
+package dto
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/url"
+	"sync"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+)
+
+// DefaultFormat is the name of the upload format that is used, if neither
+// the format nor the content type of an upload is known.
+const DefaultFormat = "cncli"
+
+// ParserContext provides information to a Parser that might not be contained
+// in every upload format.
+type ParserContext struct {
+	// PoolID is the ID in hex format of the pool for which the leader log has
+	// been uploaded.
+	PoolID string
+	// Network are the parameters of the network on which the pool operates.
+	Network *chain.Network
+	// Params are the query parameters of the upload, which can provide
+	// further information.
+	Params url.Values
+}
+
+// Parser parses the content of the given reader in a certain upload format
+// into a leader log object. If the parsing fails, then a corresponding error
+// will be returned.
+type Parser func(reader io.Reader, ctx *ParserContext) (*LeaderLog, error)
+
+// registry keeps track of the parsers for all supported upload formats.
+type registry struct {
+	lock         sync.RWMutex
+	formats      map[string]Parser
+	contentTypes map[string]string
+}
+
+var (
+	parsers = registry{
+		formats:      make(map[string]Parser),
+		contentTypes: make(map[string]string),
+	}
+	// UnknownFormatError is returned, when no parser is registered for the
+	// requested upload format.
+	UnknownFormatError = errors.New("the format of the leader log is unknown")
+)
+
+func init() {
+	RegisterParser(DefaultFormat, []string{"application/json"},
+		func(reader io.Reader, _ *ParserContext) (*LeaderLog, error) {
+			return ParseLeaderLog(reader)
+		})
+	RegisterParser(LeadershipScheduleFormat,
+		[]string{LeadershipScheduleContentType}, ParseLeadershipSchedule)
+}
+
+// RegisterParser registers the given parser for the upload format with the
+// given name, and for uploads with one of the given content types. A parser
+// that has been registered before under the same name or content type is
+// replaced.
+func RegisterParser(format string, contentTypes []string, parser Parser) {
+	parsers.lock.Lock()
+	defer parsers.lock.Unlock()
+	parsers.formats[format] = parser
+	for _, contentType := range contentTypes {
+		parsers.contentTypes[contentType] = format
+	}
+}
+
+// GetParser returns the parser for the given upload format. If no format is
+// given, then the parser is selected based on the given content type, where
+// the parser of the DefaultFormat is used for unknown content types.
+// UnknownFormatError will be returned, if no parser has been registered for
+// the given format.
+func GetParser(format string, contentType string) (Parser, error) {
+	parsers.lock.RLock()
+	defer parsers.lock.RUnlock()
+	if format == "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err == nil {
+			format = parsers.contentTypes[mediaType]
+		}
+		if format == "" {
+			format = DefaultFormat
+		}
+	}
+	parser, found := parsers.formats[format]
+	if !found {
+		return nil, UnknownFormatError
+	}
+	return parser, nil
+}
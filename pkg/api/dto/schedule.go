@@ -0,0 +1,101 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// LeadershipScheduleFormat is the name of the upload format produced by
+	// 'cardano-cli query leadership-schedule'.
+	LeadershipScheduleFormat = "cardano-cli"
+	// LeadershipScheduleContentType is the content type of uploads in the
+	// format produced by 'cardano-cli query leadership-schedule'.
+	LeadershipScheduleContentType = "application/vnd.cardano-cli.leadership-schedule+json"
+)
+
+// ScheduledSlot is a slot assigned to a pool as listed in the output of
+// 'cardano-cli query leadership-schedule'.
+type ScheduledSlot struct {
+	// SlotNumber is the slot number for which a block is assigned. This number
+	// is counted from the beginning of the chain.
+	SlotNumber uint `json:"slotNumber"`
+	// SlotTime is the starting time of the slot.
+	SlotTime time.Time `json:"slotTime"`
+}
+
+var (
+	// MissingEpochError is returned, when the epoch of a leader log can't be
+	// derived and hasn't been specified otherwise.
+	MissingEpochError = errors.New("the epoch of the leader log must be specified")
+	// PreShelleySlotError is returned, when an assigned slot is before the
+	// Shelley era, for which no leader logs exist.
+	PreShelleySlotError = errors.New("the assigned slots must be in the Shelley era or later")
+)
+
+// ParseLeadershipSchedule parses the output of the command 'cardano-cli query
+// leadership-schedule' from the given reader into a leader log object. This
+// output is a bare list of the assigned slots, which is why the pool ID is
+// taken from the given context, and the epoch as well as the epoch slots are
+// derived from the network parameters. The query parameter "epoch" must be
+// specified, if no slots have been assigned. The expected number of blocks can
+// optionally be specified with the query parameter "ideal".
+func ParseLeadershipSchedule(reader io.Reader, ctx *ParserContext) (*LeaderLog, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, ReadError
+	}
+	var slots []ScheduledSlot
+	err = json.Unmarshal(data, &slots)
+	if err != nil || slots == nil {
+		return nil, ParsingError
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].SlotNumber < slots[j].SlotNumber
+	})
+	if len(slots) > 0 && slots[0].SlotNumber < ctx.Network.ShelleyStartSlot {
+		return nil, PreShelleySlotError
+	}
+	var epoch uint
+	if epochParam := ctx.Params.Get("epoch"); epochParam != "" {
+		epochVal, err := strconv.ParseUint(epochParam, 10, 64)
+		if err != nil {
+			return nil, ParsingError
+		}
+		epoch = uint(epochVal)
+	} else if len(slots) > 0 {
+		epoch, _, _ = ctx.Network.EpochOfSlot(slots[0].SlotNumber)
+	} else {
+		return nil, MissingEpochError
+	}
+	leaderLog := &LeaderLog{
+		PoolID: ctx.PoolID,
+		Epoch:  epoch,
+		Blocks: make([]*AssignedBlock, len(slots)),
+	}
+	for i, slot := range slots {
+		_, epochSlot, _ := ctx.Network.EpochOfSlot(slot.SlotNumber)
+		leaderLog.Blocks[i] = &AssignedBlock{
+			No:        uint(i + 1),
+			Slot:      slot.SlotNumber,
+			EpochSlot: epochSlot,
+			Timestamp: slot.SlotTime,
+		}
+	}
+	if idealParam := ctx.Params.Get("ideal"); idealParam != "" {
+		ideal, err := strconv.ParseFloat(idealParam, 32)
+		if err != nil || ideal < 0 {
+			return nil, ParsingError
+		}
+		leaderLog.ExpectedBlockNumber = float32(ideal)
+		if ideal > 0 {
+			leaderLog.MaxPerformance = float32(float64(len(slots)) / ideal * 100)
+		}
+	}
+	return leaderLog, nil
+}
@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+)
+
+func TestParseLeadershipSchedule(t *testing.T) {
+	schedule := `[
+		{"slotNumber": 55902034, "slotTime": "2022-03-16T22:05:25Z"},
+		{"slotNumber": 55900900, "slotTime": "2022-03-16T21:46:31Z"}
+	]`
+	log, err := ParseLeadershipSchedule(strings.NewReader(schedule),
+		&ParserContext{PoolID: testPoolID, Network: chain.Mainnet,
+			Params: url.Values{"ideal": []string{"2"}}})
+	if err != nil {
+		t.Fatalf("expected the schedule to be parsed, got %v", err)
+	}
+	if log.Epoch != 327 || len(log.Blocks) != 2 {
+		t.Fatalf("expected two blocks in epoch 327, got %d in %d",
+			len(log.Blocks), log.Epoch)
+	}
+	if log.Blocks[0].No != 1 || log.Blocks[0].EpochSlot != 100 ||
+		log.Blocks[1].No != 2 || log.Blocks[1].EpochSlot != 1234 {
+		t.Fatalf("expected the blocks to be numbered by slot, got %+v and %+v",
+			log.Blocks[0], log.Blocks[1])
+	}
+	if verr := log.Validate(testPoolID, chain.Mainnet); verr != nil {
+		t.Fatalf("expected the parsed leader log to be valid, got %v",
+			verr.Errors)
+	}
+}
+
+func TestParseLeadershipScheduleRejectsPreShelleySlots(t *testing.T) {
+	schedule := `[{"slotNumber": 4492799, "slotTime": "2020-07-29T21:44:31Z"}]`
+	for _, params := range []url.Values{{}, {"epoch": []string{"208"}}} {
+		_, err := ParseLeadershipSchedule(strings.NewReader(schedule),
+			&ParserContext{PoolID: testPoolID, Network: chain.Mainnet,
+				Params: params})
+		if err != PreShelleySlotError {
+			t.Errorf("expected PreShelleySlotError with params %v, got %v",
+				params, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package dto
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+)
+
+// FieldError describes why a certain field of a leader log is invalid.
+type FieldError struct {
+	// Field is the JSON path of the invalid field (e.g. "assignedSlots[2].slot").
+	Field string `json:"field"`
+	// Message describes why the field is invalid.
+	Message string `json:"message"`
+}
+
+// ValidationError is returned, when a parsed leader log is inconsistent. It
+// lists all the invalid fields.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("the leader log is invalid (%d errors)", len(e.Errors))
+}
+
+// add adds an error for the given field with the formatted message.
+func (e *ValidationError) add(field string, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, FieldError{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Validate checks whether this leader log has been created for the pool with
+// the given ID, and whether it is consistent with the parameters of the given
+// network. A ValidationError listing all invalid fields will be returned, if
+// this isn't the case. Otherwise, nil will be returned.
+func (l *LeaderLog) Validate(poolID string, network *chain.Network) *ValidationError {
+	verr := &ValidationError{Errors: make([]FieldError, 0)}
+	if !strings.EqualFold(l.PoolID, poolID) {
+		verr.add("poolId", "must be the pool ID '%s' served by this API", poolID)
+	}
+	firstSlot, err := network.FirstSlotOfEpoch(l.Epoch)
+	validEpoch := err == nil
+	if !validEpoch {
+		verr.add("epoch", "must be specified and be an epoch of the Shelley era or later (>= %d)",
+			network.ShelleyStartEpoch)
+	}
+	if l.Blocks == nil {
+		verr.add("assignedSlots", "must be specified")
+	}
+	nos := make([]uint, 0, len(l.Blocks))
+	seenNos := make(map[uint]bool)
+	seenSlots := make(map[uint]int)
+	for i, block := range l.Blocks {
+		field := fmt.Sprintf("assignedSlots[%d]", i)
+		if block == nil {
+			verr.add(field, "must be an assigned slot")
+			continue
+		}
+		if seenNos[block.No] {
+			verr.add(field+".no", "must be unique, but %d is duplicated", block.No)
+		} else {
+			seenNos[block.No] = true
+			nos = append(nos, block.No)
+		}
+		if j, found := seenSlots[block.Slot]; found {
+			verr.add(field+".slot", "must be unique, but %d is already assigned in assignedSlots[%d]",
+				block.Slot, j)
+		} else {
+			seenSlots[block.Slot] = i
+		}
+		if !validEpoch {
+			continue
+		}
+		if block.Slot < firstSlot || block.Slot >= firstSlot+network.EpochLength {
+			verr.add(field+".slot", "must be in the slot range [%d, %d) of epoch %d",
+				firstSlot, firstSlot+network.EpochLength, l.Epoch)
+			continue
+		}
+		if block.EpochSlot != block.Slot-firstSlot {
+			verr.add(field+".slotInEpoch", "must be %d for slot %d",
+				block.Slot-firstSlot, block.Slot)
+		}
+		slotTime, err := network.SlotTime(block.Slot)
+		if err != nil || !block.Timestamp.Equal(slotTime) {
+			verr.add(field+".at", "must be %s for slot %d",
+				slotTime.Format(time.RFC3339), block.Slot)
+		}
+	}
+	sort.Slice(nos, func(i, j int) bool {
+		return nos[i] < nos[j]
+	})
+	for i, no := range nos {
+		if no != uint(i+1) {
+			verr.add("assignedSlots", "numbers must be contiguous starting at 1, but %d is missing",
+				i+1)
+			break
+		}
+	}
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
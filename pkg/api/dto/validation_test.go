@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+)
+
+const testPoolID = "9ff52e8f4d6c5a8a05b9a3dd5d8cf2d86d85b9e4ecc9a4cafa4a7e8b"
+
+func TestValidateRejectsPreShelleyEpoch(t *testing.T) {
+	log := &LeaderLog{
+		PoolID: testPoolID,
+		Epoch:  100,
+		Blocks: []*AssignedBlock{{No: 1, Slot: 100, EpochSlot: 100}},
+	}
+	verr := log.Validate(testPoolID, chain.Mainnet)
+	if verr == nil || len(verr.Errors) != 1 || verr.Errors[0].Field != "epoch" {
+		t.Fatalf("expected only the epoch to be invalid, got %v", verr)
+	}
+}
@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+)
+
+// WebhookDelivery is a notification of a webhook about the outcome of an
+// assigned block as it is exposed by the API.
+type WebhookDelivery struct {
+	// ID is the unique identifier of the delivery.
+	ID uint
+	// URL is the URL of the notified webhook.
+	URL string
+	// PoolID is the id in hex format of the pool, to which the block has been
+	// assigned.
+	PoolID string
+	// Epoch is the epoch for which the block has been scheduled.
+	Epoch uint
+	// No is the unique number of the block in an overall leader log.
+	No uint
+	// Status is the status of the assigned block, which has been notified.
+	Status db.BlockStatus
+	// State is the state of the delivery.
+	State db.DeliveryState
+	// Attempts is the number of failed attempts to deliver the notification.
+	Attempts uint
+	// NextAttempt is the time after which the next attempt is made. It is
+	// omitted, if no further attempt is made.
+	NextAttempt *time.Time `json:",omitempty"`
+	// LastError describes why the last attempt failed.
+	LastError string `json:",omitempty"`
+	// ResponseStatus is the HTTP status code of the last response of the
+	// receiver.
+	ResponseStatus int `json:",omitempty"`
+	// CreatedAt is the time at which the delivery has been queued.
+	CreatedAt time.Time
+	// UpdatedAt is the time at which the delivery has been updated the last
+	// time.
+	UpdatedAt time.Time
+}
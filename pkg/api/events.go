@@ -0,0 +1,295 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/api/dto"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const eventsPath = "events"
+
+const (
+	// eventPageSize is the number of events, which are read from the event log
+	// at once.
+	eventPageSize uint = 100
+	// keepAliveInterval is the interval in which a comment is sent to idle
+	// clients, such that proxies don't close the connection.
+	keepAliveInterval = 30 * time.Second
+)
+
+// eventBroker wakes up the clients of the event stream, whenever a change of
+// the leader logs of their pool has been published by the observer of a db.DB.
+// The clients then read the new events from the event log, in which the
+// changes have been recorded by the db.DB.
+type eventBroker struct {
+	lock    sync.Mutex
+	clients map[chan struct{}]string
+}
+
+// newEventBroker creates a new eventBroker without clients.
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		clients: make(map[chan struct{}]string),
+	}
+}
+
+// run wakes up the clients for the messages received on the given listener
+// until it is closed. Then all the clients are disconnected.
+func (b *eventBroker) run(listener <-chan db.ObserverMessage) {
+	for msg := range listener {
+		b.wakeUp(msg.PoolID())
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for client := range b.clients {
+		delete(b.clients, client)
+		close(client)
+	}
+}
+
+// wakeUp wakes up the clients of the pool with the given ID. A client, which
+// hasn't processed the previous wake-up yet, isn't woken up again.
+func (b *eventBroker) wakeUp(poolID string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for client, clientPoolID := range b.clients {
+		if clientPoolID != poolID {
+			continue
+		}
+		select {
+		case client <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// subscribe subscribes a new client for the pool with the given ID, and
+// returns the channel on which it is woken up. The channel is closed, if the
+// client shall be disconnected.
+func (b *eventBroker) subscribe(poolID string) chan struct{} {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	client := make(chan struct{}, 1)
+	b.clients[client] = poolID
+	return client
+}
+
+// unsubscribe unsubscribes the client with the given channel.
+func (b *eventBroker) unsubscribe(client chan struct{}) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if _, found := b.clients[client]; found {
+		delete(b.clients, client)
+		close(client)
+	}
+}
+
+// eventStream is the stream of events of a pool to a single client.
+type eventStream struct {
+	c      *gin.Context
+	db     db.DB
+	config *Configuration
+	policy RevealPolicy
+	loc    *time.Location
+	poolID string
+	lastID uint
+	// held is true, if the stream is held back at a block event, whose block
+	// can't be revealed yet.
+	held bool
+}
+
+// replay sends the events of the event log, which have been recorded after
+// the last sent event. The replay stops at a block event, which would reveal
+// the scheduled time of its block before the reveal policy allows, such that
+// the events are still sent in order.
+func (s *eventStream) replay() error {
+	for {
+		events, err := s.db.GetEventsAfter(s.c, s.poolID, s.lastID,
+			eventPageSize)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			revealed, held, err := s.reveal(event)
+			if err != nil {
+				return err
+			}
+			s.held = held
+			if held {
+				return nil
+			}
+			err = s.send(revealed)
+			if err != nil {
+				return err
+			}
+		}
+		if uint(len(events)) < eventPageSize {
+			return nil
+		}
+	}
+}
+
+// send sends the given event to the client in the format of server-sent
+// events.
+func (s *eventStream) send(event dto.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.c.Writer, "id: %d\nevent: %s\ndata: %s\n\n",
+		event.ID, event.Type, data)
+	if err != nil {
+		return err
+	}
+	s.c.Writer.Flush()
+	s.lastID = event.ID
+	return nil
+}
+
+// reveal transforms the given event into the representation that is exposed
+// by the API. The assigned block of a block event is revealed as it is
+// currently known. Since the time of a block event follows the slot of its
+// block closely, true is returned for a block event, which must be held back,
+// because its block is hidden, or because the day of it hasn't passed yet
+// and only its day can be revealed. The time of the event is then omitted.
+// Only the epoch and number of a block event are revealed, if its block isn't
+// registered anymore.
+func (s *eventStream) reveal(event db.Event) (dto.Event, bool, error) {
+	revealed := dto.Event{
+		ID:    event.ID,
+		Type:  event.Type,
+		Epoch: event.Epoch,
+	}
+	if event.Type != db.EventBlockStatus {
+		revealed.Timestamp = &event.Timestamp
+		return revealed, false, nil
+	}
+	blocks, err := s.db.GetAssignedBlocksBeforeNow(s.c, s.poolID, event.Epoch)
+	if err != nil {
+		return revealed, false, err
+	}
+	now := s.config.Clock.Now()
+	for _, block := range blocks {
+		if block.No != event.No {
+			continue
+		}
+		if !revealsPassedSlot(s.policy, &block, now, s.loc) {
+			return revealed, true, nil
+		}
+		precision := s.policy.Precision(&block, now)
+		if precision == PrecisionFull {
+			revealed.Timestamp = &event.Timestamp
+		}
+		status := event.Status
+		revealed.Status = &status
+		block := revealBlock(block, precision, s.loc)
+		revealed.Block = &block
+		return revealed, false, nil
+	}
+	return revealed, false, nil
+}
+
+// endOfDay returns the end of the day of the given time in the given
+// location.
+func endOfDay(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+}
+
+// keepAlive sends a comment to the client, such that the idle connection
+// isn't closed.
+func (s *eventStream) keepAlive() error {
+	_, err := fmt.Fprint(s.c.Writer, ": keep-alive\n\n")
+	if err != nil {
+		return err
+	}
+	s.c.Writer.Flush()
+	return nil
+}
+
+func getEvents(idb db.DB, config *Configuration,
+	policy RevealPolicy) func(router *gin.Engine) {
+
+	broker := newEventBroker()
+	listener := make(chan db.ObserverMessage)
+	idb.Observer().Sub(listener)
+	go broker.run(listener)
+	return func(router *gin.Engine) {
+		handlePoolRoute(router, http.MethodGet, eventsPath, config,
+			func(c *gin.Context, poolID string) {
+				loc, err := time.LoadLocation(c.Query("tz"))
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusBadRequest,
+						errorPayload(c, err.Error()))
+					return
+				}
+				var lastID uint64
+				lastEventID := c.GetHeader("Last-Event-ID")
+				if lastEventID != "" {
+					lastID, err = strconv.ParseUint(lastEventID, 10, 64)
+					if err != nil {
+						c.AbortWithStatusJSON(http.StatusBadRequest,
+							errorPayload(c, "Last-Event-ID couldn't be parsed"))
+						return
+					}
+				}
+				wakeUp := broker.subscribe(poolID)
+				defer broker.unsubscribe(wakeUp)
+				if lastEventID == "" {
+					id, err := idb.GetLastEventID(c, poolID)
+					if err != nil {
+						c.AbortWithStatusJSON(http.StatusInternalServerError,
+							errorPayload(c, err.Error()))
+						return
+					}
+					lastID = uint64(id)
+				}
+				stream := &eventStream{
+					c:      c,
+					db:     idb,
+					config: config,
+					policy: policy,
+					loc:    loc,
+					poolID: poolID,
+					lastID: uint(lastID),
+				}
+				c.Header("Content-Type", "text/event-stream")
+				c.Header("Cache-Control", "no-cache")
+				c.Header("Connection", "keep-alive")
+				c.Header("X-Accel-Buffering", "no")
+				c.Status(http.StatusOK)
+				c.Writer.Flush()
+				ticker := config.Clock.NewTicker(keepAliveInterval)
+				defer ticker.Stop()
+				err = stream.replay()
+				for err == nil {
+					select {
+					case _, ok := <-wakeUp:
+						if !ok {
+							return
+						}
+						err = stream.replay()
+					case <-ticker.C():
+						if stream.held {
+							err = stream.replay()
+						}
+						if err == nil {
+							err = stream.keepAlive()
+						}
+					case <-c.Request.Context().Done():
+						return
+					}
+				}
+				log.Debugf("the event stream of pool=%s has been closed: %s",
+					poolID, err.Error())
+			})
+	}
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/api/dto"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+)
+
+// quietPeriod is the duration, for which no event must be received to
+// consider the stream as held back.
+const quietPeriod = 200 * time.Millisecond
+
+// openEvents opens the event stream at the given URL, which is resumed after
+// the event with the given ID, if it isn't empty. The received events are sent
+// to the returned channel. It waits until the stream is idle on the given
+// clock.
+func openEvents(t *testing.T, url, lastID string,
+	clk *clock.Fake) <-chan dto.Event {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	events := make(chan dto.Event, 100)
+	go func() {
+		defer resp.Body.Close()
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var event dto.Event
+			err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")),
+				&event)
+			if err != nil {
+				return
+			}
+			events <- event
+		}
+	}()
+	// the ticker for the keep-alive is created before the first replay.
+	done := make(chan struct{})
+	go func() {
+		clk.BlockUntil(1)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(awaitTimeout):
+		t.Fatal("the event stream hasn't been opened")
+	}
+	return events
+}
+
+// nextEvent returns the next event received on the given stream. The test
+// fails, if no event is received in time.
+func nextEvent(t *testing.T, events <-chan dto.Event) dto.Event {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("the event stream has been closed")
+		}
+		return event
+	case <-time.After(awaitTimeout):
+		t.Fatal("no event has been received")
+	}
+	return dto.Event{}
+}
+
+// expectHeldBack fails the test, if an event is received on the given stream
+// within the quiet period.
+func expectHeldBack(t *testing.T, events <-chan dto.Event) {
+	t.Helper()
+	select {
+	case event := <-events:
+		t.Fatalf("expected the stream to be held back, got %+v", event)
+	case <-time.After(quietPeriod):
+	}
+}
+
+// expectEvent fails the test, if the given event doesn't have the given ID
+// and type.
+func expectEvent(t *testing.T, event dto.Event, id uint, typ db.EventType) {
+	t.Helper()
+	if event.ID != id || event.Type != typ {
+		t.Fatalf("expected event %d of type %s, got %+v", id, typ, event)
+	}
+}
+
+func TestEventsHoldBack(t *testing.T) {
+	epochEnd, err := chain.Mainnet.EpochEnd(327)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		policy RevealPolicy
+		// held is true, if the block event is held back at the start time.
+		held bool
+		// release is the time at which the block event is released.
+		release time.Time
+		// day is true, if only the day of the block is revealed.
+		day bool
+	}{
+		{policy: AfterSlotPolicy{}},
+		{policy: AfterDurationPolicy{Delay: time.Hour}, held: true,
+			release: slotTime(t, pastSlot).Add(time.Hour)},
+		{policy: DayGranularityPolicy{}, held: true,
+			release: time.Date(2022, 3, 17, 0, 0, 0, 0, time.UTC), day: true},
+		{policy: AfterEpochEndPolicy{Network: chain.Mainnet}, held: true,
+			release: epochEnd},
+	}
+	for _, test := range tests {
+		t.Run(test.policy.Name(), func(t *testing.T) {
+			clk := clock.NewFake(startTime)
+			idb := newTestDB(t, clk)
+			_, err := idb.WriteLeaderLog(context.Background(), &db.LeaderLog{
+				PoolID: poolA,
+				Epoch:  328,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := newTestServer(t, idb, clk, test.policy)
+			events := openEvents(t, server.URL+"/leaderlog/events", "0", clk)
+			expectEvent(t, nextEvent(t, events), 1, db.EventEpochRegistered)
+			if test.held {
+				expectHeldBack(t, events)
+				clk.Set(test.release.Add(-time.Second))
+				expectHeldBack(t, events)
+				// the held stream is replayed on the next keep-alive.
+				clk.Set(test.release)
+				clk.Advance(keepAliveInterval)
+			}
+			event := nextEvent(t, events)
+			expectEvent(t, event, 2, db.EventBlockStatus)
+			if event.Status == nil || *event.Status != db.Minted {
+				t.Fatalf("expected the status %d, got %+v", db.Minted, event)
+			}
+			if event.Block == nil || event.Block.No != 1 {
+				t.Fatalf("expected the first block, got %+v", event.Block)
+			}
+			if test.day {
+				if event.Timestamp != nil || event.Block.Slot != nil ||
+					event.Block.Timestamp != nil ||
+					event.Block.Day != "2022-03-16" {
+					t.Fatalf("expected only the day of the block, got %+v (%+v)",
+						event, event.Block)
+				}
+			} else {
+				if event.Timestamp == nil || !event.Timestamp.Equal(startTime) ||
+					event.Block.Slot == nil || *event.Block.Slot != pastSlot {
+					t.Fatalf("expected the exact time of the block, got %+v (%+v)",
+						event, event.Block)
+				}
+			}
+			expectEvent(t, nextEvent(t, events), 3, db.EventEpochRegistered)
+		})
+	}
+}
+
+func TestEventsOfDeletedLeaderLog(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	idb := newTestDB(t, clk)
+	server := newTestServer(t, idb, clk, AfterDurationPolicy{Delay: time.Hour})
+	events := openEvents(t, server.URL+"/leaderlog/events", "0", clk)
+	expectEvent(t, nextEvent(t, events), 1, db.EventEpochRegistered)
+	expectHeldBack(t, events)
+	_, err := idb.DeleteLeaderLog(context.Background(), poolA, 327)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := nextEvent(t, events)
+	expectEvent(t, event, 2, db.EventBlockStatus)
+	if event.Epoch != 327 || event.Timestamp != nil || event.Status != nil ||
+		event.Block != nil {
+		t.Fatalf("expected only the epoch of the block event, got %+v", event)
+	}
+	expectEvent(t, nextEvent(t, events), 3, db.EventEpochDeleted)
+}
+
+func TestEventsResume(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	idb := newTestDB(t, clk)
+	server := newTestServer(t, idb, clk, AfterSlotPolicy{})
+	events := openEvents(t, server.URL+"/leaderlog/events", "1", clk)
+	expectEvent(t, nextEvent(t, events), 2, db.EventBlockStatus)
+	_, err := idb.WriteLeaderLog(context.Background(), &db.LeaderLog{
+		PoolID: poolA,
+		Epoch:  328,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, nextEvent(t, events), 3, db.EventEpochRegistered)
+
+	req, err := http.NewRequest(http.MethodGet,
+		server.URL+"/leaderlog/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", "x")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an invalid Last-Event-ID, got %d",
+			resp.StatusCode)
+	}
+}
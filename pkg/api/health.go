@@ -1,13 +1,240 @@
 package api
 
 import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/api/dto"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain/fallback"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
 	"github.com/gin-gonic/gin"
 )
 
+const healthPath = "health"
+
+var (
+	DefaultHealthConfig = &HealthConfiguration{
+		MaxTipAge:    10 * time.Minute,
+		MaxSyncDelay: time.Hour,
+	}
+)
+
+// HealthConfiguration specifies when the components reported by the health
+// route are considered to be degraded.
+type HealthConfiguration struct {
+	// MaxTipAge is the age of the latest fetched tip after which the tip is
+	// degraded.
+	MaxTipAge time.Duration
+	// MaxSyncDelay is the duration after the slot of an assigned block after
+	// which the syncing of its pool is degraded, if the status of the block
+	// hasn't been gathered yet.
+	MaxSyncDelay time.Duration
+}
+
 // heartbeat returns just an "ok" status object in JSON format. It could be used
 // to monitor the reachability of this application.
 func heartbeat(router *gin.Engine) {
 	router.GET(getPath("heartbeat"), func(c *gin.Context) {
-		c.JSON(200, okPayload(nil))
+		c.JSON(200, okPayload(c, nil))
 	})
 }
+
+// componentHealth returns the health of a component, which is degraded, if the
+// given error isn't nil.
+func componentHealth(err error) dto.ComponentHealth {
+	if err != nil {
+		return dto.ComponentHealth{
+			Status: dto.HealthDegraded,
+			Error:  err.Error(),
+		}
+	}
+	return dto.ComponentHealth{Status: dto.HealthOK}
+}
+
+// checkTip checks the age of the latest tip fetched by the TipUpdater of the
+// given configuration at the given time.
+func checkTip(config *Configuration, now time.Time) *dto.TipHealth {
+	tip := config.TipUpdater.GetTip()
+	if tip == nil {
+		return &dto.TipHealth{
+			ComponentHealth: dto.ComponentHealth{
+				Status: dto.HealthDegraded,
+				Error:  "the tip hasn't been fetched yet",
+			},
+		}
+	}
+	timestamp := time.Unix(int64(tip.Timestamp), 0)
+	age := uint(0)
+	if now.After(timestamp) {
+		age = uint(now.Sub(timestamp) / time.Second)
+	}
+	health := &dto.TipHealth{
+		ComponentHealth: dto.ComponentHealth{Status: dto.HealthOK},
+		Epoch:           tip.Epoch,
+		Slot:            tip.Slot,
+		Hash:            tip.Hash,
+		Timestamp:       &timestamp,
+		AgeSeconds:      &age,
+	}
+	if now.Sub(timestamp) > config.Health.MaxTipAge {
+		health.Status = dto.HealthDegraded
+		health.Error = "the tip is older than " + config.Health.MaxTipAge.String()
+	}
+	return health
+}
+
+// checkBackend checks the backend of the given configuration. A backend
+// failing over between several backends is degraded, if none of them is
+// healthy.
+func checkBackend(config *Configuration) *dto.BackendHealth {
+	health := &dto.BackendHealth{
+		ComponentHealth: dto.ComponentHealth{Status: dto.HealthOK},
+		Name:            config.Backend.Name(),
+	}
+	if config.TipUpdater != nil {
+		if err := config.TipUpdater.GetLastError(); err != nil {
+			health.LastError = err.Error()
+		}
+	}
+	fb, ok := config.Backend.(interface{ Status() []fallback.Status })
+	if !ok {
+		return health
+	}
+	healthy := false
+	for _, status := range fb.Status() {
+		member := dto.FallbackHealth{
+			Name:    status.Name,
+			Healthy: status.Healthy,
+			Stale:   status.Stale,
+		}
+		if status.LastError != nil {
+			lastErrorTime := status.LastErrorTime
+			member.LastError = status.LastError.Error()
+			member.LastErrorTime = &lastErrorTime
+		}
+		healthy = healthy || status.Healthy
+		health.Backends = append(health.Backends, member)
+	}
+	if !healthy {
+		health.Status = dto.HealthDegraded
+		health.Error = "none of the backends is healthy"
+	}
+	return health
+}
+
+// checkPool checks the syncing of the pool with the given ID at the given
+// time, and looks up its next assigned block. Since the number of unsynced
+// blocks rises as soon as the slot of a block has passed, only the blocks are
+// counted, for which the given policy permits to reveal this. The next block
+// is revealed as permitted by the given policy.
+func checkPool(ctx context.Context, idb db.DB, config *Configuration,
+	policy RevealPolicy, poolID string, now time.Time) (*dto.PoolHealth, error) {
+
+	unsynced, err := getUnsyncedBlocks(ctx, idb, poolID)
+	if err != nil {
+		return nil, err
+	}
+	health := &dto.PoolHealth{
+		ComponentHealth: dto.ComponentHealth{Status: dto.HealthOK},
+		PoolID:          poolID,
+	}
+	for i := range unsynced {
+		block := &unsynced[i]
+		if !revealsPassedSlot(policy, block, now, time.UTC) {
+			continue
+		}
+		health.UnsyncedBlocks++
+		if now.Sub(block.Timestamp) > config.Health.MaxSyncDelay {
+			health.OverdueBlocks++
+		}
+	}
+	if health.OverdueBlocks > 0 {
+		health.Status = dto.HealthDegraded
+		health.Error = "the status of blocks hasn't been gathered within " +
+			config.Health.MaxSyncDelay.String()
+	}
+	upcoming, err := idb.GetAssignedBlocksAfterNow(ctx, poolID)
+	if err != nil {
+		return nil, err
+	}
+	var next *db.AssignedBlock
+	for i := range upcoming {
+		if next == nil || upcoming[i].Timestamp.Before(next.Timestamp) {
+			next = &upcoming[i]
+		}
+	}
+	if next != nil {
+		precision := policy.Precision(next, now)
+		revealed := revealBlock(*next, precision, time.UTC)
+		health.NextBlock = &revealed
+		if precision != PrecisionHidden {
+			days := daysBetween(now, next.Timestamp, time.UTC)
+			health.DaysUntilNextBlock = &days
+		}
+		if precision == PrecisionFull {
+			seconds := uint(next.Timestamp.Sub(now) / time.Second)
+			health.SecondsUntilNextBlock = &seconds
+		}
+	}
+	return health, nil
+}
+
+// daysBetween returns the number of days from the day of the given time to the
+// day of the other given time in the given location.
+func daysBetween(from, to time.Time, loc *time.Location) uint {
+	fromYear, fromMonth, fromDay := from.In(loc).Date()
+	toYear, toMonth, toDay := to.In(loc).Date()
+	days := time.Date(toYear, toMonth, toDay, 0, 0, 0, 0, time.UTC).Sub(
+		time.Date(fromYear, fromMonth, fromDay, 0, 0, 0, 0, time.UTC)).Hours() / 24
+	if days < 0 {
+		return 0
+	}
+	return uint(days)
+}
+
+// getHealth reports the health of the database, the tip, the backend and the
+// syncing of each served pool. The status 503 is returned, if any component
+// is degraded.
+func getHealth(idb db.DB, config *Configuration,
+	policy RevealPolicy) func(router *gin.Engine) {
+
+	return func(router *gin.Engine) {
+		router.GET(getPath(healthPath), func(c *gin.Context) {
+			now := requestTime(c)
+			health := dto.Health{
+				Database: componentHealth(idb.Ping(c)),
+			}
+			degraded := health.Database.Status != dto.HealthOK
+			if config.TipUpdater != nil {
+				health.Tip = checkTip(config, now)
+				degraded = degraded || health.Tip.Status != dto.HealthOK
+			}
+			if config.Backend != nil {
+				health.Backend = checkBackend(config)
+				degraded = degraded || health.Backend.Status != dto.HealthOK
+			}
+			if health.Database.Status == dto.HealthOK {
+				for _, poolID := range config.pools() {
+					pool, err := checkPool(c, idb, config, policy, poolID, now)
+					if err != nil {
+						health.Database = componentHealth(err)
+						degraded = true
+						break
+					}
+					health.Pools = append(health.Pools, *pool)
+					degraded = degraded || pool.Status != dto.HealthOK
+				}
+			}
+			if degraded {
+				c.JSON(http.StatusServiceUnavailable, gin.H{
+					"status":    dto.HealthDegraded,
+					"timestamp": now,
+					"response":  health,
+				})
+				return
+			}
+			c.JSON(200, okPayload(c, health))
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/api/dto"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	"github.com/blockblu-io/leaderlog-api/pkg/db/memdb"
+)
+
+func TestHealthNextBlock(t *testing.T) {
+	for _, policy := range testPolicies {
+		t.Run(policy.Name(), func(t *testing.T) {
+			clk := clock.NewFake(startTime)
+			server := newTestServer(t, newTestDB(t, clk), clk, policy)
+			var health dto.Health
+			status := getJSON(t, server.URL+"/leaderlog/health", &health)
+			if status != http.StatusOK || len(health.Pools) != 1 {
+				t.Fatalf("expected a healthy pool, got %d (%+v)", status, health)
+			}
+			pool := health.Pools[0]
+			next := pool.NextBlock
+			if next == nil || next.No != 2 {
+				t.Fatalf("expected the second block as next, got %+v", next)
+			}
+			if next.Slot != nil || next.Timestamp != nil ||
+				pool.SecondsUntilNextBlock != nil {
+				t.Fatalf("expected the time of the next block to be hidden, got %+v (%+v)",
+					pool, next)
+			}
+			_, day := policy.(DayGranularityPolicy)
+			if day {
+				if next.Day != "2022-03-17" || pool.DaysUntilNextBlock == nil ||
+					*pool.DaysUntilNextBlock != 1 {
+					t.Fatalf("expected the next block on the next day, got %+v (%+v)",
+						pool, next)
+				}
+			} else if next.Day != "" || pool.DaysUntilNextBlock != nil {
+				t.Fatalf("expected the day of the next block to be hidden, got %+v (%+v)",
+					pool, next)
+			}
+		})
+	}
+}
+
+func TestHealthUnsyncedBlocks(t *testing.T) {
+	tests := []struct {
+		policy RevealPolicy
+		// unsynced are the expected unsynced blocks at the start time, and an
+		// hour later, when the first block is overdue.
+		unsynced [2]uint
+	}{
+		{policy: testPolicies[0], unsynced: [2]uint{1, 1}},
+		{policy: testPolicies[1], unsynced: [2]uint{0, 1}},
+		{policy: testPolicies[2], unsynced: [2]uint{0, 1}},
+		{policy: testPolicies[3], unsynced: [2]uint{0, 0}},
+	}
+	for _, test := range tests {
+		t.Run(test.policy.Name(), func(t *testing.T) {
+			clk := clock.NewFake(startTime)
+			idb := memdb.NewMemDB(clk)
+			_, err := idb.WriteLeaderLog(context.Background(), &db.LeaderLog{
+				PoolID: poolA,
+				Epoch:  327,
+				Blocks: []db.AssignedBlock{
+					{Epoch: 327, No: 1, EpochSlot: pastSlot - 55900800,
+						Slot: pastSlot, Timestamp: slotTime(t, pastSlot)},
+					{Epoch: 327, No: 2, EpochSlot: futureSlot - 55900800,
+						Slot: futureSlot, Timestamp: slotTime(t, futureSlot)},
+				},
+				ExpectedBlockNumber: 2,
+				MaxPerformance:      1,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := newTestServer(t, idb, clk, test.policy)
+			for i, unsynced := range test.unsynced {
+				var health dto.Health
+				status := getJSON(t, server.URL+"/leaderlog/health", &health)
+				if len(health.Pools) != 1 {
+					t.Fatalf("expected the health of a pool, got %+v", health)
+				}
+				pool := health.Pools[0]
+				overdue := uint(0)
+				expectedStatus := http.StatusOK
+				if i == 1 && unsynced > 0 {
+					overdue = 1
+					expectedStatus = http.StatusServiceUnavailable
+				}
+				if status != expectedStatus || pool.UnsyncedBlocks != unsynced ||
+					pool.OverdueBlocks != overdue {
+					t.Fatalf("expected %d unsynced and %d overdue blocks with "+
+						"status %d at %s, got %d (%+v)", unsynced, overdue,
+						expectedStatus, clk.Now(), status, pool)
+				}
+				clk.Advance(time.Hour)
+			}
+		})
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	tests := []struct {
+		from, to time.Time
+		loc      *time.Location
+		days     uint
+	}{
+		{from: startTime, to: startTime.Add(30 * time.Minute), loc: time.UTC,
+			days: 0},
+		{from: startTime, to: startTime.Add(2 * time.Hour), loc: time.UTC,
+			days: 1},
+		{from: startTime, to: startTime.Add(2 * time.Hour), loc: loc, days: 0},
+		{from: startTime, to: startTime.Add(49 * time.Hour), loc: time.UTC,
+			days: 3},
+		{from: startTime, to: startTime.Add(-48 * time.Hour), loc: time.UTC,
+			days: 0},
+	}
+	for _, test := range tests {
+		days := daysBetween(test.from, test.to, test.loc)
+		if days != test.days {
+			t.Fatalf("expected %d days from %s to %s in %s, got %d", test.days,
+				test.from, test.to, test.loc, days)
+		}
+	}
+}
@@ -12,12 +12,20 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+const byDatePath = "epoch/:epoch/by/date"
+
 // groupByDates takes a look at the given leader log and groups the assigned
 // blocks by the day for which they are planned. The grouping is based on the
-// given location (i.e. dependent on timezone).
-func groupByDates(log *db.LeaderLog, loc *time.Location) map[time.Time][]uint {
+// given location (i.e. dependent on timezone). Blocks whose day mustn't be
+// revealed at the given time under the given policy are left out.
+func groupByDates(log *db.LeaderLog, policy RevealPolicy, now time.Time,
+	loc *time.Location) map[time.Time][]uint {
+
 	groupedDates := make(map[time.Time][]uint)
 	for _, block := range log.Blocks {
+		if policy.Precision(&block, now) == PrecisionHidden {
+			continue
+		}
 		t := block.Timestamp.In(loc)
 		key := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
 		list, found := groupedDates[key]
@@ -50,130 +58,296 @@ func groupByStatus(log *db.LeaderLog) map[db.BlockStatus]uint {
 func computeMaxPerformance(expectedBlockNumber float32,
 	statusMap map[db.BlockStatus]uint) float64 {
 
+	if expectedBlockNumber <= 0 {
+		return 0
+	}
 	val := statusMap[db.Minted] + statusMap[db.NotMinted]
 	return float64(val) / float64(expectedBlockNumber)
 }
 
-func handleLeaderLogFetching(db db.DB, c *gin.Context) (*db.LeaderLog, error) {
+func handleLeaderLogFetching(db db.DB, c *gin.Context,
+	poolID string) (*db.LeaderLog, error) {
+
 	epoch, err := strconv.Atoi(c.Param("epoch"))
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest,
-			errorPayload("epoch couldn't be parsed"))
+			errorPayload(c, "epoch couldn't be parsed"))
 		return nil, err
 	}
-	log, err := db.GetLeaderLog(c, uint(epoch))
+	log, err := db.GetLeaderLog(c, poolID, uint(epoch))
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError,
-			errorPayload(err.Error()))
+			errorPayload(c, err.Error()))
 		return nil, err
 	}
 	if log == nil {
 		c.AbortWithStatusJSON(http.StatusNotFound,
-			errorPayload("no log for epoch could be found"))
+			errorPayload(c, "no log for epoch could be found"))
 		return nil, fmt.Errorf("couldn't find a log for this epoch")
 	}
 	return log, nil
 }
 
-func getRegisteredEpochs(idb db.DB) func(router *gin.Engine) {
+func getRegisteredEpochs(idb db.DB, config *Configuration) func(router *gin.Engine) {
 	return func(router *gin.Engine) {
-		router.GET(getPath("epoch"), func(c *gin.Context) {
+		handlePoolRoute(router, http.MethodGet, "epoch", config, func(c *gin.Context,
+			poolID string) {
+
 			var limit uint = 10
 			limitParam := c.Query("limit")
 			if limitParam != "" {
 				limitVal, err := strconv.Atoi(limitParam)
 				if err != nil {
 					c.AbortWithStatusJSON(http.StatusBadRequest,
-						errorPayload("the given limit query parameter couldn't be parsed"))
+						errorPayload(c, "the given limit query parameter couldn't be parsed"))
 					return
 				}
 				limit = uint(limitVal)
 			}
-			epochs, err := idb.GetRegisteredEpochs(c, db.OrderingDesc, limit)
+			epochs, err := idb.GetRegisteredEpochs(c, poolID, db.OrderingDesc,
+				limit)
 			if err != nil {
 				c.AbortWithStatusJSON(http.StatusInternalServerError,
-					errorPayload(err.Error()))
+					errorPayload(c, err.Error()))
 				return
 			}
-			c.JSON(200, okPayload(epochs))
+			c.JSON(200, okPayload(c, epochs))
 		})
 	}
 }
 
-func getLeaderLogPerformance(idb db.DB) func(router *gin.Engine) {
+func getLeaderLogPerformance(idb db.DB, config *Configuration) func(router *gin.Engine) {
 	return func(router *gin.Engine) {
-		router.GET(getPath("epoch/:epoch/performance"),
-			func(c *gin.Context) {
-				log, err := handleLeaderLogFetching(idb, c)
+		handlePoolRoute(router, http.MethodGet, "epoch/:epoch/performance", config,
+			func(c *gin.Context, poolID string) {
+				log, err := handleLeaderLogFetching(idb, c, poolID)
 				if err != nil {
 					return
 				}
 				groupedMap := groupByStatus(log)
 				assignedBlock := len(log.Blocks)
-				c.JSON(200, okPayload(gin.H{
+				c.JSON(200, okPayload(c, gin.H{
 					"epoch":               log.Epoch,
 					"assignedBlocks":      assignedBlock,
 					"expectedBlockNumber": log.ExpectedBlockNumber,
 					"maxPerformance":      computeMaxPerformance(log.ExpectedBlockNumber, groupedMap),
-					"status": gin.H{
-						"notMinted":      groupedMap[db.NotMinted],
-						"minted":         groupedMap[db.Minted],
-						"doubleAssigned": groupedMap[db.DoubleAssignment],
-						"heightBattle":   groupedMap[db.HeightBattle],
-						"ghosted":        groupedMap[db.GHOSTED],
-					},
+					"status":              statusPayload(groupedMap),
 				}))
 			})
 	}
 }
 
-func getLeaderLogByDate(db db.DB) func(router *gin.Engine) {
+// statusPayload returns the given number of blocks with each status in the
+// representation that is exposed by the API.
+func statusPayload(statusMap map[db.BlockStatus]uint) gin.H {
+	return gin.H{
+		"notMinted":      statusMap[db.NotMinted],
+		"minted":         statusMap[db.Minted],
+		"doubleAssigned": statusMap[db.DoubleAssignment],
+		"heightBattle":   statusMap[db.HeightBattle],
+		"ghosted":        statusMap[db.GHOSTED],
+	}
+}
+
+// computePerformance computes the ratio of the minted blocks to the expected
+// blocks.
+func computePerformance(expectedBlockNumber float32, minted uint) float64 {
+	if expectedBlockNumber <= 0 {
+		return 0
+	}
+	return float64(minted) / float64(expectedBlockNumber)
+}
+
+func getPerformanceOfEpochs(idb db.DB, config *Configuration) func(router *gin.Engine) {
+	return func(router *gin.Engine) {
+		handlePoolRoute(router, http.MethodGet, "performance", config,
+			func(c *gin.Context, poolID string) {
+				from, ok := parseUintQuery(c, "from", 0)
+				if !ok {
+					return
+				}
+				var to uint
+				explicitTo := c.Query("to") != ""
+				if !explicitTo {
+					epochs, err := idb.GetRegisteredEpochs(c, poolID,
+						db.OrderingDesc, 1)
+					if err != nil {
+						c.AbortWithStatusJSON(http.StatusInternalServerError,
+							errorPayload(c, err.Error()))
+						return
+					}
+					if len(epochs) > 0 {
+						to = epochs[0]
+					}
+				} else if to, ok = parseUintQuery(c, "to", 0); !ok {
+					return
+				}
+				// the aggregation is empty, if no epoch has been registered
+				// from the given epoch on.
+				var performances []db.EpochPerformance
+				if from <= to {
+					var err error
+					performances, err = idb.GetPerformance(c, poolID, from, to)
+					if err != nil {
+						c.AbortWithStatusJSON(http.StatusInternalServerError,
+							errorPayload(c, err.Error()))
+						return
+					}
+				} else if explicitTo {
+					c.AbortWithStatusJSON(http.StatusBadRequest,
+						errorPayload(c, "the epoch range must not end before it starts"))
+					return
+				}
+				var assignedBlocks uint
+				var expectedBlockNumber float32
+				total := make(map[db.BlockStatus]uint)
+				epochs := make([]gin.H, len(performances))
+				for i, performance := range performances {
+					assignedBlocks += performance.AssignedBlocks
+					expectedBlockNumber += performance.ExpectedBlockNumber
+					for status, count := range performance.StatusCounts {
+						total[status] += count
+					}
+					epochs[i] = gin.H{
+						"epoch":               performance.Epoch,
+						"assignedBlocks":      performance.AssignedBlocks,
+						"expectedBlockNumber": performance.ExpectedBlockNumber,
+						"performance": computePerformance(
+							performance.ExpectedBlockNumber,
+							performance.StatusCounts[db.Minted]),
+						"cumulativeLuck": computePerformance(expectedBlockNumber,
+							total[db.Minted]),
+						"status": statusPayload(performance.StatusCounts),
+					}
+				}
+				c.JSON(200, okPayload(c, gin.H{
+					"from":                from,
+					"to":                  to,
+					"assignedBlocks":      assignedBlocks,
+					"expectedBlockNumber": expectedBlockNumber,
+					"performance": computePerformance(expectedBlockNumber,
+						total[db.Minted]),
+					"lostBlocks": total[db.DoubleAssignment] +
+						total[db.HeightBattle] + total[db.GHOSTED],
+					"status": statusPayload(total),
+					"epochs": epochs,
+				}))
+			})
+	}
+}
+
+func getLeaderLogByDate(db db.DB, config *Configuration,
+	policy RevealPolicy) func(router *gin.Engine) {
+
 	return func(router *gin.Engine) {
-		router.GET(getPath("epoch/:epoch/by/date"),
-			func(c *gin.Context) {
+		handlePoolRoute(router, http.MethodGet, byDatePath, config,
+			func(c *gin.Context, poolID string) {
 				loc, err := time.LoadLocation(c.Query("tz"))
 				if err != nil {
-					c.JSON(400, errorPayload(err.Error()))
+					c.JSON(400, errorPayload(c, err.Error()))
 					return
 				}
-				log, err := handleLeaderLogFetching(db, c)
+				log, err := handleLeaderLogFetching(db, c, poolID)
 				if err != nil {
 					return
 				}
-				c.JSON(200, okPayload(groupByDates(log, loc)))
+				dates := groupByDates(log, policy, requestTime(c), loc)
+				c.JSON(200, okPayload(c, dates))
 			})
 	}
 }
 
-func postLeaderLog(db db.DB, auth auth.Authenticator) func(router *gin.Engine) {
+func postLeaderLog(db db.DB, auth auth.Authenticator,
+	config *Configuration) func(router *gin.Engine) {
+
 	return func(router *gin.Engine) {
-		router.POST(getPath(""), func(c *gin.Context) {
-			username, password, ok := c.Request.BasicAuth()
-			if !ok || !auth.CheckAuthentication(username, password) {
-				c.AbortWithStatusJSON(http.StatusUnauthorized,
-					errorPayload("you aren't authorized to call this method"))
+		handlePoolRoute(router, http.MethodPost, "", config, func(c *gin.Context,
+			poolID string) {
+
+			if !checkAuthentication(c, auth) {
+				return
+			}
+			parser, err := dto.GetParser(c.Query("format"),
+				c.GetHeader("Content-Type"))
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest,
+					errorPayload(c, err.Error()))
 				return
 			}
 			reader := c.Request.Body
 			defer reader.Close()
-			log, err := dto.ParseLeaderLog(reader)
+			log, err := parser(reader, &dto.ParserContext{
+				PoolID:  poolID,
+				Network: config.Network,
+				Params:  c.Request.URL.Query(),
+			})
 			if err != nil {
-				if err == dto.ParsingError {
+				if err == dto.ParsingError || err == dto.MissingEpochError ||
+					err == dto.PreShelleySlotError {
 					c.AbortWithStatusJSON(http.StatusBadRequest,
-						errorPayload(err.Error()))
+						errorPayload(c, err.Error()))
 				} else {
 					c.AbortWithStatusJSON(http.StatusInternalServerError,
-						errorPayload(err.Error()))
+						errorPayload(c, err.Error()))
 				}
 				return
 			}
-			err = db.WriteLeaderLog(c, log.ToPlain())
+			verr := log.Validate(poolID, config.Network)
+			if verr != nil {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity,
+					validationErrorPayload(c, verr))
+				return
+			}
+			plainLog := log.ToPlain()
+			plainLog.PoolID = poolID
+			diff, err := db.WriteLeaderLog(c, plainLog)
 			if err != nil {
 				c.AbortWithStatusJSON(http.StatusInternalServerError,
-					errorPayload(err.Error()))
+					errorPayload(c, err.Error()))
 			} else {
-				c.JSON(200, okPayload(nil))
+				c.JSON(200, okPayload(c, gin.H{
+					"epoch":    diff.Epoch,
+					"replaced": diff.Replaced,
+					"slots": gin.H{
+						"added":   diff.Added,
+						"removed": diff.Removed,
+						"kept":    diff.Kept,
+					},
+				}))
 			}
 		})
 	}
 }
+
+func deleteLeaderLog(db db.DB, auth auth.Authenticator,
+	config *Configuration) func(router *gin.Engine) {
+
+	return func(router *gin.Engine) {
+		handlePoolRoute(router, http.MethodDelete, "epoch/:epoch", config,
+			func(c *gin.Context, poolID string) {
+				if !checkAuthentication(c, auth) {
+					return
+				}
+				epoch, err := strconv.Atoi(c.Param("epoch"))
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusBadRequest,
+						errorPayload(c, "epoch couldn't be parsed"))
+					return
+				}
+				found, err := db.DeleteLeaderLog(c, poolID, uint(epoch))
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusInternalServerError,
+						errorPayload(c, err.Error()))
+					return
+				}
+				if !found {
+					c.AbortWithStatusJSON(http.StatusNotFound,
+						errorPayload(c, "no log for epoch could be found"))
+					return
+				}
+				c.JSON(200, okPayload(c, gin.H{
+					"epoch": epoch,
+				}))
+			})
+	}
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/api/dto"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+)
+
+func TestPerformanceOfEpochs(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	server := newTestServer(t, newTestDB(t, clk), clk, AfterSlotPolicy{})
+	tests := []struct {
+		query  string
+		status int
+		epochs int
+	}{
+		{query: "", status: http.StatusOK, epochs: 1},
+		{query: "?from=327&to=327", status: http.StatusOK, epochs: 1},
+		// the range ends with the latest registered epoch by default, which
+		// is before the given start.
+		{query: "?from=328", status: http.StatusOK, epochs: 0},
+		{query: "?from=328&to=327", status: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		var response struct {
+			AssignedBlocks uint          `json:"assignedBlocks"`
+			Epochs         []interface{} `json:"epochs"`
+		}
+		status := getJSON(t, server.URL+"/leaderlog/performance"+test.query,
+			&response)
+		if status != test.status {
+			t.Fatalf("expected status %d for '%s', got %d", test.status,
+				test.query, status)
+		}
+		if status == http.StatusOK && len(response.Epochs) != test.epochs {
+			t.Fatalf("expected %d epochs for '%s', got %+v", test.epochs,
+				test.query, response)
+		}
+	}
+}
+
+// postJSON posts the given leader log with the given credentials to the
+// given URL, and decodes the response into the given value. The status code
+// of the response is returned.
+func postJSON(t *testing.T, url string, log *dto.LeaderLog, user,
+	pass string, v interface{}) int {
+
+	t.Helper()
+	body, err := json.Marshal(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(user, pass)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+// validLeaderLog returns a valid leader log of poolA for epoch 328 with two
+// assigned blocks.
+func validLeaderLog(t *testing.T) *dto.LeaderLog {
+	firstSlot, err := chain.Mainnet.FirstSlotOfEpoch(328)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &dto.LeaderLog{
+		PoolID: poolA,
+		Epoch:  328,
+		Blocks: []*dto.AssignedBlock{
+			{No: 1, Slot: firstSlot + 100, EpochSlot: 100,
+				Timestamp: slotTime(t, firstSlot+100)},
+			{No: 2, Slot: firstSlot + 200, EpochSlot: 200,
+				Timestamp: slotTime(t, firstSlot+200)},
+		},
+		ExpectedBlockNumber: 2,
+		MaxPerformance:      100,
+	}
+}
+
+func TestPostLeaderLogValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		// modify makes the valid leader log invalid.
+		modify func(log *dto.LeaderLog)
+		// fields are the fields, which are reported to be invalid.
+		fields []string
+	}{
+		{name: "valid", modify: func(*dto.LeaderLog) {}},
+		{name: "pool", modify: func(log *dto.LeaderLog) {
+			log.PoolID = "1d9302a3fb4b3b1935e02b27f0339798d3f08a55fbfdcd43a449a96f"
+		}, fields: []string{"poolId"}},
+		{name: "pre-shelley epoch", modify: func(log *dto.LeaderLog) {
+			log.Epoch = 207
+		}, fields: []string{"epoch"}},
+		{name: "missing slots", modify: func(log *dto.LeaderLog) {
+			log.Blocks = nil
+		}, fields: []string{"assignedSlots"}},
+		{name: "duplicate no", modify: func(log *dto.LeaderLog) {
+			log.Blocks[1].No = 1
+		}, fields: []string{"assignedSlots[1].no"}},
+		{name: "non-contiguous no", modify: func(log *dto.LeaderLog) {
+			log.Blocks[1].No = 3
+		}, fields: []string{"assignedSlots"}},
+		{name: "duplicate slot", modify: func(log *dto.LeaderLog) {
+			*log.Blocks[1] = *log.Blocks[0]
+			log.Blocks[1].No = 2
+		}, fields: []string{"assignedSlots[1].slot"}},
+		{name: "slot range", modify: func(log *dto.LeaderLog) {
+			log.Blocks[1].Slot += chain.Mainnet.EpochLength
+		}, fields: []string{"assignedSlots[1].slot"}},
+		{name: "slot in epoch", modify: func(log *dto.LeaderLog) {
+			log.Blocks[1].EpochSlot = 201
+		}, fields: []string{"assignedSlots[1].slotInEpoch"}},
+		{name: "timestamp", modify: func(log *dto.LeaderLog) {
+			log.Blocks[1].Timestamp = log.Blocks[1].Timestamp.Add(time.Second)
+		}, fields: []string{"assignedSlots[1].at"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clk := clock.NewFake(startTime)
+			idb := newTestDB(t, clk)
+			server := newTestServer(t, idb, clk, AfterSlotPolicy{})
+			log := validLeaderLog(t)
+			test.modify(log)
+			var response struct {
+				Status  string           `json:"status"`
+				Message string           `json:"message"`
+				Errors  []dto.FieldError `json:"errors"`
+			}
+			status := postJSON(t, server.URL+"/leaderlog", log, username,
+				password, &response)
+			if test.fields == nil {
+				if status != http.StatusOK || response.Status != "ok" {
+					t.Fatalf("expected the leader log to be accepted, got %d (%+v)",
+						status, response)
+				}
+				return
+			}
+			if status != http.StatusUnprocessableEntity ||
+				response.Status != "error" || response.Message == "" {
+				t.Fatalf("expected status 422, got %d (%+v)", status, response)
+			}
+			fields := make([]string, len(response.Errors))
+			for i, fieldErr := range response.Errors {
+				if fieldErr.Message == "" {
+					t.Errorf("expected a message for field %s", fieldErr.Field)
+				}
+				fields[i] = fieldErr.Field
+			}
+			if !reflect.DeepEqual(fields, test.fields) {
+				t.Fatalf("expected the invalid fields %v, got %+v", test.fields,
+					response.Errors)
+			}
+			epochs, err := idb.GetRegisteredEpochs(context.Background(), poolA,
+				db.OrderingDesc, 1)
+			if err != nil || len(epochs) != 1 || epochs[0] != 327 {
+				t.Fatalf("expected the invalid leader log not to be written, got %v (%v)",
+					epochs, err)
+			}
+		})
+	}
+}
+
+func TestPostLeaderLogUnauthorized(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	server := newTestServer(t, newTestDB(t, clk), clk, AfterSlotPolicy{})
+	var response struct {
+		Status string `json:"status"`
+	}
+	status := postJSON(t, server.URL+"/leaderlog", validLeaderLog(t),
+		username, "wrong", &response)
+	if status != http.StatusUnauthorized || response.Status != "error" {
+		t.Fatalf("expected status 401, got %d (%+v)", status, response)
+	}
+}
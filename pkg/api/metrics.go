@@ -0,0 +1,283 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/auth"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain/ratelimit"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	"github.com/blockblu-io/leaderlog-api/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// metricsEpochs is the number of the latest registered epochs of each
+	// pool, for which the per-epoch metrics are exposed.
+	metricsEpochs uint = 10
+	// unsyncedPageSize is the number of unsynced blocks, which are read from
+	// the database at once.
+	unsyncedPageSize uint = 100
+)
+
+// statusLabels are the values of the status label of the block metrics.
+var statusLabels = map[db.BlockStatus]string{
+	db.NotMinted:        "not_minted",
+	db.Minted:           "minted",
+	db.DoubleAssignment: "double_assigned",
+	db.HeightBattle:     "height_battle",
+	db.GHOSTED:          "ghosted",
+}
+
+// epochMetrics are the metrics of the leader log of a pool for an epoch.
+type epochMetrics struct {
+	poolID   string
+	epoch    uint
+	assigned int
+	expected float32
+	statuses map[db.BlockStatus]uint
+}
+
+// poolMetrics are the metrics of the syncing of a pool.
+type poolMetrics struct {
+	poolID   string
+	unsynced int
+	stuck    int
+}
+
+// backendMetrics are the metrics of the requests for a method of a backend.
+type backendMetrics struct {
+	backend string
+	method  string
+	metrics ratelimit.MethodMetrics
+}
+
+// collectEpochMetrics collects the metrics of the latest registered epochs of
+// the pool with the given ID.
+func collectEpochMetrics(ctx context.Context, idb db.DB,
+	poolID string) ([]epochMetrics, error) {
+
+	epochs, err := idb.GetRegisteredEpochs(ctx, poolID, db.OrderingDesc,
+		metricsEpochs)
+	if err != nil {
+		return nil, err
+	}
+	collected := make([]epochMetrics, 0, len(epochs))
+	for i := len(epochs) - 1; i >= 0; i-- {
+		log, err := idb.GetLeaderLog(ctx, poolID, epochs[i])
+		if err != nil {
+			return nil, err
+		}
+		if log == nil {
+			continue
+		}
+		collected = append(collected, epochMetrics{
+			poolID:   poolID,
+			epoch:    log.Epoch,
+			assigned: len(log.Blocks),
+			expected: log.ExpectedBlockNumber,
+			statuses: groupByStatus(log),
+		})
+	}
+	return collected, nil
+}
+
+// getUnsyncedBlocks gets the assigned blocks of the pool with the given ID
+// that have been planned before now, and whose status is still db.NotMinted.
+func getUnsyncedBlocks(ctx context.Context, idb db.DB,
+	poolID string) ([]db.AssignedBlock, error) {
+
+	var unsynced []db.AssignedBlock
+	for {
+		blocks, err := idb.GetAssignedBlocksWithStatusBeforeNow(ctx, poolID,
+			db.NotMinted, uint(len(unsynced)), unsyncedPageSize)
+		if err != nil {
+			return nil, err
+		}
+		unsynced = append(unsynced, blocks...)
+		if uint(len(blocks)) < unsyncedPageSize {
+			return unsynced, nil
+		}
+	}
+}
+
+// collectBackendMetrics collects the metrics of the requests of the given
+// backend, and of all the backends to which it delegates.
+func collectBackendMetrics(backend chain.Backend) []backendMetrics {
+	var collected []backendMetrics
+	if limited, ok := backend.(interface {
+		Metrics() map[string]ratelimit.MethodMetrics
+	}); ok {
+		for method, mm := range limited.Metrics() {
+			collected = append(collected, backendMetrics{
+				backend: backend.Name(),
+				method:  method,
+				metrics: mm,
+			})
+		}
+		sort.Slice(collected, func(i, j int) bool {
+			return collected[i].method < collected[j].method
+		})
+	}
+	if composite, ok := backend.(chain.Composite); ok {
+		for _, b := range composite.Backends() {
+			collected = append(collected, collectBackendMetrics(b)...)
+		}
+	}
+	return collected
+}
+
+// writeMetrics writes all the collected metrics with the given writer.
+func writeMetrics(w *metrics.Writer, config *Configuration, now time.Time,
+	epochs []epochMetrics, pools []poolMetrics) {
+
+	poolLabel := func(poolID string) metrics.Label {
+		return metrics.Label{Name: "pool_id", Value: poolID}
+	}
+	epochLabel := func(epoch uint) metrics.Label {
+		return metrics.Label{Name: "epoch",
+			Value: strconv.FormatUint(uint64(epoch), 10)}
+	}
+
+	w.Header("leaderlog_epoch_assigned_blocks", metrics.TypeGauge,
+		"Number of blocks assigned to the pool in the epoch.")
+	for _, e := range epochs {
+		w.Sample("leaderlog_epoch_assigned_blocks", float64(e.assigned),
+			poolLabel(e.poolID), epochLabel(e.epoch))
+	}
+	w.Header("leaderlog_epoch_expected_blocks", metrics.TypeGauge,
+		"Number of blocks the pool was expected to mint in the epoch.")
+	for _, e := range epochs {
+		w.Sample("leaderlog_epoch_expected_blocks", float64(e.expected),
+			poolLabel(e.poolID), epochLabel(e.epoch))
+	}
+	w.Header("leaderlog_epoch_blocks", metrics.TypeGauge,
+		"Number of blocks assigned to the pool in the epoch by status.")
+	for _, e := range epochs {
+		for status := db.NotMinted; status <= db.GHOSTED; status++ {
+			w.Sample("leaderlog_epoch_blocks", float64(e.statuses[status]),
+				poolLabel(e.poolID), epochLabel(e.epoch),
+				metrics.Label{Name: "status", Value: statusLabels[status]})
+		}
+	}
+
+	w.Header("leaderlog_unsynced_blocks", metrics.TypeGauge,
+		"Number of blocks assigned to the pool before now, whose status hasn't been gathered yet.")
+	for _, p := range pools {
+		w.Sample("leaderlog_unsynced_blocks", float64(p.unsynced),
+			poolLabel(p.poolID))
+	}
+	w.Header("leaderlog_stuck_blocks", metrics.TypeGauge,
+		"Number of blocks assigned to the pool, which are queued to be retried.")
+	for _, p := range pools {
+		w.Sample("leaderlog_stuck_blocks", float64(p.stuck),
+			poolLabel(p.poolID))
+	}
+	if len(config.Syncers) > 0 {
+		w.Header("leaderlog_processing_blocks", metrics.TypeGauge,
+			"Number of blocks assigned to the pool, which are currently being processed.")
+		for _, s := range config.Syncers {
+			m := s.Metrics()
+			w.Sample("leaderlog_processing_blocks", float64(m.Processing),
+				poolLabel(m.PoolID))
+		}
+		w.Header("leaderlog_classification_latency_seconds",
+			metrics.TypeHistogram,
+			"Duration from the slot of an assigned block to the classification of its status.")
+		for _, s := range config.Syncers {
+			m := s.Metrics()
+			w.Histogram("leaderlog_classification_latency_seconds", m.Latency,
+				poolLabel(m.PoolID))
+		}
+	}
+
+	if config.TipUpdater != nil {
+		if tip := config.TipUpdater.GetTip(); tip != nil {
+			w.Header("leaderlog_tip_age_seconds", metrics.TypeGauge,
+				"Age of the latest fetched tip of the chain.")
+			w.Sample("leaderlog_tip_age_seconds",
+				now.Sub(time.Unix(int64(tip.Timestamp), 0)).Seconds())
+		}
+	}
+
+	if config.Backend != nil {
+		requests := collectBackendMetrics(config.Backend)
+		labels := func(b backendMetrics) []metrics.Label {
+			return []metrics.Label{
+				{Name: "backend", Value: b.backend},
+				{Name: "method", Value: b.method},
+			}
+		}
+		w.Header("leaderlog_backend_requests_total", metrics.TypeCounter,
+			"Number of calls of a method of the chain backend.")
+		for _, b := range requests {
+			w.Sample("leaderlog_backend_requests_total",
+				float64(b.metrics.Calls), labels(b)...)
+		}
+		w.Header("leaderlog_backend_errors_total", metrics.TypeCounter,
+			"Number of calls of a method of the chain backend, which failed in the end.")
+		for _, b := range requests {
+			w.Sample("leaderlog_backend_errors_total",
+				float64(b.metrics.Failures), labels(b)...)
+		}
+		w.Header("leaderlog_backend_retries_total", metrics.TypeCounter,
+			"Number of retried requests for a method of the chain backend.")
+		for _, b := range requests {
+			w.Sample("leaderlog_backend_retries_total",
+				float64(b.metrics.Retries), labels(b)...)
+		}
+	}
+}
+
+// getMetrics exposes the metrics of the served pools and of this service in
+// the text format of Prometheus. The metrics require authentication, because
+// the counts of the blocks change as soon as the slot of an assigned block has
+// passed, which would disclose the slot regardless of the reveal policies.
+func getMetrics(idb db.DB, auth auth.Authenticator,
+	config *Configuration) func(router *gin.Engine) {
+
+	return func(router *gin.Engine) {
+		router.GET("/metrics", func(c *gin.Context) {
+			if !checkAuthentication(c, auth) {
+				return
+			}
+			var epochs []epochMetrics
+			var pools []poolMetrics
+			for _, poolID := range config.pools() {
+				poolEpochs, err := collectEpochMetrics(c, idb, poolID)
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusInternalServerError,
+						errorPayload(c, err.Error()))
+					return
+				}
+				epochs = append(epochs, poolEpochs...)
+				unsynced, err := getUnsyncedBlocks(c, idb, poolID)
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusInternalServerError,
+						errorPayload(c, err.Error()))
+					return
+				}
+				entries, err := idb.GetRetryEntries(c, poolID)
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusInternalServerError,
+						errorPayload(c, err.Error()))
+					return
+				}
+				pools = append(pools, poolMetrics{
+					poolID:   poolID,
+					unsynced: len(unsynced),
+					stuck:    len(entries),
+				})
+			}
+			var buffer bytes.Buffer
+			writeMetrics(metrics.NewWriter(&buffer), config,
+				config.Clock.Now(), epochs, pools)
+			c.Data(http.StatusOK, metrics.ContentType, buffer.Bytes())
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/metrics"
+)
+
+// getMetricsText requests the metrics of the given server with the given
+// credentials, and returns the status code and the body of the response.
+func getMetricsText(t *testing.T, url, user, pass string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url+"/metrics", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth(user, pass)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode == http.StatusOK &&
+		resp.Header.Get("Content-Type") != metrics.ContentType {
+		t.Fatalf("expected the content type of the text format, got '%s'",
+			resp.Header.Get("Content-Type"))
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	server := newTestServer(t, newTestDB(t, clk), clk, AfterSlotPolicy{})
+	status, _ := getMetricsText(t, server.URL, username, "wrong")
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %d", status)
+	}
+	status, body := getMetricsText(t, server.URL, username, password)
+	if status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+	for _, sample := range []string{
+		`leaderlog_epoch_assigned_blocks{pool_id="` + poolA + `",epoch="327"} 2`,
+		`leaderlog_epoch_blocks{pool_id="` + poolA + `",epoch="327",status="minted"} 1`,
+		`leaderlog_unsynced_blocks{pool_id="` + poolA + `"} 0`,
+		`leaderlog_stuck_blocks{pool_id="` + poolA + `"} 0`,
+	} {
+		if !strings.Contains(body, sample+"\n") {
+			t.Errorf("expected the sample '%s', got:\n%s", sample, body)
+		}
+	}
+}
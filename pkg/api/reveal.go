@@ -0,0 +1,246 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/api/dto"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+)
+
+// Precision specifies how precise the scheduled time of an assigned block can
+// be exposed by the API.
+type Precision uint
+
+const (
+	// PrecisionHidden exposes nothing about the scheduled time of a block.
+	PrecisionHidden Precision = 0
+	// PrecisionDay exposes only the day for which a block is scheduled.
+	PrecisionDay Precision = 1
+	// PrecisionFull exposes the exact slot and timestamp of a block.
+	PrecisionFull Precision = 2
+)
+
+// RevealPolicy decides how precise the scheduled time of an assigned block can
+// be exposed at a certain point in time.
+type RevealPolicy interface {
+
+	// Name returns the name of this policy.
+	Name() string
+
+	// Precision returns the precision with which the given assigned block can
+	// be exposed at the given time.
+	Precision(block *db.AssignedBlock, now time.Time) Precision
+}
+
+// AfterSlotPolicy reveals an assigned block as soon as its slot has passed.
+type AfterSlotPolicy struct{}
+
+func (p AfterSlotPolicy) Name() string {
+	return "after-slot"
+}
+
+func (p AfterSlotPolicy) Precision(block *db.AssignedBlock,
+	now time.Time) Precision {
+
+	if now.Before(block.Timestamp) {
+		return PrecisionHidden
+	}
+	return PrecisionFull
+}
+
+// AfterDurationPolicy reveals an assigned block only after the specified delay
+// has passed since its slot.
+type AfterDurationPolicy struct {
+	Delay time.Duration
+}
+
+func (p AfterDurationPolicy) Name() string {
+	return fmt.Sprintf("after-minutes:%d", int(p.Delay.Minutes()))
+}
+
+func (p AfterDurationPolicy) Precision(block *db.AssignedBlock,
+	now time.Time) Precision {
+
+	if now.Before(block.Timestamp.Add(p.Delay)) {
+		return PrecisionHidden
+	}
+	return PrecisionFull
+}
+
+// DayGranularityPolicy reveals only the day for which an assigned block is
+// scheduled, regardless of whether its slot has passed or not.
+type DayGranularityPolicy struct{}
+
+func (p DayGranularityPolicy) Name() string {
+	return "day"
+}
+
+func (p DayGranularityPolicy) Precision(*db.AssignedBlock,
+	time.Time) Precision {
+
+	return PrecisionDay
+}
+
+// AfterEpochEndPolicy reveals an assigned block only after the epoch for which
+// it has been scheduled has ended.
+type AfterEpochEndPolicy struct {
+	Network *chain.Network
+}
+
+func (p AfterEpochEndPolicy) Name() string {
+	return "after-epoch"
+}
+
+func (p AfterEpochEndPolicy) Precision(block *db.AssignedBlock,
+	now time.Time) Precision {
+
+	epochEnd, err := p.Network.EpochEnd(block.Epoch)
+	if err != nil || now.Before(epochEnd) {
+		return PrecisionHidden
+	}
+	return PrecisionFull
+}
+
+// ParseRevealPolicy parses the given textual representation of a reveal
+// policy. The following values are supported: "after-slot", "after-minutes:N",
+// "day" and "after-epoch". An error will be returned, if the value doesn't
+// match any of them.
+func ParseRevealPolicy(value string, network *chain.Network) (RevealPolicy, error) {
+	switch {
+	case value == "after-slot":
+		return AfterSlotPolicy{}, nil
+	case value == "day":
+		return DayGranularityPolicy{}, nil
+	case value == "after-epoch":
+		return AfterEpochEndPolicy{Network: network}, nil
+	case strings.HasPrefix(value, "after-minutes:"):
+		minutes, err := strconv.Atoi(strings.TrimPrefix(value, "after-minutes:"))
+		if err != nil || minutes < 0 {
+			return nil, fmt.Errorf("the minutes of reveal policy '%s' couldn't be parsed",
+				value)
+		}
+		return AfterDurationPolicy{Delay: time.Duration(minutes) * time.Minute}, nil
+	}
+	return nil, fmt.Errorf("the reveal policy '%s' is unknown", value)
+}
+
+// RevealRoutes are the relative paths of the routes exposing assigned blocks,
+// for which a reveal policy can be configured.
+var RevealRoutes = []string{
+	byDatePath,
+	blocksBeforeNowPath,
+	eventsPath,
+	healthPath,
+}
+
+// IsRevealRoute checks whether the given relative path is one of the
+// RevealRoutes.
+func IsRevealRoute(relativePath string) bool {
+	for _, route := range RevealRoutes {
+		if route == relativePath {
+			return true
+		}
+	}
+	return false
+}
+
+// RevealConfiguration specifies the reveal policy for each route of the API
+// that exposes assigned blocks.
+type RevealConfiguration struct {
+	// Default is the policy for all routes without a specific policy.
+	Default RevealPolicy
+	// Routes maps the relative path of a route (e.g. "epoch/:epoch/by/date")
+	// to the policy that shall be applied for this route.
+	Routes map[string]RevealPolicy
+}
+
+// NewDefaultRevealConfiguration creates a new RevealConfiguration that reveals
+// assigned blocks after their slot has passed, but exposes the day of future
+// blocks for the route grouping them by date.
+func NewDefaultRevealConfiguration() *RevealConfiguration {
+	return &RevealConfiguration{
+		Default: AfterSlotPolicy{},
+		Routes: map[string]RevealPolicy{
+			byDatePath: DayGranularityPolicy{},
+		},
+	}
+}
+
+// policy returns the reveal policy for the route with the given relative path.
+func (conf *RevealConfiguration) policy(relativePath string) RevealPolicy {
+	if p, found := conf.Routes[relativePath]; found {
+		return p
+	}
+	if conf.Default != nil {
+		return conf.Default
+	}
+	return AfterSlotPolicy{}
+}
+
+// revealsPassedSlot returns true, if the given policy permits to reveal at the
+// given time that the slot of the given block has passed. This is the case, if
+// the exact time of the block can be revealed, or if only its day can be
+// revealed and the day has ended in the given location.
+func revealsPassedSlot(policy RevealPolicy, block *db.AssignedBlock,
+	now time.Time, loc *time.Location) bool {
+
+	switch policy.Precision(block, now) {
+	case PrecisionFull:
+		return true
+	case PrecisionDay:
+		return !now.Before(endOfDay(block.Timestamp, loc))
+	}
+	return false
+}
+
+// revealBlock transforms the given assigned block into the representation
+// that is exposed by the API with the given precision. The day of a block is
+// computed for the given location.
+func revealBlock(block db.AssignedBlock, precision Precision,
+	loc *time.Location) dto.RevealedBlock {
+
+	revealed := dto.RevealedBlock{
+		Epoch:  block.Epoch,
+		No:     block.No,
+		Status: block.Status,
+	}
+	switch precision {
+	case PrecisionFull:
+		revealed.EpochSlot = &block.EpochSlot
+		revealed.Slot = &block.Slot
+		revealed.Timestamp = &block.Timestamp
+		revealed.RelevantBlock = revealMintedBlock(block.RelevantBlock)
+	case PrecisionDay:
+		revealed.Day = block.Timestamp.In(loc).Format("2006-01-02")
+	}
+	return revealed
+}
+
+// revealMintedBlock transforms the given minted block into the representation
+// that is exposed by the API. Nil is returned, if the given block is nil.
+func revealMintedBlock(block *db.MintedBlock) *dto.MintedBlock {
+	if block == nil {
+		return nil
+	}
+	revealed := &dto.MintedBlock{MintedBlock: *block}
+	if bech32ID, err := chain.PoolIDToBech32(block.PoolID); err == nil {
+		revealed.PoolBech32ID = bech32ID
+	}
+	return revealed
+}
+
+// revealBlocks transforms the given assigned blocks into the representation
+// that is exposed by the API under the given policy at the given time.
+func revealBlocks(policy RevealPolicy, blocks []db.AssignedBlock,
+	now time.Time, loc *time.Location) []dto.RevealedBlock {
+
+	revealed := make([]dto.RevealedBlock, len(blocks))
+	for i, block := range blocks {
+		revealed[i] = revealBlock(block, policy.Precision(&block, now), loc)
+	}
+	return revealed
+}
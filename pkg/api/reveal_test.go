@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/api/dto"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+)
+
+func TestByDateReveal(t *testing.T) {
+	epochEnd, err := chain.Mainnet.EpochEnd(327)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := map[string][]uint{
+		"2022-03-16T00:00:00Z": {1},
+		"2022-03-17T00:00:00Z": {2},
+	}
+	tests := []struct {
+		policy RevealPolicy
+		// dates are the revealed blocks grouped by date at the start time.
+		dates map[string][]uint
+	}{
+		{policy: AfterSlotPolicy{},
+			dates: map[string][]uint{"2022-03-16T00:00:00Z": {1}}},
+		{policy: AfterDurationPolicy{Delay: time.Hour},
+			dates: map[string][]uint{}},
+		{policy: DayGranularityPolicy{}, dates: all},
+		{policy: AfterEpochEndPolicy{Network: chain.Mainnet},
+			dates: map[string][]uint{}},
+	}
+	for _, test := range tests {
+		t.Run(test.policy.Name(), func(t *testing.T) {
+			clk := clock.NewFake(startTime)
+			server := newTestServer(t, newTestDB(t, clk), clk, test.policy)
+			url := server.URL + "/leaderlog/epoch/327/by/date"
+			var dates map[string][]uint
+			status := getJSON(t, url, &dates)
+			if status != http.StatusOK || !reflect.DeepEqual(dates, test.dates) {
+				t.Fatalf("expected %v, got %d (%v)", test.dates, status, dates)
+			}
+			// all blocks are revealed once the epoch has ended.
+			clk.Set(epochEnd)
+			dates = nil
+			status = getJSON(t, url, &dates)
+			if status != http.StatusOK || !reflect.DeepEqual(dates, all) {
+				t.Fatalf("expected %v after the epoch, got %d (%v)", all, status,
+					dates)
+			}
+		})
+	}
+}
+
+func TestBlocksBeforeNowReveal(t *testing.T) {
+	tests := []struct {
+		policy    RevealPolicy
+		precision Precision
+	}{
+		{policy: AfterSlotPolicy{}, precision: PrecisionFull},
+		{policy: AfterDurationPolicy{Delay: time.Hour},
+			precision: PrecisionHidden},
+		{policy: DayGranularityPolicy{}, precision: PrecisionDay},
+		{policy: AfterEpochEndPolicy{Network: chain.Mainnet},
+			precision: PrecisionHidden},
+	}
+	for _, test := range tests {
+		t.Run(test.policy.Name(), func(t *testing.T) {
+			clk := clock.NewFake(startTime)
+			server := newTestServer(t, newTestDB(t, clk), clk, test.policy)
+			var blocks []dto.RevealedBlock
+			status := getJSON(t,
+				server.URL+"/leaderlog/epoch/327/blocks/before/now", &blocks)
+			if status != http.StatusOK || len(blocks) != 1 {
+				t.Fatalf("expected the first block, got %d (%+v)", status, blocks)
+			}
+			block := blocks[0]
+			if block.No != 1 || block.Status != db.Minted {
+				t.Fatalf("expected the first block to be minted, got %+v", block)
+			}
+			full := block.Slot != nil && *block.Slot == pastSlot &&
+				block.EpochSlot != nil && block.Timestamp != nil &&
+				block.RelevantBlock != nil &&
+				block.RelevantBlock.Hash == "minted" && block.Day == ""
+			hidden := block.Slot == nil && block.EpochSlot == nil &&
+				block.Timestamp == nil && block.RelevantBlock == nil
+			switch test.precision {
+			case PrecisionFull:
+				if !full {
+					t.Fatalf("expected the block to be fully revealed, got %+v",
+						block)
+				}
+			case PrecisionDay:
+				if !hidden || block.Day != "2022-03-16" {
+					t.Fatalf("expected only the day of the block, got %+v", block)
+				}
+			default:
+				if !hidden || block.Day != "" {
+					t.Fatalf("expected the block to be hidden, got %+v", block)
+				}
+			}
+		})
+	}
+}
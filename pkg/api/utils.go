@@ -1,28 +1,97 @@
 package api
 
 import (
+	"github.com/blockblu-io/leaderlog-api/pkg/api/dto"
+	"github.com/blockblu-io/leaderlog-api/pkg/auth"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
 	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
 	"time"
 )
 
-func okPayload(v interface{}) gin.H {
+// checkAuthentication checks the basic authentication credentials of the given
+// request. If they aren't valid, the request is aborted with an unauthorized
+// status and false is returned.
+func checkAuthentication(c *gin.Context, auth auth.Authenticator) bool {
+	username, password, ok := c.Request.BasicAuth()
+	if !ok || !auth.CheckAuthentication(username, password) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized,
+			errorPayload(c, "you aren't authorized to call this method"))
+		return false
+	}
+	return true
+}
+
+// requestTimeKey is the key of the gin context, under which the time of the
+// request is stored.
+const requestTimeKey = "requestTime"
+
+// stampRequestTime is a middleware, which stores the current time of the given
+// clock.Clock in the context of each request.
+func stampRequestTime(clk clock.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(requestTimeKey, clk.Now())
+		c.Next()
+	}
+}
+
+// requestTime returns the time at which the given request has been received.
+// It is the time, which is "now" for all the reveal policies applied in the
+// request. The time of the system is used like for a Configuration without
+// clock, if the time hasn't been stored for the request.
+func requestTime(c *gin.Context) time.Time {
+	if t, ok := c.Get(requestTimeKey); ok {
+		return t.(time.Time)
+	}
+	return clock.Real.Now()
+}
+
+func okPayload(c *gin.Context, v interface{}) gin.H {
 	if v == nil {
 		return gin.H{
 			"status":    "ok",
-			"timestamp": time.Now(),
+			"timestamp": requestTime(c),
 		}
 	}
 	return gin.H{
 		"status":    "ok",
-		"timestamp": time.Now(),
+		"timestamp": requestTime(c),
 		"response":  v,
 	}
 }
 
-func errorPayload(message string) gin.H {
+func errorPayload(c *gin.Context, message string) gin.H {
 	return gin.H{
 		"status":    "error",
 		"message":   message,
-		"timestamp": time.Now(),
+		"timestamp": requestTime(c),
+	}
+}
+
+func validationErrorPayload(c *gin.Context, err *dto.ValidationError) gin.H {
+	return gin.H{
+		"status":    "error",
+		"message":   err.Error(),
+		"errors":    err.Errors,
+		"timestamp": requestTime(c),
+	}
+}
+
+// parseUintQuery parses the query parameter with the given name as unsigned
+// integer. The given default value is returned, if the parameter is missing.
+// If it can't be parsed, the request is aborted with a bad request status and
+// false is returned.
+func parseUintQuery(c *gin.Context, name string, defaultValue uint) (uint, bool) {
+	param := c.Query(name)
+	if param == "" {
+		return defaultValue, true
+	}
+	value, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			errorPayload(c, "the given "+name+" query parameter couldn't be parsed"))
+		return 0, false
 	}
+	return uint(value), true
 }
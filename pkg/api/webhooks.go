@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/api/dto"
+	"github.com/blockblu-io/leaderlog-api/pkg/auth"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	"github.com/gin-gonic/gin"
+)
+
+func getWebhookDeliveries(idb db.DB, auth auth.Authenticator) func(router *gin.Engine) {
+	return func(router *gin.Engine) {
+		router.GET(getPath("webhooks/deliveries"), func(c *gin.Context) {
+			if !checkAuthentication(c, auth) {
+				return
+			}
+			offset, ok := parseUintQuery(c, "offset", 0)
+			if !ok {
+				return
+			}
+			limit, ok := parseUintQuery(c, "limit", 100)
+			if !ok {
+				return
+			}
+			entries, err := idb.GetWebhookDeliveries(c, offset, limit)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError,
+					errorPayload(c, err.Error()))
+				return
+			}
+			deliveries := make([]dto.WebhookDelivery, len(entries))
+			for i, entry := range entries {
+				deliveries[i] = dto.WebhookDelivery{
+					ID:             entry.ID,
+					URL:            entry.URL,
+					PoolID:         entry.PoolID,
+					Epoch:          entry.Epoch,
+					No:             entry.No,
+					Status:         entry.Status,
+					State:          entry.State,
+					Attempts:       entry.Attempts,
+					LastError:      entry.LastError,
+					ResponseStatus: entry.ResponseStatus,
+					CreatedAt:      entry.CreatedAt,
+					UpdatedAt:      entry.UpdatedAt,
+				}
+				if entry.State == db.DeliveryPending {
+					nextAttempt := entry.NextAttempt
+					deliveries[i].NextAttempt = &nextAttempt
+				}
+			}
+			c.JSON(200, okPayload(c, deliveries))
+		})
+	}
+}
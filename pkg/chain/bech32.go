@@ -0,0 +1,154 @@
+package chain
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// poolIDPrefix is the human-readable prefix of pool IDs in bech32 format.
+const poolIDPrefix = "pool"
+
+// bech32Charset is the alphabet of the data part of bech32 strings.
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// PoolIDToHex converts the given pool ID in bech32 (e.g. 'pool1...') or hex
+// format into the hex format. An error will be returned, if the given ID is
+// in neither of the two formats.
+func PoolIDToHex(poolID string) (string, error) {
+	if data, err := hex.DecodeString(poolID); err == nil && len(data) > 0 {
+		return strings.ToLower(poolID), nil
+	}
+	prefix, data, err := decodeBech32(poolID)
+	if err != nil {
+		return "", fmt.Errorf("the pool ID '%s' is neither in bech32 nor in hex format: %s",
+			poolID, err.Error())
+	}
+	if prefix != poolIDPrefix {
+		return "", fmt.Errorf("the pool ID '%s' must have the prefix '%s'",
+			poolID, poolIDPrefix)
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// PoolIDToBech32 converts the given pool ID in hex format into the bech32
+// format (e.g. 'pool1...'). An error will be returned, if the given ID isn't
+// in hex format.
+func PoolIDToBech32(poolID string) (string, error) {
+	data, err := hex.DecodeString(poolID)
+	if err != nil || len(data) == 0 {
+		return "", fmt.Errorf("the pool ID '%s' isn't in hex format", poolID)
+	}
+	return encodeBech32(poolIDPrefix, data), nil
+}
+
+// bech32Polymod computes the checksum of bech32 over the given values.
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd,
+		0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// bech32ExpandPrefix expands the given human-readable prefix for computing
+// the checksum.
+func bech32ExpandPrefix(prefix string) []byte {
+	expanded := make([]byte, 0, len(prefix)*2+1)
+	for i := 0; i < len(prefix); i++ {
+		expanded = append(expanded, prefix[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(prefix); i++ {
+		expanded = append(expanded, prefix[i]&31)
+	}
+	return expanded
+}
+
+// convertBits regroups the given data from groups of the given number of bits
+// into groups of the other given number of bits. The last group is padded
+// with zeros, if padding is requested. Otherwise, an error is returned for
+// non-zero padding.
+func convertBits(data []byte, from, to uint, pad bool) ([]byte, error) {
+	acc := uint32(0)
+	bits := uint(0)
+	maxValue := uint32(1)<<to - 1
+	converted := make([]byte, 0, len(data)*int(from)/int(to)+1)
+	for _, v := range data {
+		if uint32(v)>>from != 0 {
+			return nil, fmt.Errorf("invalid data range")
+		}
+		acc = acc<<from | uint32(v)
+		bits += from
+		for bits >= to {
+			bits -= to
+			converted = append(converted, byte(acc>>bits&maxValue))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			converted = append(converted, byte(acc<<(to-bits)&maxValue))
+		}
+	} else if bits >= from || acc<<(to-bits)&maxValue != 0 {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	return converted, nil
+}
+
+// encodeBech32 encodes the given data with the given human-readable prefix
+// in bech32 format.
+func encodeBech32(prefix string, data []byte) string {
+	values, _ := convertBits(data, 8, 5, true)
+	checksumInput := append(bech32ExpandPrefix(prefix), values...)
+	checksumInput = append(checksumInput, 0, 0, 0, 0, 0, 0)
+	polymod := bech32Polymod(checksumInput) ^ 1
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	sb.WriteByte('1')
+	for _, v := range values {
+		sb.WriteByte(bech32Charset[v])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+	return sb.String()
+}
+
+// decodeBech32 decodes the given string in bech32 format into its
+// human-readable prefix and its data. An error will be returned, if the
+// string isn't valid.
+func decodeBech32(s string) (string, []byte, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, fmt.Errorf("mixed case")
+	}
+	s = strings.ToLower(s)
+	separator := strings.LastIndexByte(s, '1')
+	if separator < 1 || separator+7 > len(s) {
+		return "", nil, fmt.Errorf("invalid separator position")
+	}
+	prefix := s[:separator]
+	values := make([]byte, 0, len(s)-separator-1)
+	for i := separator + 1; i < len(s); i++ {
+		v := strings.IndexByte(bech32Charset, s[i])
+		if v < 0 {
+			return "", nil, fmt.Errorf("invalid character '%c'", s[i])
+		}
+		values = append(values, byte(v))
+	}
+	if bech32Polymod(append(bech32ExpandPrefix(prefix), values...)) != 1 {
+		return "", nil, fmt.Errorf("invalid checksum")
+	}
+	data, err := convertBits(values[:len(values)-6], 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return prefix, data, nil
+}
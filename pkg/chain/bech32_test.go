@@ -0,0 +1,92 @@
+package chain
+
+import (
+	"strings"
+	"testing"
+)
+
+// knownPoolIDs are the IDs of pools on the mainnet in hex and bech32 format.
+var knownPoolIDs = []struct {
+	hex    string
+	bech32 string
+}{
+	{"cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214b",
+		"pool1ekhy5xsgjaq38em75vevk8df0k0rljju77tljw288ys5kumqce5"},
+	{"1d9302a3fb4b3b1935e02b27f0339798d3f08a55fbfdcd43a449a96f",
+		"pool1rkfs9glmfva3jd0q9vnlqvuhnrflpzj4l07u6sayfx5k7d788us"},
+	{"0f292fcaa02b8b2f9b3c8f9fd8e0bb21abedb692a6d5058df3ef2735",
+		"pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"},
+}
+
+func TestPoolIDToBech32(t *testing.T) {
+	for _, id := range knownPoolIDs {
+		for _, hexID := range []string{id.hex, strings.ToUpper(id.hex)} {
+			bech32ID, err := PoolIDToBech32(hexID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bech32ID != id.bech32 {
+				t.Errorf("expected %s for %s, got %s", id.bech32, hexID,
+					bech32ID)
+			}
+		}
+	}
+	for _, invalid := range []string{"", "pool", knownPoolIDs[0].bech32,
+		"cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214"} {
+
+		if _, err := PoolIDToBech32(invalid); err == nil {
+			t.Errorf("expected '%s' to be rejected", invalid)
+		}
+	}
+}
+
+func TestPoolIDToHex(t *testing.T) {
+	for _, id := range knownPoolIDs {
+		for _, poolID := range []string{id.hex, strings.ToUpper(id.hex),
+			id.bech32, strings.ToUpper(id.bech32)} {
+
+			hexID, err := PoolIDToHex(poolID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hexID != id.hex {
+				t.Errorf("expected %s for %s, got %s", id.hex, poolID, hexID)
+			}
+		}
+	}
+}
+
+func TestPoolIDToHexRejectsInvalidIDs(t *testing.T) {
+	valid := knownPoolIDs[0].bech32
+	tests := map[string]string{
+		"checksum":  valid[:len(valid)-1] + "x",
+		"mixed":     "Pool" + valid[4:],
+		"character": valid[:10] + "b" + valid[11:],
+		"separator": strings.Replace(valid, "1", "", 1),
+		"prefix": encodeBech32("stake", []byte{0xcd, 0xae, 0x4a, 0x1a,
+			0x08}),
+		"empty": "",
+	}
+	for name, poolID := range tests {
+		if _, err := PoolIDToHex(poolID); err == nil {
+			t.Errorf("%s: expected '%s' to be rejected", name, poolID)
+		}
+	}
+}
+
+func TestBech32RoundTrip(t *testing.T) {
+	for n := 1; n <= 64; n++ {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i*37 + n)
+		}
+		prefix, decoded, err := decodeBech32(encodeBech32(poolIDPrefix, data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prefix != poolIDPrefix || string(decoded) != string(data) {
+			t.Fatalf("expected %x with prefix '%s', got %x with prefix '%s'",
+				data, poolIDPrefix, decoded, prefix)
+		}
+	}
+}
@@ -2,32 +2,82 @@ package blockfrost
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain/poolcache"
 	"github.com/blockfrost/blockfrost-go"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 )
 
 // Backend is an implementation of chain.Backend that makes use of the
 // Blockfrost API.
+//
+// The endpoints are requested directly instead of with the client of the
+// Blockfrost SDK, because the SDK retries exceeded rate limits and server
+// errors on its own without telling the status code. Such failures are
+// returned as chain.TemporaryError instead, such that they can be handled by
+// the caller (e.g. the ratelimit.Backend).
 type Backend struct {
-	client blockfrost.APIClient
-	cache  poolCache
+	serverURL string
+	projectID string
+	client    *http.Client
+	cache     *poolcache.Cache
 }
 
+// APIError is returned, when the Blockfrost API responded with an unexpected
+// status code. It is wrapped into a chain.TemporaryError, if the status code
+// signals a temporary failure.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Message is the body of the response.
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("blockfrost responded with status %d: %s", e.StatusCode,
+		e.Message)
+}
+
+// DefaultURL is the URL of the public Blockfrost API for the mainnet.
+const DefaultURL = blockfrost.CardanoMainNet
+
 // NewBlockFrostBackend is creating a new chain.Backend that uses Blockfrost API
-// with the api key specified in the environment.
-func NewBlockFrostBackend() (chain.Backend, error) {
+// at the given server URL with the api key specified in the environment. The
+// public Blockfrost API for the mainnet is used, if the URL is empty. The
+// metadata of pools is cached in the given poolcache.Cache, and only in memory,
+// if it is nil.
+func NewBlockFrostBackend(serverURL string,
+	cache *poolcache.Cache) (chain.Backend, error) {
+
 	apiKey := os.Getenv("BLU_BLOCKFROST_API_KEY")
 	if apiKey == "" {
 		return nil, fmt.Errorf("API key for blockfrost hasn't been specified in the environment (BLU_BLOCKFROST_API_KEY)")
 	}
-	client := blockfrost.NewAPIClient(blockfrost.APIClientOptions{
-		ProjectID: apiKey,
-	})
+	if serverURL == "" {
+		serverURL = DefaultURL
+	}
+	_, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("the server URL '%s' for blockfrost is invalid: %s",
+			serverURL, err.Error())
+	}
+	if cache == nil {
+		cache = poolcache.NewCache(nil, nil)
+	}
 	return &Backend{
-		client: client,
-		cache:  newPoolCache(5),
+		serverURL: strings.TrimSuffix(serverURL, "/"),
+		projectID: apiKey,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		cache: cache,
 	}, nil
 }
 
@@ -35,85 +85,126 @@ func (b *Backend) Name() string {
 	return "blockfrost"
 }
 
+// get requests the given path of the Blockfrost API, and decodes the JSON
+// response into the given value. False is returned, if the requested resource
+// doesn't exist.
+func (b *Backend) get(ctx context.Context, path string,
+	v interface{}) (bool, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		b.serverURL+path, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("project_id", b.projectID)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := ioutil.ReadAll(resp.Body)
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Message:    string(message),
+		}
+		if chain.IsTemporaryStatus(resp.StatusCode) {
+			return false, &chain.TemporaryError{
+				StatusCode: resp.StatusCode,
+				RetryAfter: chain.ParseRetryAfter(resp.Header.Get("Retry-After")),
+				Err:        apiErr,
+			}
+		}
+		return false, apiErr
+	}
+	return true, json.NewDecoder(resp.Body).Decode(v)
+}
+
 func (b *Backend) GetLatestBlock(ctx context.Context) (*chain.Tip, error) {
-	block, err := b.client.BlockLatest(ctx)
+	var block blockfrost.Block
+	found, err := b.get(ctx, "/blocks/latest", &block)
 	if err != nil {
 		return nil, err
 	}
-	return &chain.Tip{
+	if !found {
+		return nil, fmt.Errorf("blockfrost returned no tip")
+	}
+	tip := toTip(&block)
+	tip.Source = b.Name()
+	return &tip, nil
+}
+
+// toTip transforms the given block of the Blockfrost API into a tip.
+func toTip(block *blockfrost.Block) chain.Tip {
+	return chain.Tip{
 		Height:      uint(block.Height),
 		Hash:        block.Hash,
 		Epoch:       uint(block.Epoch),
 		SlotInEpoch: uint(block.EpochSlot),
 		Slot:        uint(block.Slot),
 		Timestamp:   uint(block.Time),
-	}, nil
+	}
 }
 
-// fetchPoolMetadata returns a function that can be used with the pool
-// cache to fetch metadata of a pool.
-func (b *Backend) fetchPoolMetadata() fetchPoolMetadataFunc {
-	return func(ctx context.Context, poolID string) (*chain.StakePool, error) {
-		poolMetadata, err := b.client.PoolMetadata(ctx, poolID)
-		if err != nil {
-			return nil, err
-		}
-		return &chain.StakePool{
-			HexID:  poolMetadata.Hex,
-			Ticker: poolMetadata.Ticker,
-			Name:   poolMetadata.Name,
-		}, err
+// fetchPoolMetadata fetches the metadata of the pool with the given ID in hex
+// format from the Blockfrost API.
+func (b *Backend) fetchPoolMetadata(ctx context.Context,
+	hexID string) (*chain.StakePool, error) {
+
+	var poolMetadata blockfrost.PoolMetadata
+	found, err := b.get(ctx, fmt.Sprintf("/pools/%s/metadata", hexID),
+		&poolMetadata)
+	if err != nil {
+		return nil, err
 	}
+	if !found {
+		return &chain.StakePool{HexID: hexID}, nil
+	}
+	return &chain.StakePool{
+		HexID:  hexID,
+		Ticker: poolMetadata.Ticker,
+		Name:   poolMetadata.Name,
+	}, nil
+}
+
+func (b *Backend) GetStakePool(ctx context.Context,
+	hexID string) (*chain.StakePool, error) {
+
+	return b.cache.Fetch(ctx, hexID, b.fetchPoolMetadata)
 }
 
 func (b *Backend) GetMintedBlock(ctx context.Context,
 	slot uint) (*chain.MintedBlock, error) {
 
-	block, err := b.client.BlockBySlot(ctx, int(slot))
+	var block blockfrost.Block
+	found, err := b.get(ctx, fmt.Sprintf("/blocks/slot/%d", slot), &block)
+	if err != nil || !found {
+		return nil, err
+	}
+	hexID, err := chain.PoolIDToHex(block.SlotLeader)
 	if err != nil {
-		if serr, ok := err.(*blockfrost.APIError); ok {
-			if _, ok := serr.Response.(blockfrost.NotFound); ok {
-				return nil, nil
-			}
-		}
 		return nil, err
 	}
-	pool, err := b.cache.fetchPoolMetadata(ctx, block.SlotLeader,
-		b.fetchPoolMetadata())
+	pool, err := b.GetStakePool(ctx, hexID)
 	if err != nil {
 		return nil, err
 	}
-	return &chain.MintedBlock{
-		Tip: chain.Tip{
-			Height:      uint(block.Height),
-			Hash:        block.Hash,
-			Epoch:       uint(block.Epoch),
-			SlotInEpoch: uint(block.EpochSlot),
-			Slot:        uint(block.Slot),
-			Timestamp:   uint(block.Time),
-		},
+	mBlock := &chain.MintedBlock{
+		Tip:  toTip(&block),
 		Pool: *pool,
-	}, nil
+	}
+	mBlock.Source = b.Name()
+	return mBlock, nil
 }
 
 func (b *Backend) TraverseAround(ctx context.Context, slot uint,
 	interval uint) (*chain.MintedBlock, error) {
 
-	for i := 1; i <= int(interval); i++ {
-		mBlock, err := b.GetMintedBlock(ctx, slot+uint(i))
-		if err != nil {
-			return nil, err
-		}
-		if mBlock != nil {
-			return mBlock, nil
-		}
-		mBlock, err = b.GetMintedBlock(ctx, slot-uint(i))
-		if err != nil {
-			return nil, err
-		}
-		if mBlock != nil {
-			return mBlock, nil
-		}
-	}
-	return nil, nil
+	return chain.TraverseSlots(ctx, b, slot, interval)
 }
+
+func (b *Backend) TraversesSlots() {}
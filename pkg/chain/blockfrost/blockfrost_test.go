@@ -0,0 +1,168 @@
+package blockfrost
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain/blockfrost/fake"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain/ratelimit"
+	"github.com/blockfrost/blockfrost-go"
+)
+
+const (
+	projectID = "test"
+	poolA     = "cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214b"
+	poolB     = "1d9302a3fb4b3b1935e02b27f0339798d3f08a55fbfdcd43a449a96f"
+	bech32A   = "pool1ekhy5xsgjaq38em75vevk8df0k0rljju77tljw288ys5kumqce5"
+	bech32B   = "pool1rkfs9glmfva3jd0q9vnlqvuhnrflpzj4l07u6sayfx5k7d788us"
+)
+
+// newTestBackend starts a fake Blockfrost server with a short chain, and
+// creates a backend for it.
+func newTestBackend(t *testing.T) (*Backend, *fake.Server) {
+	t.Setenv("BLU_BLOCKFROST_API_KEY", projectID)
+	server := fake.NewServer(&fake.Chain{
+		ProjectID: projectID,
+		Blocks: []blockfrost.Block{
+			{Slot: 55900900, EpochSlot: 100, Epoch: 327, Height: 7000001,
+				Hash: "0a02", Time: 1647467191, SlotLeader: bech32A},
+			{Slot: 55900910, EpochSlot: 110, Epoch: 327, Height: 7000002,
+				Hash: "0a03", Time: 1647467201, SlotLeader: bech32B},
+		},
+		Pools: []blockfrost.PoolMetadata{
+			{PoolID: bech32A, Hex: poolA, Ticker: "BLUE", Name: "BlockBlu Pool"},
+		},
+	})
+	t.Cleanup(server.Close)
+	backend, err := NewBlockFrostBackend(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return backend.(*Backend), server
+}
+
+func TestGetLatestBlock(t *testing.T) {
+	backend, _ := newTestBackend(t)
+	tip, err := backend.GetLatestBlock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := chain.Tip{
+		Height:      7000002,
+		Hash:        "0a03",
+		Epoch:       327,
+		SlotInEpoch: 110,
+		Slot:        55900910,
+		Timestamp:   1647467201,
+		Source:      "blockfrost",
+	}
+	if *tip != want {
+		t.Errorf("expected tip %+v, got %+v", want, *tip)
+	}
+}
+
+func TestGetMintedBlock(t *testing.T) {
+	backend, _ := newTestBackend(t)
+	ctx := context.Background()
+	block, err := backend.GetMintedBlock(ctx, 55900900)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := chain.StakePool{HexID: poolA, Ticker: "BLUE", Name: "BlockBlu Pool"}
+	if block == nil || block.Hash != "0a02" || block.Pool != want {
+		t.Fatalf("expected the block 0a02 of pool %+v, got %+v", want, block)
+	}
+	block, err = backend.GetMintedBlock(ctx, 55900910)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block == nil || block.Pool != (chain.StakePool{HexID: poolB}) {
+		t.Fatalf("expected a block of a pool without metadata, got %+v", block)
+	}
+	block, err = backend.GetMintedBlock(ctx, 55900901)
+	if err != nil || block != nil {
+		t.Errorf("expected no block in slot 55900901, got %+v (%v)", block, err)
+	}
+}
+
+func TestTemporaryFailures(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		retryAfter string
+		temporary  bool
+		wantAfter  time.Duration
+	}{
+		{http.StatusTooManyRequests, "7", true, 7 * time.Second},
+		{http.StatusTooManyRequests, "", true, 0},
+		{http.StatusInternalServerError, "", true, 0},
+		{http.StatusServiceUnavailable, "3", true, 3 * time.Second},
+		{http.StatusBadRequest, "", false, 0},
+		{http.StatusForbidden, "", false, 0},
+	}
+	for _, tc := range tests {
+		backend, server := newTestBackend(t)
+		server.AddFault(fake.Fault{
+			Path:       "/blocks/slot/*",
+			StatusCode: tc.statusCode,
+			RetryAfter: tc.retryAfter,
+		})
+		_, err := backend.GetMintedBlock(context.Background(), 55900900)
+		if err == nil {
+			t.Fatalf("expected status %d to fail", tc.statusCode)
+		}
+		// the failure must be returned right away without retrying.
+		if n := server.Requests("/blocks/slot/*"); n != 1 {
+			t.Errorf("expected status %d to be requested once, got %d requests",
+				tc.statusCode, n)
+		}
+		var temporaryErr *chain.TemporaryError
+		if !errors.As(err, &temporaryErr) {
+			if tc.temporary {
+				t.Errorf("expected status %d to fail temporarily, got %v",
+					tc.statusCode, err)
+			}
+			continue
+		}
+		if !tc.temporary {
+			t.Errorf("expected status %d to fail permanently, got %v",
+				tc.statusCode, err)
+			continue
+		}
+		if temporaryErr.StatusCode != tc.statusCode ||
+			temporaryErr.RetryAfter != tc.wantAfter {
+			t.Errorf("expected status %d with retry after %v, got %+v",
+				tc.statusCode, tc.wantAfter, temporaryErr)
+		}
+	}
+}
+
+func TestRateLimitedRetries(t *testing.T) {
+	backend, server := newTestBackend(t)
+	server.AddFault(fake.Fault{
+		Path:       "/blocks/slot/55900900",
+		StatusCode: http.StatusTooManyRequests,
+		Times:      2,
+		RetryAfter: "0",
+	})
+	limited := ratelimit.NewBackend(backend, &ratelimit.Configuration{
+		Burst:      10,
+		MaxRetries: 3,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+	})
+	block, err := limited.GetMintedBlock(context.Background(), 55900900)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block == nil || block.Hash != "0a02" {
+		t.Errorf("expected the block 0a02, got %+v", block)
+	}
+	if n := server.Requests("/blocks/slot/55900900"); n != 3 {
+		t.Errorf("expected the decorator to retry the request twice, got %d requests",
+			n)
+	}
+}
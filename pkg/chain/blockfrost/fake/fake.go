@@ -0,0 +1,258 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/blockfrost/blockfrost-go"
+)
+
+// Chain is a scripted chain, which is served by a Server.
+type Chain struct {
+	// ProjectID is the project ID, which must be sent by the clients. It isn't
+	// checked, if it is empty.
+	ProjectID string
+	// Blocks are the minted blocks of the chain. The block with the greatest
+	// height is the tip of the chain.
+	Blocks []blockfrost.Block
+	// Pools are the metadata of stake pools, which can be looked up by their
+	// bech32 as well as hex ID.
+	Pools []blockfrost.PoolMetadata
+	// Faults are the failures, which are responded instead of the chain.
+	Faults []Fault
+}
+
+// Fault is a scripted failure of requests to the Server.
+type Fault struct {
+	// Path is the path of the failing requests (e.g. '/blocks/slot/1234'). A
+	// trailing '*' matches any suffix, and the requests to all paths fail, if
+	// it is empty.
+	Path string
+	// StatusCode is the HTTP status code of the failure (e.g. 429 or 500).
+	StatusCode int
+	// Times is the number of requests that fail. The requests fail forever,
+	// if it is zero.
+	Times int
+	// RetryAfter is the value of the 'Retry-After' header in seconds. The
+	// header isn't sent, if it is empty.
+	RetryAfter string
+}
+
+// matches checks whether this fault matches the given path.
+func (f *Fault) matches(path string) bool {
+	if strings.HasSuffix(f.Path, "*") {
+		return strings.HasPrefix(path, strings.TrimSuffix(f.Path, "*"))
+	}
+	return f.Path == "" || f.Path == path
+}
+
+// Server is a HTTP server imitating the Blockfrost API for a scripted chain.
+// It serves the routes '/blocks/latest', '/blocks/slot/{slot}' and
+// '/pools/{id}/metadata', which are needed by the blockfrost backend.
+type Server struct {
+	*httptest.Server
+	projectID string
+	lock      sync.Mutex
+	blocks    map[int]blockfrost.Block
+	pools     map[string]blockfrost.PoolMetadata
+	faults    []*Fault
+	requests  []string
+}
+
+// NewServer starts a new Server serving the given chain. The server must be
+// closed, when it isn't needed anymore.
+func NewServer(chain *Chain) *Server {
+	s := &Server{
+		projectID: chain.ProjectID,
+		blocks:    make(map[int]blockfrost.Block),
+		pools:     make(map[string]blockfrost.PoolMetadata),
+	}
+	for _, block := range chain.Blocks {
+		s.AddBlock(block)
+	}
+	for _, pool := range chain.Pools {
+		s.AddPool(pool)
+	}
+	for _, fault := range chain.Faults {
+		s.AddFault(fault)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// AddBlock adds the given block to the chain. A block in the same slot is
+// replaced.
+func (s *Server) AddBlock(block blockfrost.Block) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.blocks[block.Slot] = block
+}
+
+// RemoveBlock removes the block in the given slot from the chain, which
+// imitates a rollback.
+func (s *Server) RemoveBlock(slot int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.blocks, slot)
+}
+
+// AddPool adds the metadata of the given pool.
+func (s *Server) AddPool(pool blockfrost.PoolMetadata) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if pool.PoolID != "" {
+		s.pools[pool.PoolID] = pool
+	}
+	if pool.Hex != "" {
+		s.pools[pool.Hex] = pool
+	}
+}
+
+// AddFault adds the given fault, which takes precedence over the faults that
+// have been added before.
+func (s *Server) AddFault(fault Fault) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.faults = append([]*Fault{&fault}, s.faults...)
+}
+
+// Requests returns the number of requests that have been received for the
+// given path. A trailing '*' matches any suffix, and all requests are
+// counted, if the path is empty.
+func (s *Server) Requests(path string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	fault := Fault{Path: path}
+	n := 0
+	for _, requested := range s.requests {
+		if fault.matches(requested) {
+			n++
+		}
+	}
+	return n
+}
+
+// fault returns the fault for a request to the given path, and records the
+// request. Nil is returned, if the request shall not fail.
+func (s *Server) fault(path string) *Fault {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.requests = append(s.requests, path)
+	for i, fault := range s.faults {
+		if !fault.matches(path) {
+			continue
+		}
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return fault
+	}
+	return nil
+}
+
+// tip returns the block with the greatest height. False is returned, if the
+// chain is empty.
+func (s *Server) tip() (interface{}, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var tip blockfrost.Block
+	found := false
+	for _, block := range s.blocks {
+		if !found || block.Height > tip.Height {
+			tip = block
+			found = true
+		}
+	}
+	return tip, found
+}
+
+// block returns the block in the given slot. False is returned, if no block
+// has been minted in the slot.
+func (s *Server) block(slot int) (interface{}, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	block, found := s.blocks[slot]
+	return block, found
+}
+
+// pool returns the metadata of the pool with the given bech32 or hex ID.
+// False is returned, if the pool is unknown.
+func (s *Server) pool(id string) (interface{}, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	pool, found := s.pools[id]
+	return pool, found
+}
+
+// handle handles the given request to this server.
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	if fault := s.fault(path); fault != nil {
+		if fault.RetryAfter != "" {
+			w.Header().Set("Retry-After", fault.RetryAfter)
+		}
+		writeError(w, fault.StatusCode, "scripted fault")
+		return
+	}
+	if s.projectID != "" && r.Header.Get("project_id") != s.projectID {
+		writeError(w, http.StatusForbidden, "Invalid project token.")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusBadRequest, "Only GET is supported.")
+		return
+	}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "blocks" && parts[1] == "latest":
+		result, found := s.tip()
+		writeResult(w, result, found)
+	case len(parts) == 3 && parts[0] == "blocks" && parts[1] == "slot":
+		slot, err := strconv.Atoi(parts[2])
+		if err != nil {
+			writeError(w, http.StatusBadRequest,
+				fmt.Sprintf("Invalid slot '%s'.", parts[2]))
+			return
+		}
+		result, found := s.block(slot)
+		writeResult(w, result, found)
+	case len(parts) == 3 && parts[0] == "pools" && parts[2] == "metadata":
+		result, found := s.pool(parts[1])
+		writeResult(w, result, found)
+	default:
+		writeError(w, http.StatusNotFound, "The requested route is unknown.")
+	}
+}
+
+// writeResult writes the given result as JSON to the given response writer.
+// The status 404 is responded, if the result hasn't been found.
+func writeResult(w http.ResponseWriter, result interface{}, found bool) {
+	if !found {
+		writeError(w, http.StatusNotFound,
+			"The requested component has not been found.")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// writeError writes an error in the format of Blockfrost with the given
+// status code and message to the given response writer.
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"status_code": statusCode,
+		"error":       http.StatusText(statusCode),
+		"message":     message,
+	})
+}
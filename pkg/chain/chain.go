@@ -31,6 +31,8 @@ type Tip struct {
 	// Timestamp is the Unix timestamp in seconds of the time at which this
 	// block has been minted.
 	Timestamp uint
+	// Source is the name of the backend, which served this block.
+	Source string
 }
 
 // MintedBlock is an object containing information about a minted block.
@@ -42,12 +44,14 @@ type MintedBlock struct {
 
 func (b *MintedBlock) ToDTO() *db.MintedBlock {
 	dto := db.MintedBlock{
-		Epoch:     b.Epoch,
-		EpochSlot: b.SlotInEpoch,
-		Slot:      b.Slot,
-		Hash:      b.Hash,
-		Height:    b.Height,
-		PoolID:    b.Pool.HexID,
+		Epoch:      b.Epoch,
+		EpochSlot:  b.SlotInEpoch,
+		Slot:       b.Slot,
+		Hash:       b.Hash,
+		Height:     b.Height,
+		PoolID:     b.Pool.HexID,
+		PoolTicker: b.Pool.Ticker,
+		PoolName:   b.Pool.Name,
 	}
 	return &dto
 }
@@ -80,4 +84,70 @@ type Backend interface {
 	// If querying the chain failed, an error will be returned instead.
 	TraverseAround(ctx context.Context, slot uint,
 		interval uint) (*MintedBlock, error)
+
+	// GetStakePool queries the metadata of the stake pool with the given ID in
+	// hex format. The ticker and name are empty, if the pool hasn't registered
+	// any metadata.
+	//
+	// If querying the chain failed, an error will be returned instead.
+	GetStakePool(ctx context.Context, hexID string) (*StakePool, error)
+}
+
+// SlotTraverser is a Backend, which can only look up single slots, and thus
+// traverses around a slot with one GetMintedBlock query per slot (see
+// TraverseSlots). A decorator of such a backend can traverse with its own
+// GetMintedBlock instead, such that it sees each single query.
+type SlotTraverser interface {
+	Backend
+
+	// TraversesSlots marks this backend as SlotTraverser.
+	TraversesSlots()
+}
+
+// TraverseSlots implements Backend.TraverseAround for the given backend by
+// querying the slots with GetMintedBlock one by one. The slot after the given
+// slot is queried before the slot with the same distance before it.
+func TraverseSlots(ctx context.Context, backend Backend, slot uint,
+	interval uint) (*MintedBlock, error) {
+
+	for i := uint(1); i <= interval; i++ {
+		mBlock, err := backend.GetMintedBlock(ctx, slot+i)
+		if err != nil {
+			return nil, err
+		}
+		if mBlock != nil {
+			return mBlock, nil
+		}
+		mBlock, err = backend.GetMintedBlock(ctx, slot-i)
+		if err != nil {
+			return nil, err
+		}
+		if mBlock != nil {
+			return mBlock, nil
+		}
+	}
+	return nil, nil
+}
+
+// TipFollower is a Backend that follows the chain itself, and pushes updates
+// of the tip instead of being polled for them.
+type TipFollower interface {
+	Backend
+
+	// Follow follows the chain until the given context has been canceled. This
+	// method is blocking.
+	Follow(ctx context.Context)
+
+	// SubscribeTip subscribes to updates of the tip. It returns the channel
+	// with the updated tips, and a function to cancel the subscription.
+	SubscribeTip() (<-chan Tip, func())
+}
+
+// Composite is a Backend that delegates the queries to other backends, e.g. to
+// fail over between them.
+type Composite interface {
+	Backend
+
+	// Backends returns the backends to which the queries are delegated.
+	Backends() []Backend
 }
@@ -0,0 +1,151 @@
+package dbsync
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	_ "github.com/lib/pq"
+)
+
+// Backend is an implementation of chain.Backend that queries the PostgreSQL
+// database of a cardano-db-sync instance.
+type Backend struct {
+	db *sql.DB
+}
+
+// mintedBlockQuery selects a minted block with the pool that minted it. The
+// metadata of the pool is taken from the latest registered off-chain data.
+const mintedBlockQuery = `
+SELECT b.block_no, encode(b.hash, 'hex'), b.epoch_no, b.epoch_slot_no, b.slot_no,
+	extract(epoch from b.time)::bigint, encode(ph.hash_raw, 'hex'), md.ticker_name, md.name
+FROM block b
+	JOIN slot_leader sl ON sl.id = b.slot_leader_id
+	LEFT JOIN pool_hash ph ON ph.id = sl.pool_hash_id
+	LEFT JOIN LATERAL (
+		SELECT o.ticker_name, o.json ->> 'name' AS name FROM off_chain_pool_data o
+		WHERE o.pool_id = ph.id
+		ORDER BY o.id DESC
+		LIMIT 1
+	) md ON true
+`
+
+// NewDBSyncBackend is creating a new chain.Backend that queries the
+// cardano-db-sync database, which is reachable with the given DSN (e.g.
+// 'postgres://user@localhost/cexplorer?sslmode=disable'). An error will be
+// returned, if the database isn't reachable.
+func NewDBSyncBackend(dsn string) (chain.Backend, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("the DSN for the db-sync database hasn't been specified")
+	}
+	sqlDB, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	err = sqlDB.Ping()
+	if err != nil {
+		_ = sqlDB.Close()
+		return nil, fmt.Errorf("the db-sync database isn't reachable: %s",
+			err.Error())
+	}
+	return &Backend{
+		db: sqlDB,
+	}, nil
+}
+
+func (b *Backend) Name() string {
+	return "db-sync"
+}
+
+func (b *Backend) GetLatestBlock(ctx context.Context) (*chain.Tip, error) {
+	row := b.db.QueryRowContext(ctx, `
+SELECT b.block_no, encode(b.hash, 'hex'), b.epoch_no, b.epoch_slot_no, b.slot_no,
+	extract(epoch from b.time)::bigint
+FROM block b
+WHERE b.block_no IS NOT NULL
+ORDER BY b.block_no DESC
+LIMIT 1;
+`)
+	tip := chain.Tip{Source: b.Name()}
+	err := row.Scan(&tip.Height, &tip.Hash, &tip.Epoch, &tip.SlotInEpoch,
+		&tip.Slot, &tip.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return &tip, nil
+}
+
+// queryMintedBlock queries for a single minted block with the given query.
+// Nil will be returned, if no block could be found.
+func (b *Backend) queryMintedBlock(ctx context.Context, query string,
+	args ...interface{}) (*chain.MintedBlock, error) {
+
+	row := b.db.QueryRowContext(ctx, query, args...)
+	block := chain.MintedBlock{Tip: chain.Tip{Source: b.Name()}}
+	var poolID, ticker, name sql.NullString
+	err := row.Scan(&block.Height, &block.Hash, &block.Epoch,
+		&block.SlotInEpoch, &block.Slot, &block.Timestamp, &poolID, &ticker,
+		&name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	block.Pool = chain.StakePool{
+		HexID:  poolID.String,
+		Ticker: ticker.String,
+		Name:   name.String,
+	}
+	return &block, nil
+}
+
+func (b *Backend) GetMintedBlock(ctx context.Context,
+	slot uint) (*chain.MintedBlock, error) {
+
+	return b.queryMintedBlock(ctx, mintedBlockQuery+`
+WHERE b.slot_no = $1;
+`, slot)
+}
+
+func (b *Backend) TraverseAround(ctx context.Context, slot uint,
+	interval uint) (*chain.MintedBlock, error) {
+
+	from := uint(0)
+	if slot > interval {
+		from = slot - interval
+	}
+	// the slot after the given slot is preferred over the slot before with
+	// the same distance.
+	return b.queryMintedBlock(ctx, mintedBlockQuery+`
+WHERE b.slot_no BETWEEN $1 AND $2 AND b.slot_no <> $3
+ORDER BY abs(b.slot_no - $3), b.slot_no DESC
+LIMIT 1;
+`, from, slot+interval, slot)
+}
+
+func (b *Backend) GetStakePool(ctx context.Context,
+	hexID string) (*chain.StakePool, error) {
+
+	pool := chain.StakePool{HexID: hexID}
+	var ticker, name sql.NullString
+	err := b.db.QueryRowContext(ctx, `
+SELECT o.ticker_name, o.json ->> 'name' FROM pool_hash ph
+	JOIN off_chain_pool_data o ON o.pool_id = ph.id
+WHERE ph.hash_raw = decode($1, 'hex')
+ORDER BY o.id DESC
+LIMIT 1;
+`, hexID).Scan(&ticker, &name)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	pool.Ticker = ticker.String
+	pool.Name = name.String
+	return &pool, nil
+}
+
+// Close closes the connections to the db-sync database.
+func (b *Backend) Close() error {
+	return b.db.Close()
+}
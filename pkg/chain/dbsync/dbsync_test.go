@@ -0,0 +1,137 @@
+package dbsync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blockblu-io/leaderlog-api/internal/pgtest"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+)
+
+// dsnEnv is the environment variable, in which the DSN of a disposable
+// PostgreSQL database for the tests can be specified.
+const dsnEnv = "BLU_TEST_POSTGRES_DSN"
+
+const (
+	poolA = "cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214b"
+	poolB = "1d9302a3fb4b3b1935e02b27f0339798d3f08a55fbfdcd43a449a96f"
+)
+
+// newTestBackend creates a backend for a db-sync database, which is seeded
+// with the fixture in testdata.
+func newTestBackend(t *testing.T) chain.Backend {
+	dsn := pgtest.DSN(t, dsnEnv)
+	pgtest.Seed(t, dsn, "testdata/seed.sql")
+	backend, err := NewDBSyncBackend(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = backend.(*Backend).Close()
+	})
+	return backend
+}
+
+func TestGetLatestBlock(t *testing.T) {
+	backend := newTestBackend(t)
+	tip, err := backend.GetLatestBlock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := chain.Tip{
+		Height:      7000003,
+		Hash:        "0a04",
+		Epoch:       327,
+		SlotInEpoch: 150,
+		Slot:        55900950,
+		Timestamp:   1647467241,
+		Source:      "db-sync",
+	}
+	if *tip != want {
+		t.Errorf("expected tip %+v, got %+v", want, *tip)
+	}
+}
+
+func TestGetMintedBlock(t *testing.T) {
+	backend := newTestBackend(t)
+	block, err := backend.GetMintedBlock(context.Background(), 55900900)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := chain.MintedBlock{
+		Tip: chain.Tip{
+			Height:      7000001,
+			Hash:        "0a02",
+			Epoch:       327,
+			SlotInEpoch: 100,
+			Slot:        55900900,
+			Timestamp:   1647467191,
+			Source:      "db-sync",
+		},
+		Pool: chain.StakePool{Ticker: "BLUE", Name: "BlockBlu Pool",
+			HexID: poolA},
+	}
+	if block == nil || *block != want {
+		t.Fatalf("expected block %+v, got %+v", want, block)
+	}
+	block, err = backend.GetMintedBlock(context.Background(), 55900910)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPool := chain.StakePool{HexID: poolB}
+	if block == nil || block.Pool != wantPool {
+		t.Fatalf("expected a block of a pool without metadata, got %+v", block)
+	}
+	block, err = backend.GetMintedBlock(context.Background(), 55900901)
+	if err != nil || block != nil {
+		t.Errorf("expected no block in slot 55900901, got %+v (%v)", block, err)
+	}
+}
+
+func TestTraverseAround(t *testing.T) {
+	backend := newTestBackend(t)
+	tests := []struct {
+		slot     uint
+		interval uint
+		want     uint
+	}{
+		// the blocks before and after have the same distance.
+		{55900900, 10, 55900910},
+		{55900895, 10, 55900900},
+		{55900949, 5, 55900950},
+		{55900930, 5, 0},
+	}
+	for _, tc := range tests {
+		block, err := backend.TraverseAround(context.Background(), tc.slot,
+			tc.interval)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case tc.want == 0 && block != nil:
+			t.Errorf("expected no block around slot %d, got %+v", tc.slot, block)
+		case tc.want != 0 && (block == nil || block.Slot != tc.want):
+			t.Errorf("expected the block in slot %d around slot %d, got %+v",
+				tc.want, tc.slot, block)
+		}
+	}
+}
+
+func TestGetStakePool(t *testing.T) {
+	backend := newTestBackend(t)
+	pool, err := backend.GetStakePool(context.Background(), poolA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := chain.StakePool{Ticker: "BLUE", Name: "BlockBlu Pool", HexID: poolA}
+	if *pool != want {
+		t.Errorf("expected pool %+v, got %+v", want, *pool)
+	}
+	pool, err = backend.GetStakePool(context.Background(), poolB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *pool != (chain.StakePool{HexID: poolB}) {
+		t.Errorf("expected pool %s without metadata, got %+v", poolB, *pool)
+	}
+}
@@ -0,0 +1,78 @@
+-- A subset of the schema of cardano-db-sync, which is seeded with the blocks
+-- of a few slots at the start of epoch 327 of the mainnet.
+
+CREATE TABLE epoch (
+    id bigserial PRIMARY KEY,
+    out_sum numeric(39, 0) NOT NULL,
+    fees numeric(20, 0) NOT NULL,
+    tx_count integer NOT NULL,
+    blk_count integer NOT NULL,
+    no integer NOT NULL UNIQUE,
+    start_time timestamp NOT NULL,
+    end_time timestamp NOT NULL
+);
+
+CREATE TABLE pool_hash (
+    id bigserial PRIMARY KEY,
+    hash_raw bytea NOT NULL UNIQUE,
+    view varchar NOT NULL
+);
+
+CREATE TABLE slot_leader (
+    id bigserial PRIMARY KEY,
+    hash bytea NOT NULL UNIQUE,
+    pool_hash_id bigint REFERENCES pool_hash (id),
+    description varchar NOT NULL
+);
+
+CREATE TABLE block (
+    id bigserial PRIMARY KEY,
+    hash bytea NOT NULL UNIQUE,
+    epoch_no integer,
+    slot_no bigint,
+    epoch_slot_no integer,
+    block_no integer,
+    previous_id bigint REFERENCES block (id),
+    slot_leader_id bigint NOT NULL REFERENCES slot_leader (id),
+    size integer NOT NULL,
+    time timestamp NOT NULL,
+    tx_count bigint NOT NULL
+);
+
+CREATE TABLE off_chain_pool_data (
+    id bigserial PRIMARY KEY,
+    pool_id bigint NOT NULL REFERENCES pool_hash (id),
+    ticker_name varchar NOT NULL,
+    hash bytea NOT NULL,
+    json jsonb NOT NULL,
+    bytes bytea NOT NULL,
+    pmr_id bigint NOT NULL
+);
+
+INSERT INTO epoch (out_sum, fees, tx_count, blk_count, no, start_time, end_time)
+VALUES (0, 0, 0, 4, 327, '2022-03-16 21:44:51', '2022-03-21 21:44:51');
+
+INSERT INTO pool_hash (id, hash_raw, view)
+VALUES (1, decode('cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214b', 'hex'),
+        'pool1ekhy5xsgjaq38em75vevk8df0k0rljju77tljw288ys5kumqce5'),
+       (2, decode('1d9302a3fb4b3b1935e02b27f0339798d3f08a55fbfdcd43a449a96f', 'hex'),
+        'pool1rkfs9glmfva3jd0q9vnlqvuhnrflpzj4l07u6sayfx5k7d788us');
+
+INSERT INTO slot_leader (id, hash, pool_hash_id, description)
+VALUES (1, decode('cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214b', 'hex'), 1,
+        'Pool-cdae4a1a08974113'),
+       (2, decode('1d9302a3fb4b3b1935e02b27f0339798d3f08a55fbfdcd43a449a96f', 'hex'), 2,
+        'Pool-1d9302a3fb4b3b19');
+
+-- the metadata of the first pool has been updated, and only the latest one is
+-- expected to be returned. The second pool has no metadata.
+INSERT INTO off_chain_pool_data (pool_id, ticker_name, hash, json, bytes, pmr_id)
+VALUES (1, 'OLD', decode('00', 'hex'), '{"name": "Old Name", "ticker": "OLD"}', decode('00', 'hex'), 1),
+       (1, 'BLUE', decode('01', 'hex'), '{"name": "BlockBlu Pool", "ticker": "BLUE"}', decode('01', 'hex'), 2);
+
+INSERT INTO block (id, hash, epoch_no, slot_no, epoch_slot_no, block_no, previous_id,
+                   slot_leader_id, size, time, tx_count)
+VALUES (1, decode('0a01', 'hex'), 327, 55900890, 90, 7000000, NULL, 2, 1024, '2022-03-16 21:46:21', 3),
+       (2, decode('0a02', 'hex'), 327, 55900900, 100, 7000001, 1, 1, 2048, '2022-03-16 21:46:31', 5),
+       (3, decode('0a03', 'hex'), 327, 55900910, 110, 7000002, 2, 2, 1024, '2022-03-16 21:46:41', 1),
+       (4, decode('0a04', 'hex'), 327, 55900950, 150, 7000003, 3, 1, 4096, '2022-03-16 21:47:21', 9);
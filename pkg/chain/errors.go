@@ -0,0 +1,66 @@
+package chain
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// TemporaryError is returned by a Backend, if querying the chain failed only
+// temporarily, e.g. because the rate limit of the queried API has been exceeded
+// or the API is unavailable for the moment. The query can be retried later.
+type TemporaryError struct {
+	// StatusCode is the HTTP status code of the failed request, or zero, if it
+	// is unknown.
+	StatusCode int
+	// RetryAfter is the duration after which the API asked to be queried
+	// again, or zero, if it didn't ask for it.
+	RetryAfter time.Duration
+	// Err is the cause of the failure.
+	Err error
+}
+
+func (e *TemporaryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TemporaryError) Unwrap() error {
+	return e.Err
+}
+
+// RateLimited checks whether the rate limit of the queried API has been
+// exceeded.
+func (e *TemporaryError) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// IsTemporaryStatus checks whether a request to an API, which responded with
+// the given HTTP status code, failed only temporarily. This is the case for
+// exceeded rate limits and server errors.
+func IsTemporaryStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests ||
+		(statusCode >= 500 && statusCode != http.StatusNotImplemented)
+}
+
+// ParseRetryAfter parses the given value of a 'Retry-After' header in seconds.
+// Zero is returned, if the value is missing or not in seconds.
+func ParseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// IsTemporary checks whether the given error returned by a Backend is only
+// temporary, i.e. it is a TemporaryError or a timeout of the network.
+func IsTemporary(err error) bool {
+	var temporaryErr *TemporaryError
+	if errors.As(err, &temporaryErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,22 @@
+package chain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":                              0,
+		"0":                             0,
+		"30":                            30 * time.Second,
+		"-1":                            0,
+		"Wed, 21 Oct 2015 07:28:00 GMT": 0,
+	}
+	for value, want := range tests {
+		if got := ParseRetryAfter(value); got != want {
+			t.Errorf("expected %s for Retry-After '%s', got %s", want, value,
+				got)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+package fallback
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	DefaultConfig = &Configuration{
+		MaxTipAge:  10 * time.Minute,
+		Cooldown:   time.Minute,
+		CrossCheck: false,
+		Clock:      clock.Real,
+	}
+)
+
+// Configuration configures the behaviour of the Backend.
+type Configuration struct {
+	// MaxTipAge is the age of the tip after which a backend is considered to
+	// be stale. A backend is never considered to be stale, if it isn't
+	// positive.
+	MaxTipAge time.Duration
+	// Cooldown is the duration for which a failed backend is only queried, if
+	// all the other backends have failed as well. A stale backend stays
+	// unhealthy until it caught up, which is checked after each cooldown.
+	Cooldown time.Duration
+	// CrossCheck specifies whether each minted block is looked up with two
+	// healthy backends, whose answers must agree.
+	CrossCheck bool
+	// Clock tells the time for the Backend. The time of the system is used,
+	// if it is nil.
+	Clock clock.Clock
+}
+
+// Status is the health of a single backend of the Backend.
+type Status struct {
+	// Name is the name of the backend.
+	Name string
+	// Healthy is true, if the backend neither failed recently nor is stale.
+	Healthy bool
+	// Stale is true, if the tip of the backend was too old, and the backend
+	// hasn't caught up since.
+	Stale bool
+	// Served is the number of answers served by the backend.
+	Served uint64
+	// Failures is the number of failed queries of the backend.
+	Failures uint64
+	// LastError is the last error returned by the backend, or nil, if it
+	// never failed.
+	LastError error
+	// LastErrorTime is the time at which the backend failed the last time.
+	LastErrorTime time.Time
+}
+
+// member is a backend of the Backend with its health.
+type member struct {
+	backend       chain.Backend
+	failedUntil   time.Time
+	stale         bool
+	recheckAt     time.Time
+	served        uint64
+	failures      uint64
+	lastError     error
+	lastErrorTime time.Time
+}
+
+// Backend is a chain.Backend composed of an ordered list of backends. Each
+// query is answered by the first healthy backend, and it fails over to the
+// next backend, if a backend failed or its tip is stale. The backend that
+// served an answer is named in its chain.Tip.Source.
+type Backend struct {
+	lock    sync.Mutex
+	members []*member
+	config  *Configuration
+}
+
+// staleError is returned for a backend, whose tip is too old.
+type staleError struct {
+	age time.Duration
+}
+
+func (e *staleError) Error() string {
+	return fmt.Sprintf("the tip is stale since it is %v old", e.age)
+}
+
+// NewBackend creates a new Backend failing over between the given backends in
+// the given order. The backends mustn't be a chain.TipFollower, because they
+// aren't followed. The default configuration is used, if the given
+// Configuration is nil.
+func NewBackend(backends []chain.Backend, config *Configuration) (*Backend, error) {
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("at least one backend must be specified for the fallback")
+	}
+	if config == nil {
+		config = DefaultConfig
+	}
+	if config.Clock == nil {
+		withClock := *config
+		withClock.Clock = clock.Real
+		config = &withClock
+	}
+	members := make([]*member, len(backends))
+	for i, backend := range backends {
+		if _, ok := backend.(chain.TipFollower); ok {
+			return nil, fmt.Errorf("the backend '%s' follows the chain, and can't be failed over",
+				backend.Name())
+		}
+		members[i] = &member{backend: backend}
+	}
+	return &Backend{
+		members: members,
+		config:  config,
+	}, nil
+}
+
+func (b *Backend) Name() string {
+	names := make([]string, len(b.members))
+	for i, m := range b.members {
+		names[i] = m.backend.Name()
+	}
+	return fmt.Sprintf("fallback(%s)", strings.Join(names, ","))
+}
+
+// Backends returns the backends of this Backend in their given order.
+func (b *Backend) Backends() []chain.Backend {
+	backends := make([]chain.Backend, len(b.members))
+	for i, m := range b.members {
+		backends[i] = m.backend
+	}
+	return backends
+}
+
+// Status returns the health of the backends in their given order.
+func (b *Backend) Status() []Status {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	now := b.config.Clock.Now()
+	statuses := make([]Status, len(b.members))
+	for i, m := range b.members {
+		statuses[i] = Status{
+			Name:          m.backend.Name(),
+			Healthy:       m.healthy(now),
+			Stale:         m.stale,
+			Served:        m.served,
+			Failures:      m.failures,
+			LastError:     m.lastError,
+			LastErrorTime: m.lastErrorTime,
+		}
+	}
+	return statuses
+}
+
+// healthy checks whether this member neither failed recently nor is stale.
+func (m *member) healthy(now time.Time) bool {
+	return !now.Before(m.failedUntil) && !m.stale
+}
+
+// candidates returns the healthy members in their given order followed by the
+// unhealthy ones as last resort.
+func (b *Backend) candidates() []*member {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	now := b.config.Clock.Now()
+	candidates := make([]*member, 0, len(b.members))
+	for _, m := range b.members {
+		if m.healthy(now) {
+			candidates = append(candidates, m)
+		}
+	}
+	for _, m := range b.members {
+		if !m.healthy(now) {
+			candidates = append(candidates, m)
+		}
+	}
+	return candidates
+}
+
+// served records that the given member has served an answer.
+func (b *Backend) served(m *member) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	m.served++
+	m.failedUntil = time.Time{}
+}
+
+// failed records that the given member failed with the given error.
+func (b *Backend) failed(m *member, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	now := b.config.Clock.Now()
+	m.failures++
+	m.lastError = err
+	m.lastErrorTime = now
+	m.failedUntil = now.Add(b.config.Cooldown)
+}
+
+// checkTip checks whether the given tip of the given member is too old, and
+// records it. A staleError is returned, if the tip is stale. A stale member
+// stays stale until a tip has been checked, which isn't too old.
+func (b *Backend) checkTip(m *member, t *chain.Tip) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	now := b.config.Clock.Now()
+	age := now.Sub(time.Unix(int64(t.Timestamp), 0))
+	if b.config.MaxTipAge > 0 && age > b.config.MaxTipAge {
+		m.stale = true
+		m.recheckAt = now.Add(b.config.Cooldown)
+		return &staleError{age: age}
+	}
+	m.stale = false
+	return nil
+}
+
+// recheckStale queries the tips of the stale members, whose cooldown has
+// passed, such that they become healthy again, if they caught up. Otherwise,
+// a stale member wouldn't be asked for its tip, as long as a healthy member
+// answers.
+func (b *Backend) recheckStale(ctx context.Context) {
+	b.lock.Lock()
+	now := b.config.Clock.Now()
+	due := make([]*member, 0)
+	for _, m := range b.members {
+		if m.stale && !now.Before(m.recheckAt) {
+			due = append(due, m)
+		}
+	}
+	b.lock.Unlock()
+	for _, m := range due {
+		t, err := m.backend.GetLatestBlock(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			b.failed(m, err)
+			log.Warnf("rechecking the tip of stale backend '%s' failed: %s",
+				m.backend.Name(), err.Error())
+			continue
+		}
+		if b.checkTip(m, t) == nil {
+			log.Infof("backend '%s' caught up with the chain", m.backend.Name())
+		}
+	}
+}
+
+// query calls the given function for the given method with the candidates
+// until it succeeds, and returns the member that succeeded. An error is
+// returned, if all candidates failed.
+func (b *Backend) query(ctx context.Context, method string,
+	f func(m *member) error) (*member, error) {
+
+	var err error
+	for _, m := range b.candidates() {
+		err = f(m)
+		if err == nil {
+			b.served(m)
+			return m, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		if _, ok := err.(*staleError); ok {
+			log.Warnf("backend '%s' is stale, failing over: %s",
+				m.backend.Name(), err.Error())
+			continue
+		}
+		b.failed(m, err)
+		log.Warnf("%s of backend '%s' failed, failing over: %s", method,
+			m.backend.Name(), err.Error())
+	}
+	return nil, fmt.Errorf("all backends failed for %s, the last one with: %w",
+		method, err)
+}
+
+// GetLatestBlock returns the tip of the first backend, whose tip isn't stale.
+// The latest of the stale tips is returned, if all tips are stale. The stale
+// backends are checked for whether they caught up, once their cooldown has
+// passed.
+func (b *Backend) GetLatestBlock(ctx context.Context) (*chain.Tip, error) {
+	b.recheckStale(ctx)
+	var tip, staleTip *chain.Tip
+	_, err := b.query(ctx, "GetLatestBlock", func(m *member) error {
+		t, err := m.backend.GetLatestBlock(ctx)
+		if err != nil {
+			return err
+		}
+		err = b.checkTip(m, t)
+		if err != nil {
+			if staleTip == nil || t.Height > staleTip.Height {
+				staleTip = t
+			}
+			return err
+		}
+		tip = t
+		return nil
+	})
+	if err != nil && staleTip != nil && ctx.Err() == nil {
+		return staleTip, nil
+	}
+	return tip, err
+}
+
+// GetMintedBlock returns the minted block of the first healthy backend. If
+// cross-checking is enabled, then the minted block is looked up with the next
+// healthy backend as well, and a chain.TemporaryError is returned, if their
+// answers disagree.
+func (b *Backend) GetMintedBlock(ctx context.Context,
+	slot uint) (*chain.MintedBlock, error) {
+
+	var mBlock *chain.MintedBlock
+	servedBy, err := b.query(ctx, "GetMintedBlock", func(m *member) error {
+		var err error
+		mBlock, err = m.backend.GetMintedBlock(ctx, slot)
+		return err
+	})
+	if err != nil || !b.config.CrossCheck {
+		return mBlock, err
+	}
+	err = b.crossCheck(ctx, servedBy, slot, mBlock)
+	if err != nil {
+		return nil, err
+	}
+	return mBlock, nil
+}
+
+// crossCheck looks up the minted block in the given slot with the next
+// healthy backend after the given one, and compares it with the given minted
+// block. The check is skipped, if there is no further healthy backend or it
+// failed.
+func (b *Backend) crossCheck(ctx context.Context, servedBy *member, slot uint,
+	mBlock *chain.MintedBlock) error {
+
+	var checker *member
+	now := b.config.Clock.Now()
+	b.lock.Lock()
+	for _, m := range b.members {
+		if m != servedBy && m.healthy(now) {
+			checker = m
+			break
+		}
+	}
+	b.lock.Unlock()
+	if checker == nil {
+		log.Debugf("no healthy backend is left for cross-checking slot %d",
+			slot)
+		return nil
+	}
+	other, err := checker.backend.GetMintedBlock(ctx, slot)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		b.failed(checker, err)
+		log.Warnf("cross-checking slot %d with backend '%s' failed: %s", slot,
+			checker.backend.Name(), err.Error())
+		return nil
+	}
+	if (mBlock == nil) != (other == nil) ||
+		(mBlock != nil && mBlock.Hash != other.Hash) {
+		return &chain.TemporaryError{
+			Err: fmt.Errorf("the backends '%s' and '%s' disagree about the block in slot %d",
+				servedBy.backend.Name(), checker.backend.Name(), slot),
+		}
+	}
+	return nil
+}
+
+func (b *Backend) TraverseAround(ctx context.Context, slot uint,
+	interval uint) (*chain.MintedBlock, error) {
+
+	var mBlock *chain.MintedBlock
+	_, err := b.query(ctx, "TraverseAround", func(m *member) error {
+		var err error
+		mBlock, err = m.backend.TraverseAround(ctx, slot, interval)
+		return err
+	})
+	return mBlock, err
+}
+
+func (b *Backend) GetStakePool(ctx context.Context,
+	hexID string) (*chain.StakePool, error) {
+
+	var pool *chain.StakePool
+	_, err := b.query(ctx, "GetStakePool", func(m *member) error {
+		var err error
+		pool, err = m.backend.GetStakePool(ctx, hexID)
+		return err
+	})
+	return pool, err
+}
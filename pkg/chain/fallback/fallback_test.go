@@ -0,0 +1,310 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+)
+
+const (
+	// slot is the slot, which is looked up in the tests.
+	slot uint = 55904400
+	// cooldown is the cooldown of the tested Backend.
+	cooldown = time.Minute
+	// maxTipAge is the age after which the tip of a backend is stale.
+	maxTipAge = 10 * time.Minute
+)
+
+// startTime is the time to which the clock is set at the start of a test.
+var startTime = time.Date(2022, 3, 16, 23, 0, 0, 0, time.UTC)
+
+// stubBackend is a chain.Backend with a configurable tip and minted block.
+type stubBackend struct {
+	lock  sync.Mutex
+	name  string
+	tip   *chain.Tip
+	block *chain.MintedBlock
+	err   error
+	calls int
+}
+
+// newStubBackend creates a backend with the given name, whose tip has been
+// minted at the given time, and which knows no minted block.
+func newStubBackend(name string, tipTime time.Time) *stubBackend {
+	b := &stubBackend{name: name}
+	b.setTip(tipTime)
+	return b
+}
+
+// setTip sets the tip of this backend to a block minted at the given time.
+func (b *stubBackend) setTip(tipTime time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.tip = &chain.Tip{
+		Height:    uint(tipTime.Unix()),
+		Hash:      b.name,
+		Timestamp: uint(tipTime.Unix()),
+		Source:    b.name,
+	}
+}
+
+// setBlock lets this backend answer the queries for the slot with a block
+// with the given hash, or with no block, if the hash is empty.
+func (b *stubBackend) setBlock(hash string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.block = nil
+	if hash != "" {
+		b.block = &chain.MintedBlock{Tip: chain.Tip{Slot: slot, Hash: hash,
+			Source: b.name}}
+	}
+}
+
+// setErr lets all the queries of this backend fail with the given error, or
+// succeed, if it is nil.
+func (b *stubBackend) setErr(err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.err = err
+}
+
+// callCount returns the number of queries of this backend.
+func (b *stubBackend) callCount() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.calls
+}
+
+func (b *stubBackend) Name() string {
+	return b.name
+}
+
+func (b *stubBackend) GetLatestBlock(ctx context.Context) (*chain.Tip, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.calls++
+	if b.err != nil {
+		return nil, b.err
+	}
+	tip := *b.tip
+	return &tip, nil
+}
+
+func (b *stubBackend) GetMintedBlock(ctx context.Context,
+	slot uint) (*chain.MintedBlock, error) {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.calls++
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.block, nil
+}
+
+func (b *stubBackend) TraverseAround(ctx context.Context, slot uint,
+	interval uint) (*chain.MintedBlock, error) {
+
+	return b.GetMintedBlock(ctx, slot)
+}
+
+func (b *stubBackend) GetStakePool(ctx context.Context,
+	hexID string) (*chain.StakePool, error) {
+
+	return &chain.StakePool{HexID: hexID}, nil
+}
+
+// newTestBackend creates a Backend failing over between the given backends.
+func newTestBackend(t *testing.T, clk clock.Clock, crossCheck bool,
+	backends ...*stubBackend) *Backend {
+
+	members := make([]chain.Backend, len(backends))
+	for i, backend := range backends {
+		members[i] = backend
+	}
+	b, err := NewBackend(members, &Configuration{
+		MaxTipAge:  maxTipAge,
+		Cooldown:   cooldown,
+		CrossCheck: crossCheck,
+		Clock:      clk,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// expectHealth checks whether the backends of the given Backend are healthy
+// and stale as expected in their order.
+func expectHealth(t *testing.T, b *Backend, healthy []bool, stale []bool) {
+	t.Helper()
+	for i, status := range b.Status() {
+		if status.Healthy != healthy[i] || status.Stale != stale[i] {
+			t.Fatalf("expected backend '%s' to be healthy=%t and stale=%t, "+
+				"got healthy=%t and stale=%t", status.Name, healthy[i],
+				stale[i], status.Healthy, status.Stale)
+		}
+	}
+}
+
+// expectBlock looks up the slot with the given Backend, and checks whether it
+// is answered with the block with the given hash by the given backend.
+func expectBlock(t *testing.T, b *Backend, hash, source string) {
+	t.Helper()
+	mBlock, err := b.GetMintedBlock(context.Background(), slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == "" {
+		if mBlock != nil {
+			t.Fatalf("expected no block, got '%s' of '%s'", mBlock.Hash,
+				mBlock.Source)
+		}
+		return
+	}
+	if mBlock == nil || mBlock.Hash != hash || mBlock.Source != source {
+		t.Fatalf("expected block '%s' of '%s', got %+v", hash, source, mBlock)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	a := newStubBackend("a", startTime)
+	a.setBlock("block")
+	c := newStubBackend("c", startTime)
+	c.setBlock("block")
+	b := newTestBackend(t, clk, false, a, c)
+	expectBlock(t, b, "block", "a")
+	a.setErr(errors.New("unavailable"))
+	expectBlock(t, b, "block", "c")
+	expectHealth(t, b, []bool{false, true}, []bool{false, false})
+	// the failed backend isn't queried during its cooldown.
+	a.setErr(nil)
+	calls := a.callCount()
+	expectBlock(t, b, "block", "c")
+	if a.callCount() != calls {
+		t.Fatal("expected the failed backend not to be queried")
+	}
+	clk.Advance(cooldown)
+	expectBlock(t, b, "block", "a")
+	expectHealth(t, b, []bool{true, true}, []bool{false, false})
+	// the failed backend is queried as last resort.
+	a.setErr(errors.New("unavailable"))
+	expectBlock(t, b, "block", "c")
+	a.setErr(nil)
+	c.setErr(errors.New("unavailable"))
+	expectBlock(t, b, "block", "a")
+	a.setErr(errors.New("unavailable"))
+	_, err := b.GetMintedBlock(context.Background(), slot)
+	if err == nil {
+		t.Fatal("expected an error, if all backends failed")
+	}
+	status := b.Status()
+	if status[0].Served != 3 || status[0].Failures != 3 ||
+		status[1].Served != 3 || status[1].Failures != 2 {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
+
+func TestStaleness(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	a := newStubBackend("a", startTime.Add(-time.Hour))
+	c := newStubBackend("c", startTime)
+	c.setBlock("block")
+	b := newTestBackend(t, clk, false, a, c)
+	tip, err := b.GetLatestBlock(context.Background())
+	if err != nil || tip.Source != "c" {
+		t.Fatalf("expected the tip of 'c', got %+v (%v)", tip, err)
+	}
+	expectHealth(t, b, []bool{false, true}, []bool{true, false})
+	// the stale backend, which hasn't seen the minted block yet, must not
+	// answer the query, even after its cooldown.
+	clk.Advance(2 * cooldown)
+	expectHealth(t, b, []bool{false, true}, []bool{true, false})
+	expectBlock(t, b, "block", "c")
+	tip, err = b.GetLatestBlock(context.Background())
+	if err != nil || tip.Source != "c" {
+		t.Fatalf("expected the tip of 'c', got %+v (%v)", tip, err)
+	}
+	expectHealth(t, b, []bool{false, true}, []bool{true, false})
+	// the backend caught up, which is only checked after the cooldown.
+	a.setTip(clk.Now())
+	a.setBlock("block")
+	tip, err = b.GetLatestBlock(context.Background())
+	if err != nil || tip.Source != "c" {
+		t.Fatalf("expected the tip of 'c', got %+v (%v)", tip, err)
+	}
+	expectBlock(t, b, "block", "c")
+	clk.Advance(cooldown)
+	tip, err = b.GetLatestBlock(context.Background())
+	if err != nil || tip.Source != "a" {
+		t.Fatalf("expected the tip of 'a', got %+v (%v)", tip, err)
+	}
+	expectHealth(t, b, []bool{true, true}, []bool{false, false})
+	expectBlock(t, b, "block", "a")
+}
+
+func TestAllStale(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	a := newStubBackend("a", startTime.Add(-2*time.Hour))
+	c := newStubBackend("c", startTime.Add(-time.Hour))
+	b := newTestBackend(t, clk, false, a, c)
+	tip, err := b.GetLatestBlock(context.Background())
+	if err != nil || tip.Source != "c" {
+		t.Fatalf("expected the latest stale tip of 'c', got %+v (%v)", tip,
+			err)
+	}
+	expectHealth(t, b, []bool{false, false}, []bool{true, true})
+}
+
+func TestCrossCheck(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	a := newStubBackend("a", startTime)
+	a.setBlock("block")
+	c := newStubBackend("c", startTime)
+	c.setBlock("block")
+	b := newTestBackend(t, clk, true, a, c)
+	expectBlock(t, b, "block", "a")
+	if c.callCount() != 1 {
+		t.Fatal("expected the block to be cross-checked with 'c'")
+	}
+	for _, hash := range []string{"", "other"} {
+		c.setBlock(hash)
+		_, err := b.GetMintedBlock(context.Background(), slot)
+		if err == nil || !chain.IsTemporary(err) {
+			t.Fatalf("expected a temporary error for the disagreement with "+
+				"'%s', got %v", hash, err)
+		}
+	}
+	// the check is skipped, if the checking backend failed.
+	c.setErr(errors.New("unavailable"))
+	expectBlock(t, b, "block", "a")
+	expectHealth(t, b, []bool{true, false}, []bool{false, false})
+	// the check is skipped, while the checking backend cools down.
+	c.setErr(nil)
+	c.setBlock("")
+	expectBlock(t, b, "block", "a")
+}
+
+func TestCrossCheckSkipsStaleBackends(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	a := newStubBackend("a", startTime.Add(-time.Hour))
+	c := newStubBackend("c", startTime)
+	c.setBlock("block")
+	b := newTestBackend(t, clk, true, a, c)
+	_, err := b.GetLatestBlock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the stale backend hasn't seen the block yet, and mustn't veto it.
+	clk.Advance(2 * cooldown)
+	expectBlock(t, b, "block", "c")
+	if a.callCount() != 1 {
+		t.Fatal("expected the stale backend not to be queried")
+	}
+}
@@ -0,0 +1,281 @@
+package koios
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain/poolcache"
+)
+
+// DefaultURL is the base URL of the public Koios API for the mainnet.
+const DefaultURL = "https://api.koios.rest/api/v1"
+
+// Backend is an implementation of chain.Backend that makes use of the Koios
+// REST API.
+type Backend struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+	cache   *poolcache.Cache
+}
+
+// APIError is returned, when the Koios API responded with an unexpected
+// status code. It is wrapped into a chain.TemporaryError, if the status code
+// signals a temporary failure.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Message is the body of the response.
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("koios responded with status %d: %s", e.StatusCode,
+		e.Message)
+}
+
+// block is a block as returned by the Koios API.
+type block struct {
+	Hash      string `json:"hash"`
+	Epoch     uint   `json:"epoch_no"`
+	Slot      uint   `json:"abs_slot"`
+	EpochSlot uint   `json:"epoch_slot"`
+	Height    uint   `json:"block_height"`
+	Time      uint   `json:"block_time"`
+	Pool      string `json:"pool"`
+}
+
+// poolInfo is the information about a stake pool as returned by the Koios API.
+type poolInfo struct {
+	Bech32ID string `json:"pool_id_bech32"`
+	HexID    string `json:"pool_id_hex"`
+	Metadata *struct {
+		Name   string `json:"name"`
+		Ticker string `json:"ticker"`
+	} `json:"meta_json"`
+}
+
+// NewKoiosBackend is creating a new chain.Backend that uses the Koios API at
+// the given base URL. An API key for Koios is optional. If it is specified in
+// the environment as 'BLU_KOIOS_API_KEY', then it is passed as bearer token.
+// The metadata of pools is cached in the given poolcache.Cache, and only in
+// memory, if it is nil.
+func NewKoiosBackend(baseURL string,
+	cache *poolcache.Cache) (chain.Backend, error) {
+
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	_, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("the base URL '%s' for koios is invalid: %s",
+			baseURL, err.Error())
+	}
+	if cache == nil {
+		cache = poolcache.NewCache(nil, nil)
+	}
+	return &Backend{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  os.Getenv("BLU_KOIOS_API_KEY"),
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		cache: cache,
+	}, nil
+}
+
+func (b *Backend) Name() string {
+	return "koios"
+}
+
+// request sends a request with the given method to the given path of the
+// Koios API, and decodes the JSON response into the given value. The given
+// body is sent as JSON, if it isn't nil.
+func (b *Backend) request(ctx context.Context, method, path string,
+	body interface{}, v interface{}) error {
+
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+b.apiKey)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := ioutil.ReadAll(resp.Body)
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Message:    string(message),
+		}
+		if chain.IsTemporaryStatus(resp.StatusCode) {
+			return &chain.TemporaryError{
+				StatusCode: resp.StatusCode,
+				RetryAfter: chain.ParseRetryAfter(resp.Header.Get("Retry-After")),
+				Err:        apiErr,
+			}
+		}
+		return apiErr
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (b *Backend) GetLatestBlock(ctx context.Context) (*chain.Tip, error) {
+	var blocks []block
+	err := b.request(ctx, http.MethodGet, "/tip", nil, &blocks)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("koios returned no tip")
+	}
+	tip := blocks[0].toTip()
+	tip.Source = b.Name()
+	return &tip, nil
+}
+
+// fetchPool fetches the metadata of the pool with the given ID in hex format
+// from the Koios API.
+func (b *Backend) fetchPool(ctx context.Context,
+	hexID string) (*chain.StakePool, error) {
+
+	bech32ID, err := chain.PoolIDToBech32(hexID)
+	if err != nil {
+		return nil, err
+	}
+	var infos []poolInfo
+	err = b.request(ctx, http.MethodPost, "/pool_info", map[string][]string{
+		"_pool_bech32_ids": {bech32ID},
+	}, &infos)
+	if err != nil {
+		return nil, err
+	}
+	pool := chain.StakePool{
+		HexID: hexID,
+	}
+	if len(infos) > 0 && infos[0].Metadata != nil {
+		pool.Ticker = infos[0].Metadata.Ticker
+		pool.Name = infos[0].Metadata.Name
+	}
+	return &pool, nil
+}
+
+func (b *Backend) GetStakePool(ctx context.Context,
+	hexID string) (*chain.StakePool, error) {
+
+	return b.cache.Fetch(ctx, hexID, b.fetchPool)
+}
+
+// toMintedBlock transforms the given block of the Koios API into a minted
+// block, which includes the metadata of the pool that minted the block.
+func (b *Backend) toMintedBlock(ctx context.Context,
+	blk *block) (*chain.MintedBlock, error) {
+
+	hexID, err := chain.PoolIDToHex(blk.Pool)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := b.GetStakePool(ctx, hexID)
+	if err != nil {
+		return nil, err
+	}
+	mBlock := &chain.MintedBlock{
+		Tip:  blk.toTip(),
+		Pool: *pool,
+	}
+	mBlock.Source = b.Name()
+	return mBlock, nil
+}
+
+func (b *Backend) GetMintedBlock(ctx context.Context,
+	slot uint) (*chain.MintedBlock, error) {
+
+	var blocks []block
+	err := b.request(ctx, http.MethodGet,
+		fmt.Sprintf("/blocks?abs_slot=eq.%d", slot), nil, &blocks)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+	return b.toMintedBlock(ctx, &blocks[0])
+}
+
+func (b *Backend) TraverseAround(ctx context.Context, slot uint,
+	interval uint) (*chain.MintedBlock, error) {
+
+	from := uint(0)
+	if slot > interval {
+		from = slot - interval
+	}
+	var blocks []block
+	err := b.request(ctx, http.MethodGet,
+		fmt.Sprintf("/blocks?abs_slot=gte.%d&abs_slot=lte.%d&order=abs_slot.asc",
+			from, slot+interval), nil, &blocks)
+	if err != nil {
+		return nil, err
+	}
+	var nearest *block
+	var nearestDistance uint
+	for i := range blocks {
+		blk := &blocks[i]
+		if blk.Slot == slot {
+			continue
+		}
+		distance := blk.Slot - slot
+		if blk.Slot < slot {
+			distance = slot - blk.Slot
+		}
+		// the slot after the given slot is preferred over the slot before
+		// with the same distance.
+		if nearest == nil || distance < nearestDistance ||
+			(distance == nearestDistance && blk.Slot > nearest.Slot) {
+			nearest = blk
+			nearestDistance = distance
+		}
+	}
+	if nearest == nil {
+		return nil, nil
+	}
+	return b.toMintedBlock(ctx, nearest)
+}
+
+// toTip transforms this block of the Koios API into a chain.Tip.
+func (blk *block) toTip() chain.Tip {
+	return chain.Tip{
+		Height:      blk.Height,
+		Hash:        blk.Hash,
+		Epoch:       blk.Epoch,
+		SlotInEpoch: blk.EpochSlot,
+		Slot:        blk.Slot,
+		Timestamp:   blk.Time,
+	}
+}
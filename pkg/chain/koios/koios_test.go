@@ -0,0 +1,222 @@
+package koios
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+)
+
+const (
+	testPoolHexID    = "cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214b"
+	testPoolBech32ID = "pool1ekhy5xsgjaq38em75vevk8df0k0rljju77tljw288ys5kumqce5"
+)
+
+// stub is a local stand-in for the Koios API, which serves the responses
+// of the given blocks in the shape of Koios.
+type stub struct {
+	blocks      []map[string]interface{}
+	status      int
+	retryAfter  string
+	auth        string
+	poolIDs     []string
+	poolLookups int
+}
+
+func (s *stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.auth = r.Header.Get("Authorization")
+	if s.status != 0 {
+		if s.retryAfter != "" {
+			w.Header().Set("Retry-After", s.retryAfter)
+		}
+		w.WriteHeader(s.status)
+		_, _ = w.Write([]byte(`{"message":"stubbed failure"}`))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/tip":
+		_ = json.NewEncoder(w).Encode(s.blocks[len(s.blocks)-1:])
+	case "/blocks":
+		var matching []map[string]interface{}
+		for _, blk := range s.blocks {
+			if matches(blk["abs_slot"].(int), r.URL.Query()["abs_slot"]) {
+				matching = append(matching, blk)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(matching)
+	case "/pool_info":
+		s.poolLookups++
+		var body struct {
+			IDs []string `json:"_pool_bech32_ids"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		s.poolIDs = body.IDs
+		_, _ = w.Write([]byte(`[{
+			"pool_id_bech32": "` + testPoolBech32ID + `",
+			"pool_id_hex": "` + testPoolHexID + `",
+			"active_stake": "12345678",
+			"meta_json": {"name": "BlockBlu Pool", "ticker": "BLUE", "homepage": "https://blockblu.io"}
+		}]`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// matches checks whether the given slot matches all the given PostgREST
+// filters of the form "eq.N", "gte.N" and "lte.N".
+func matches(slot int, filters []string) bool {
+	for _, filter := range filters {
+		parts := strings.SplitN(filter, ".", 2)
+		if len(parts) != 2 {
+			return false
+		}
+		op := parts[0]
+		value, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return false
+		}
+		switch {
+		case op == "eq" && slot != value,
+			op == "gte" && slot < value,
+			op == "lte" && slot > value:
+			return false
+		}
+	}
+	return true
+}
+
+// testBlock returns a block in the shape of the Koios API, which has been
+// minted by the test pool in the given slot.
+func testBlock(slot int) map[string]interface{} {
+	return map[string]interface{}{
+		"hash":         fmt.Sprintf("hash-%d", slot),
+		"epoch_no":     327,
+		"abs_slot":     slot,
+		"epoch_slot":   slot - 55900800,
+		"block_height": 7000000 + slot - 55900800,
+		"block_time":   1647467091 + slot - 55900800,
+		"block_size":   4096,
+		"tx_count":     5,
+		"pool":         testPoolBech32ID,
+	}
+}
+
+func newTestBackend(t *testing.T, s *stub) *Backend {
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	backend, err := NewKoiosBackend(server.URL+"/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return backend.(*Backend)
+}
+
+func TestGetLatestBlock(t *testing.T) {
+	t.Setenv("BLU_KOIOS_API_KEY", "secret")
+	s := &stub{blocks: []map[string]interface{}{testBlock(55900900),
+		testBlock(55900920)}}
+	tip, err := newTestBackend(t, s).GetLatestBlock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := chain.Tip{
+		Height:      7000120,
+		Hash:        testBlock(55900920)["hash"].(string),
+		Epoch:       327,
+		SlotInEpoch: 120,
+		Slot:        55900920,
+		Timestamp:   1647467211,
+		Source:      "koios",
+	}
+	if *tip != want {
+		t.Errorf("expected tip %+v, got %+v", want, *tip)
+	}
+	if s.auth != "Bearer secret" {
+		t.Errorf("expected the API key as bearer token, got '%s'", s.auth)
+	}
+}
+
+func TestGetMintedBlock(t *testing.T) {
+	s := &stub{blocks: []map[string]interface{}{testBlock(55900900)}}
+	backend := newTestBackend(t, s)
+	block, err := backend.GetMintedBlock(context.Background(), 55900900)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block == nil || block.Slot != 55900900 || block.Epoch != 327 ||
+		block.SlotInEpoch != 100 || block.Source != "koios" {
+		t.Fatalf("expected the block in slot 55900900, got %+v", block)
+	}
+	wantPool := chain.StakePool{Ticker: "BLUE", Name: "BlockBlu Pool",
+		HexID: testPoolHexID}
+	if block.Pool != wantPool {
+		t.Errorf("expected pool %+v, got %+v", wantPool, block.Pool)
+	}
+	if len(s.poolIDs) != 1 || s.poolIDs[0] != testPoolBech32ID {
+		t.Errorf("expected the pool to be looked up by its bech32 ID, got %v",
+			s.poolIDs)
+	}
+	_, err = backend.GetMintedBlock(context.Background(), 55900900)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.poolLookups != 1 {
+		t.Errorf("expected the pool metadata to be cached, but it has been looked up %d times",
+			s.poolLookups)
+	}
+	block, err = backend.GetMintedBlock(context.Background(), 55900901)
+	if err != nil || block != nil {
+		t.Errorf("expected no block in slot 55900901, got %+v (%v)", block, err)
+	}
+}
+
+func TestTraverseAround(t *testing.T) {
+	s := &stub{blocks: []map[string]interface{}{testBlock(55900890),
+		testBlock(55900900), testBlock(55900910), testBlock(55900950)}}
+	backend := newTestBackend(t, s)
+	block, err := backend.TraverseAround(context.Background(), 55900900, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block == nil || block.Slot != 55900910 {
+		t.Fatalf("expected the later block at the same distance, got %+v", block)
+	}
+	block, err = backend.TraverseAround(context.Background(), 55900930, 5)
+	if err != nil || block != nil {
+		t.Errorf("expected no block around slot 55900930, got %+v (%v)",
+			block, err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	s := &stub{status: http.StatusTooManyRequests, retryAfter: "7"}
+	backend := newTestBackend(t, s)
+	_, err := backend.GetLatestBlock(context.Background())
+	var temporaryErr *chain.TemporaryError
+	if !errors.As(err, &temporaryErr) || !temporaryErr.RateLimited() ||
+		temporaryErr.RetryAfter != 7*time.Second {
+		t.Fatalf("expected a rate limit error with a delay of 7s, got %v", err)
+	}
+	s.status = http.StatusBadGateway
+	s.retryAfter = ""
+	_, err = backend.GetMintedBlock(context.Background(), 55900900)
+	if !chain.IsTemporary(err) {
+		t.Errorf("expected a server error to be temporary, got %v", err)
+	}
+	s.status = http.StatusBadRequest
+	_, err = backend.TraverseAround(context.Background(), 55900900, 10)
+	var apiErr *APIError
+	if chain.IsTemporary(err) || !errors.As(err, &apiErr) ||
+		apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a permanent API error, got %v", err)
+	}
+}
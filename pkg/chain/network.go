@@ -0,0 +1,120 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PreShelleyError is returned, if an epoch or slot before the Shelley era is
+// passed to a conversion of a Network, which only covers the Shelley era and
+// later.
+var PreShelleyError = errors.New("the epoch or slot is before the Shelley era")
+
+// Network contains the parameters of a Cardano network that are needed to
+// convert between slot numbers, epochs and wall-clock time. Only the Shelley
+// era and later is covered, since leader logs aren't available before.
+type Network struct {
+	// Name is the unique name of the network.
+	Name string
+	// ShelleyStartEpoch is the first epoch of the Shelley era.
+	ShelleyStartEpoch uint
+	// ShelleyStartSlot is the first slot of the Shelley era. The number is
+	// counted from the inception of the chain.
+	ShelleyStartSlot uint
+	// ShelleyStartTime is the starting time of the first slot of the Shelley
+	// era.
+	ShelleyStartTime time.Time
+	// EpochLength is the number of slots in an epoch.
+	EpochLength uint
+	// SlotLength is the duration of a single slot.
+	SlotLength time.Duration
+}
+
+var (
+	// Mainnet are the network parameters of the Cardano mainnet.
+	Mainnet = &Network{
+		Name:              "mainnet",
+		ShelleyStartEpoch: 208,
+		ShelleyStartSlot:  4492800,
+		ShelleyStartTime:  time.Date(2020, 7, 29, 21, 44, 51, 0, time.UTC),
+		EpochLength:       432000,
+		SlotLength:        1 * time.Second,
+	}
+	// Preprod are the network parameters of the Cardano pre-production
+	// testnet.
+	Preprod = &Network{
+		Name:              "preprod",
+		ShelleyStartEpoch: 4,
+		ShelleyStartSlot:  86400,
+		ShelleyStartTime:  time.Date(2022, 6, 21, 0, 0, 0, 0, time.UTC),
+		EpochLength:       432000,
+		SlotLength:        1 * time.Second,
+	}
+	// Preview are the network parameters of the Cardano preview testnet.
+	Preview = &Network{
+		Name:              "preview",
+		ShelleyStartEpoch: 0,
+		ShelleyStartSlot:  0,
+		ShelleyStartTime:  time.Date(2022, 10, 25, 0, 0, 0, 0, time.UTC),
+		EpochLength:       86400,
+		SlotLength:        1 * time.Second,
+	}
+)
+
+// GetNetwork returns the network parameters for the network with the given
+// name. An error will be returned, if no network with this name is known.
+func GetNetwork(name string) (*Network, error) {
+	for _, network := range []*Network{Mainnet, Preprod, Preview} {
+		if network.Name == name {
+			return network, nil
+		}
+	}
+	return nil, fmt.Errorf("the network '%s' is unknown", name)
+}
+
+// FirstSlotOfEpoch returns the number of the first slot in the given epoch.
+// The number is counted from the inception of the chain. PreShelleyError is
+// returned, if the epoch is before the Shelley era.
+func (n *Network) FirstSlotOfEpoch(epoch uint) (uint, error) {
+	if epoch < n.ShelleyStartEpoch {
+		return 0, PreShelleyError
+	}
+	return n.ShelleyStartSlot + (epoch-n.ShelleyStartEpoch)*n.EpochLength, nil
+}
+
+// EpochOfSlot returns the epoch of the given slot as well as the slot number
+// counted from the start of this epoch. PreShelleyError is returned, if the
+// slot is before the Shelley era.
+func (n *Network) EpochOfSlot(slot uint) (epoch uint, epochSlot uint, err error) {
+	if slot < n.ShelleyStartSlot {
+		return 0, 0, PreShelleyError
+	}
+	shelleySlot := slot - n.ShelleyStartSlot
+	return n.ShelleyStartEpoch + shelleySlot/n.EpochLength,
+		shelleySlot % n.EpochLength, nil
+}
+
+// SlotTime returns the starting time of the given slot. PreShelleyError is
+// returned, if the slot is before the Shelley era.
+func (n *Network) SlotTime(slot uint) (time.Time, error) {
+	if slot < n.ShelleyStartSlot {
+		return time.Time{}, PreShelleyError
+	}
+	shelleySlot := time.Duration(slot - n.ShelleyStartSlot)
+	return n.ShelleyStartTime.Add(shelleySlot * n.SlotLength), nil
+}
+
+// EpochEnd returns the time at which the given epoch ends, i.e. the starting
+// time of the first slot of the next epoch. PreShelleyError is returned, if
+// the epoch is before the Shelley era.
+func (n *Network) EpochEnd(epoch uint) (time.Time, error) {
+	if epoch < n.ShelleyStartEpoch {
+		return time.Time{}, PreShelleyError
+	}
+	firstSlot, err := n.FirstSlotOfEpoch(epoch + 1)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return n.SlotTime(firstSlot)
+}
@@ -0,0 +1,50 @@
+package chain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNetworkConversions(t *testing.T) {
+	firstSlot, err := Mainnet.FirstSlotOfEpoch(327)
+	if err != nil || firstSlot != 55900800 {
+		t.Fatalf("expected first slot 55900800 of epoch 327, got %d (%v)",
+			firstSlot, err)
+	}
+	epoch, epochSlot, err := Mainnet.EpochOfSlot(55900800 + 1234)
+	if err != nil || epoch != 327 || epochSlot != 1234 {
+		t.Fatalf("expected slot 1234 in epoch 327, got %d in %d (%v)",
+			epochSlot, epoch, err)
+	}
+	slotTime, err := Mainnet.SlotTime(55900800)
+	want := time.Date(2022, 3, 16, 21, 44, 51, 0, time.UTC)
+	if err != nil || !slotTime.Equal(want) {
+		t.Fatalf("expected slot time %s, got %s (%v)", want, slotTime, err)
+	}
+	epochEnd, err := Mainnet.EpochEnd(327)
+	want = want.Add(5 * 24 * time.Hour)
+	if err != nil || !epochEnd.Equal(want) {
+		t.Fatalf("expected epoch end %s, got %s (%v)", want, epochEnd, err)
+	}
+}
+
+func TestNetworkRejectsPreShelley(t *testing.T) {
+	if _, err := Mainnet.FirstSlotOfEpoch(207); err != PreShelleyError {
+		t.Errorf("expected PreShelleyError for epoch 207, got %v", err)
+	}
+	if _, _, err := Mainnet.EpochOfSlot(4492799); err != PreShelleyError {
+		t.Errorf("expected PreShelleyError for slot 4492799, got %v", err)
+	}
+	if _, err := Mainnet.SlotTime(0); err != PreShelleyError {
+		t.Errorf("expected PreShelleyError for slot 0, got %v", err)
+	}
+	if _, err := Mainnet.EpochEnd(0); err != PreShelleyError {
+		t.Errorf("expected PreShelleyError for epoch 0, got %v", err)
+	}
+	if _, err := Mainnet.FirstSlotOfEpoch(208); err != nil {
+		t.Errorf("expected the first Shelley epoch to be accepted, got %v", err)
+	}
+	if _, _, err := Preview.EpochOfSlot(0); err != nil {
+		t.Errorf("expected slot 0 of preview to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,399 @@
+package ogmios
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// DefaultURL is the URL at which Ogmios is served by default.
+	DefaultURL = "ws://localhost:1337"
+	// DefaultWindowSize is the default number of the latest block headers that
+	// are kept in memory, which covers roughly one day on the mainnet.
+	DefaultWindowSize = 4320
+	// reconnectInterval is the interval after which a new connection to Ogmios
+	// is established, if the previous one failed.
+	reconnectInterval = 10 * time.Second
+)
+
+// Backend is an implementation of chain.Backend that follows the chain with
+// the chain-sync protocol of Ogmios. It keeps a rolling window of the latest
+// block headers in memory such that minted blocks can be looked up without any
+// further requests. Lookups of slots outside of this window are delegated to
+// a fallback chain.Backend.
+type Backend struct {
+	url      string
+	network  *chain.Network
+	fallback chain.Backend
+	window   *window
+	subs     subscribers
+}
+
+// subscribers keeps track of the channels subscribed to tip updates.
+type subscribers struct {
+	lock      sync.Mutex
+	idCounter int
+	channels  map[int]chan chain.Tip
+}
+
+// NewOgmiosBackend is creating a new chain.Backend that follows the chain with
+// Ogmios at the given websocket URL. The given number of latest block headers
+// are kept in memory. The given fallback is used to look up slots outside of
+// this window, and it can be nil, if such lookups shall fail instead.
+func NewOgmiosBackend(url string, network *chain.Network, windowSize int,
+	fallback chain.Backend) (*Backend, error) {
+
+	if url == "" {
+		url = DefaultURL
+	}
+	if windowSize <= 0 {
+		return nil, fmt.Errorf("the window size for ogmios must be positive, but was %d",
+			windowSize)
+	}
+	return &Backend{
+		url:      url,
+		network:  network,
+		fallback: fallback,
+		window:   newWindow(windowSize),
+		subs: subscribers{
+			channels: make(map[int]chan chain.Tip),
+		},
+	}, nil
+}
+
+func (b *Backend) Name() string {
+	return "ogmios"
+}
+
+// Backends returns the fallback, to which lookups of slots outside of the
+// window are delegated, or no backend, if no fallback has been specified.
+func (b *Backend) Backends() []chain.Backend {
+	if b.fallback == nil {
+		return nil
+	}
+	return []chain.Backend{b.fallback}
+}
+
+// Follow follows the chain with Ogmios until the given context has been
+// canceled. A new connection is established, if the connection fails.
+func (b *Backend) Follow(ctx context.Context) {
+	for {
+		err := b.follow(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Errorf("following the chain with ogmios failed: %s", err.Error())
+		timer := time.NewTimer(reconnectInterval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// follow connects to Ogmios, finds an intersection with the headers of the
+// window or otherwise the tip, and then rolls the window forward and backward
+// as instructed by Ogmios. This method only returns, if the connection failed
+// or the given context has been canceled.
+func (b *Backend) follow(ctx context.Context) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, b.url, nil)
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+			_ = conn.Close()
+		}
+	}()
+	c := &client{conn: conn}
+	err = b.findIntersection(c)
+	if err != nil {
+		return err
+	}
+	log.Infof("following the chain with ogmios at '%s'", b.url)
+	for {
+		var result nextBlockResult
+		err = c.call("nextBlock", nil, &result)
+		if err != nil {
+			return err
+		}
+		switch result.Direction {
+		case "forward":
+			b.rollForward(&result)
+		case "backward":
+			p, err := parsePoint(result.Point)
+			if err != nil {
+				return err
+			}
+			if p == nil {
+				b.window.reset(0)
+			} else {
+				log.Infof("rolled back to slot %d", p.Slot)
+				b.window.rollback(p.Slot)
+			}
+		}
+	}
+}
+
+// findIntersection finds the intersection with the chain. The latest headers
+// of the window are tried first, and if none of them is on the chain anymore,
+// the window is reset and the following starts at the current tip.
+func (b *Backend) findIntersection(c *client) error {
+	points := b.window.points(10)
+	if len(points) > 0 {
+		var result intersectionResult
+		err := c.call("findIntersection", map[string]interface{}{
+			"points": points,
+		}, &result)
+		if err == nil {
+			p, err := parsePoint(result.Intersection)
+			if err != nil {
+				return err
+			}
+			if p != nil {
+				b.window.rollback(p.Slot)
+				return nil
+			}
+		} else if rpcErr, ok := err.(*RPCError); !ok || rpcErr.Code != intersectionNotFoundCode {
+			return err
+		}
+	}
+	var tipData json.RawMessage
+	err := c.call("queryNetwork/tip", nil, &tipData)
+	if err != nil {
+		return err
+	}
+	tip, err := parsePoint(tipData)
+	if err != nil {
+		return err
+	}
+	var intersection interface{} = "origin"
+	from := uint(0)
+	if tip != nil {
+		intersection = tip
+		from = tip.Slot + 1
+	}
+	var result intersectionResult
+	err = c.call("findIntersection", map[string]interface{}{
+		"points": []interface{}{intersection},
+	}, &result)
+	if err != nil {
+		return err
+	}
+	b.window.reset(from)
+	return nil
+}
+
+// rollForward adds the block of the given result to the window. The tip is
+// pushed to the subscribers, if the block is the tip of the chain.
+func (b *Backend) rollForward(result *nextBlockResult) {
+	if result.Block == nil {
+		return
+	}
+	h := header{
+		slot:   result.Block.Slot,
+		height: result.Block.Height,
+		hash:   result.Block.ID,
+	}
+	if result.Block.Issuer != nil {
+		poolID, err := poolIDOfVerificationKey(result.Block.Issuer.VerificationKey)
+		if err != nil {
+			log.Warnf("the issuer of block '%s' couldn't be decoded: %s",
+				h.hash, err.Error())
+		} else {
+			h.poolID = poolID
+		}
+	}
+	b.window.add(h)
+	tip, err := parsePoint(result.Tip)
+	if err == nil && tip != nil && tip.Slot == h.slot {
+		t, err := b.toTip(&h)
+		if err != nil {
+			log.Warnf("the tip at slot %d couldn't be pushed: %s", h.slot,
+				err.Error())
+			return
+		}
+		b.subs.notify(t)
+	}
+}
+
+// poolIDOfVerificationKey computes the pool ID in hex format of the given cold
+// verification key in hex format. The pool ID is the Blake2b-224 hash of the
+// key.
+func poolIDOfVerificationKey(vkey string) (string, error) {
+	key, err := hex.DecodeString(vkey)
+	if err != nil {
+		return "", err
+	}
+	hash, err := blake2b.New(28, nil)
+	if err != nil {
+		return "", err
+	}
+	hash.Write(key)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// toTip transforms the given header into a chain.Tip. An error is returned,
+// if the slot of the header is before the Shelley era.
+func (b *Backend) toTip(h *header) (chain.Tip, error) {
+	epoch, epochSlot, err := b.network.EpochOfSlot(h.slot)
+	if err != nil {
+		return chain.Tip{}, err
+	}
+	slotTime, err := b.network.SlotTime(h.slot)
+	if err != nil {
+		return chain.Tip{}, err
+	}
+	return chain.Tip{
+		Height:      h.height,
+		Hash:        h.hash,
+		Epoch:       epoch,
+		SlotInEpoch: epochSlot,
+		Slot:        h.slot,
+		Timestamp:   uint(slotTime.Unix()),
+		Source:      b.Name(),
+	}, nil
+}
+
+// toMintedBlock transforms the given header into a chain.MintedBlock. Only
+// the ID of the pool is set, if its metadata couldn't be looked up.
+func (b *Backend) toMintedBlock(ctx context.Context,
+	h *header) (*chain.MintedBlock, error) {
+
+	tip, err := b.toTip(h)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := b.GetStakePool(ctx, h.poolID)
+	if err != nil {
+		log.Warnf("couldn't look up the metadata of pool=%s: %s", h.poolID,
+			err.Error())
+		pool = &chain.StakePool{HexID: h.poolID}
+	}
+	return &chain.MintedBlock{
+		Tip:  tip,
+		Pool: *pool,
+	}, nil
+}
+
+// OutOfWindowError is returned, if a slot before the window of block headers
+// or after its latest header has been looked up, and no fallback has been
+// specified.
+var OutOfWindowError = errors.New("the slot isn't covered by the window of ogmios")
+
+func (b *Backend) GetLatestBlock(ctx context.Context) (*chain.Tip, error) {
+	h := b.window.latest()
+	if h == nil {
+		if b.fallback != nil {
+			return b.fallback.GetLatestBlock(ctx)
+		}
+		return nil, fmt.Errorf("no block has been received from ogmios yet")
+	}
+	tip, err := b.toTip(h)
+	if err != nil {
+		return nil, err
+	}
+	return &tip, nil
+}
+
+func (b *Backend) GetMintedBlock(ctx context.Context,
+	slot uint) (*chain.MintedBlock, error) {
+
+	if !b.window.covers(slot, slot) {
+		if b.fallback != nil {
+			return b.fallback.GetMintedBlock(ctx, slot)
+		}
+		return nil, OutOfWindowError
+	}
+	h := b.window.find(slot)
+	if h == nil {
+		return nil, nil
+	}
+	return b.toMintedBlock(ctx, h)
+}
+
+func (b *Backend) TraverseAround(ctx context.Context, slot uint,
+	interval uint) (*chain.MintedBlock, error) {
+
+	from := uint(0)
+	if slot > interval {
+		from = slot - interval
+	}
+	if !b.window.covers(from, slot+interval) {
+		if b.fallback != nil {
+			return b.fallback.TraverseAround(ctx, slot, interval)
+		}
+		return nil, OutOfWindowError
+	}
+	h := b.window.nearest(slot, interval)
+	if h == nil {
+		return nil, nil
+	}
+	return b.toMintedBlock(ctx, h)
+}
+
+// GetStakePool looks up the metadata of the pool with the fallback, since it
+// isn't part of the block headers. Only the ID of the pool is returned, if no
+// fallback has been specified.
+func (b *Backend) GetStakePool(ctx context.Context,
+	hexID string) (*chain.StakePool, error) {
+
+	if b.fallback != nil {
+		return b.fallback.GetStakePool(ctx, hexID)
+	}
+	return &chain.StakePool{HexID: hexID}, nil
+}
+
+func (b *Backend) SubscribeTip() (<-chan chain.Tip, func()) {
+	return b.subs.subscribe()
+}
+
+// subscribe registers a new channel for tip updates. It returns the channel,
+// and a function to cancel the subscription.
+func (s *subscribers) subscribe() (<-chan chain.Tip, func()) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.idCounter++
+	id := s.idCounter
+	c := make(chan chain.Tip, 1)
+	s.channels[id] = c
+	return c, func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		if _, found := s.channels[id]; found {
+			delete(s.channels, id)
+			close(c)
+		}
+	}
+}
+
+// notify pushes the given tip to all subscribers. A tip that hasn't been
+// consumed yet by a subscriber is replaced with the given one.
+func (s *subscribers) notify(tip chain.Tip) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, c := range s.channels {
+		select {
+		case <-c:
+		default:
+		}
+		c <- tip
+	}
+}
@@ -0,0 +1,265 @@
+package ogmios
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// keyA and keyB are the cold verification keys of the pools, which
+	// issued the recorded blocks.
+	keyA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	keyB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	// awaitTimeout is the duration to wait for the replay to be followed.
+	awaitTimeout = 5 * time.Second
+)
+
+// exchange is a recorded response of Ogmios to a call of a method.
+type exchange struct {
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// newReplayServer starts a websocket server, which answers the calls of a
+// client with the exchanges recorded in the file at the given path. The calls
+// must be made in the recorded order. Once all exchanges have been replayed,
+// the server keeps the connection open without answering.
+func newReplayServer(t *testing.T, path string) *httptest.Server {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exchanges []exchange
+	err = json.Unmarshal(data, &exchanges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("couldn't upgrade the connection: %s", err.Error())
+			return
+		}
+		defer conn.Close()
+		for _, ex := range exchanges {
+			var req request
+			if conn.ReadJSON(&req) != nil {
+				return
+			}
+			if req.Method != ex.Method {
+				t.Errorf("expected a call of '%s', but '%s' has been called",
+					ex.Method, req.Method)
+				return
+			}
+			err = conn.WriteJSON(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  ex.Method,
+				"result":  ex.Result,
+				"error":   ex.Error,
+				"id":      req.ID,
+			})
+			if err != nil {
+				return
+			}
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// stubBackend is a chain.Backend, which records the slots looked up with it,
+// and never finds a minted block.
+type stubBackend struct {
+	lock  sync.Mutex
+	slots []uint
+}
+
+func (b *stubBackend) Name() string {
+	return "stub"
+}
+
+func (b *stubBackend) GetLatestBlock(context.Context) (*chain.Tip, error) {
+	return &chain.Tip{Source: b.Name()}, nil
+}
+
+func (b *stubBackend) GetMintedBlock(_ context.Context,
+	slot uint) (*chain.MintedBlock, error) {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.slots = append(b.slots, slot)
+	return nil, nil
+}
+
+func (b *stubBackend) TraverseAround(_ context.Context, slot uint,
+	_ uint) (*chain.MintedBlock, error) {
+
+	return b.GetMintedBlock(context.Background(), slot)
+}
+
+func (b *stubBackend) GetStakePool(_ context.Context,
+	hexID string) (*chain.StakePool, error) {
+
+	return &chain.StakePool{HexID: hexID, Ticker: "STUB"}, nil
+}
+
+func (b *stubBackend) lookedUp() []uint {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]uint(nil), b.slots...)
+}
+
+// followReplay follows the recorded chain with a new backend, and waits until
+// the tip in the given slot has been pushed.
+func followReplay(t *testing.T, fallback chain.Backend,
+	tipSlot uint) *Backend {
+
+	server := newReplayServer(t, "testdata/chainsync.json")
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	backend, err := NewOgmiosBackend(url, chain.Mainnet, 10, fallback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tips, unsubscribe := backend.SubscribeTip()
+	defer unsubscribe()
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		backend.Follow(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+	timeout := time.After(awaitTimeout)
+	for {
+		select {
+		case tip := <-tips:
+			if tip.Slot == tipSlot {
+				return backend
+			}
+		case <-timeout:
+			t.Fatalf("the tip in slot %d hasn't been pushed", tipSlot)
+		}
+	}
+}
+
+func TestFollow(t *testing.T) {
+	poolA, _ := poolIDOfVerificationKey(keyA)
+	poolB, _ := poolIDOfVerificationKey(keyB)
+	backend := followReplay(t, nil, 55900960)
+	ctx := context.Background()
+
+	tip, err := backend.GetLatestBlock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tip.Slot != 55900960 || tip.Epoch != 327 || tip.SlotInEpoch != 160 ||
+		tip.Height != 7000003 || tip.Timestamp != 1647467251 {
+		t.Errorf("expected the tip in slot 55900960, got %+v", tip)
+	}
+
+	block, err := backend.GetMintedBlock(ctx, 55900900)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block == nil || block.Pool.HexID != poolA || block.Height != 7000001 {
+		t.Errorf("expected the block of pool %s in slot 55900900, got %+v",
+			poolA, block)
+	}
+	// the block in slot 55900950 has been rolled back.
+	for _, slot := range []uint{55900905, 55900950} {
+		block, err = backend.GetMintedBlock(ctx, slot)
+		if err != nil || block != nil {
+			t.Errorf("expected no block in slot %d, got %+v (%v)", slot,
+				block, err)
+		}
+	}
+	block, err = backend.TraverseAround(ctx, 55900905, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block == nil || block.Slot != 55900910 || block.Pool.HexID != poolB {
+		t.Errorf("expected the block of pool %s in slot 55900910, got %+v",
+			poolB, block)
+	}
+}
+
+func TestOutOfWindow(t *testing.T) {
+	backend := followReplay(t, nil, 55900960)
+	ctx := context.Background()
+	// the window covers the slots after the intersection up to the latest
+	// received header.
+	for _, slot := range []uint{55900800, 55900961, 55901000} {
+		block, err := backend.GetMintedBlock(ctx, slot)
+		if err != OutOfWindowError {
+			t.Errorf("expected slot %d to be out of the window, got %+v (%v)",
+				slot, block, err)
+		}
+	}
+	block, err := backend.TraverseAround(ctx, 55900958, 5)
+	if err != OutOfWindowError {
+		t.Errorf("expected slots after the latest header to be out of the window, got %+v (%v)",
+			block, err)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	fallback := &stubBackend{}
+	backend := followReplay(t, fallback, 55900960)
+	ctx := context.Background()
+	for _, slot := range []uint{55900800, 55900961} {
+		_, err := backend.GetMintedBlock(ctx, slot)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := backend.TraverseAround(ctx, 55900958, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = backend.GetMintedBlock(ctx, 55900910)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slots := fallback.lookedUp()
+	want := []uint{55900800, 55900961, 55900958}
+	if len(slots) != len(want) {
+		t.Fatalf("expected the slots %v to be looked up with the fallback, got %v",
+			want, slots)
+	}
+	for i := range want {
+		if slots[i] != want[i] {
+			t.Fatalf("expected the slots %v to be looked up with the fallback, got %v",
+				want, slots)
+		}
+	}
+	block, err := backend.GetMintedBlock(ctx, 55900900)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Pool.Ticker != "STUB" {
+		t.Errorf("expected the pool metadata of the fallback, got %+v",
+			block.Pool)
+	}
+}
@@ -0,0 +1,116 @@
+package ogmios
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gorilla/websocket"
+)
+
+// intersectionNotFoundCode is the error code of Ogmios, if none of the given
+// points could be found on the chain.
+const intersectionNotFoundCode = 1000
+
+// point is a point on the chain identified by its slot and block hash.
+type point struct {
+	Slot uint   `json:"slot"`
+	ID   string `json:"id"`
+}
+
+// request is a JSON-RPC request sent to Ogmios.
+type request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+	ID      uint64      `json:"id"`
+}
+
+// response is a JSON-RPC response received from Ogmios.
+type response struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+	ID     uint64          `json:"id"`
+}
+
+// RPCError is returned, when Ogmios responded to a request with an error.
+type RPCError struct {
+	// Code is the error code of Ogmios.
+	Code int `json:"code"`
+	// Message describes the error.
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("ogmios responded with error %d: %s", e.Code, e.Message)
+}
+
+// block is a block as received with the chain-sync protocol of Ogmios.
+type block struct {
+	ID     string `json:"id"`
+	Height uint   `json:"height"`
+	Slot   uint   `json:"slot"`
+	Issuer *struct {
+		VerificationKey string `json:"verificationKey"`
+	} `json:"issuer"`
+}
+
+// nextBlockResult is the result of the 'nextBlock' method, which either rolls
+// the chain forward or backward.
+type nextBlockResult struct {
+	Direction string          `json:"direction"`
+	Block     *block          `json:"block"`
+	Point     json.RawMessage `json:"point"`
+	Tip       json.RawMessage `json:"tip"`
+}
+
+// intersectionResult is the result of the 'findIntersection' method.
+type intersectionResult struct {
+	Intersection json.RawMessage `json:"intersection"`
+}
+
+// client is a JSON-RPC client for Ogmios over a websocket connection. Requests
+// are sent sequentially, i.e. a response is awaited before the next request.
+type client struct {
+	conn      *websocket.Conn
+	idCounter uint64
+}
+
+// call calls the given method with the given params, and decodes the result
+// into the given value. An error will be returned, if the call failed or
+// Ogmios responded with an error.
+func (c *client) call(method string, params interface{}, v interface{}) error {
+	c.idCounter++
+	err := c.conn.WriteJSON(&request{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      c.idCounter,
+	})
+	if err != nil {
+		return err
+	}
+	var resp response
+	err = c.conn.ReadJSON(&resp)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	return json.Unmarshal(resp.Result, v)
+}
+
+// parsePoint parses the given point, which is either an object with slot and
+// hash, or the string "origin". Nil will be returned for the origin.
+func parsePoint(data json.RawMessage) (*point, error) {
+	var origin string
+	if json.Unmarshal(data, &origin) == nil && origin == "origin" {
+		return nil, nil
+	}
+	var p point
+	err := json.Unmarshal(data, &p)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
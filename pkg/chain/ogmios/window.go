@@ -0,0 +1,138 @@
+package ogmios
+
+import (
+	"sort"
+	"sync"
+)
+
+// header is the header of a block that has been received from Ogmios.
+type header struct {
+	slot   uint
+	height uint
+	hash   string
+	poolID string
+}
+
+// window keeps a rolling window of the latest block headers of the chain. The
+// window covers all the slots from a certain slot up to the latest received
+// header, i.e. a slot in this range without header hasn't been minted.
+type window struct {
+	lock        sync.RWMutex
+	size        int
+	headers     []header
+	coveredFrom uint
+	synced      bool
+}
+
+// newWindow creates a new empty window that keeps at most the given number of
+// headers.
+func newWindow(size int) *window {
+	return &window{
+		size:    size,
+		headers: make([]header, 0, size),
+	}
+}
+
+// reset drops all the headers of this window. The window then covers the
+// slots starting at the given slot.
+func (w *window) reset(from uint) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.headers = w.headers[:0]
+	w.coveredFrom = from
+	w.synced = true
+}
+
+// add appends the given header to this window. The oldest header is dropped,
+// if the window exceeds its size.
+func (w *window) add(h header) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.headers = append(w.headers, h)
+	if len(w.headers) > w.size {
+		w.coveredFrom = w.headers[0].slot + 1
+		w.headers = append(w.headers[:0], w.headers[1:]...)
+	}
+}
+
+// rollback drops all the headers after the given slot.
+func (w *window) rollback(slot uint) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	n := sort.Search(len(w.headers), func(i int) bool {
+		return w.headers[i].slot > slot
+	})
+	w.headers = w.headers[:n]
+}
+
+// latest returns the latest header of this window, or nil, if the window is
+// empty.
+func (w *window) latest() *header {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	if len(w.headers) == 0 {
+		return nil
+	}
+	h := w.headers[len(w.headers)-1]
+	return &h
+}
+
+// covers checks whether this window covers all the slots in the given range.
+// A slot after the latest received header isn't covered, since a block might
+// still be received for it.
+func (w *window) covers(from uint, to uint) bool {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	if !w.synced || from < w.coveredFrom || len(w.headers) == 0 {
+		return false
+	}
+	return to <= w.headers[len(w.headers)-1].slot
+}
+
+// find returns the header of the block minted in the given slot, or nil, if
+// no block has been minted in this slot.
+func (w *window) find(slot uint) *header {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	n := sort.Search(len(w.headers), func(i int) bool {
+		return w.headers[i].slot >= slot
+	})
+	if n < len(w.headers) && w.headers[n].slot == slot {
+		h := w.headers[n]
+		return &h
+	}
+	return nil
+}
+
+// nearest returns the header of the block that is minted closest to the given
+// slot, but not in the slot itself. Only the given interval of slots in both
+// directions is considered. The later slot is preferred, if two blocks have
+// the same distance. Nil will be returned, if no block could be found.
+func (w *window) nearest(slot uint, interval uint) *header {
+	for i := uint(1); i <= interval; i++ {
+		if h := w.find(slot + i); h != nil {
+			return h
+		}
+		if i <= slot {
+			if h := w.find(slot - i); h != nil {
+				return h
+			}
+		}
+	}
+	return nil
+}
+
+// points returns the points of the latest n headers in descending order, which
+// can be used to find an intersection with the chain.
+func (w *window) points(n int) []point {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	points := make([]point, 0, n)
+	for i := len(w.headers) - 1; i >= 0 && len(points) < n; i-- {
+		points = append(points, point{
+			Slot: w.headers[i].slot,
+			ID:   w.headers[i].hash,
+		})
+	}
+	return points
+}
@@ -0,0 +1,187 @@
+package poolcache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	DefaultConfig = &Configuration{
+		Size:  1000,
+		TTL:   24 * time.Hour,
+		Clock: clock.Real,
+	}
+)
+
+// Configuration configures the behaviour of the Cache.
+type Configuration struct {
+	// Size is the number of pools, whose metadata is kept in memory. The
+	// least recently used metadata is evicted, if the size is exceeded.
+	Size int
+	// TTL is the duration after which the metadata of a pool is fetched again.
+	TTL time.Duration
+	// Clock tells the time for the Cache. The time of the system is used, if
+	// it is nil.
+	Clock clock.Clock
+}
+
+// FetchFunc fetches the metadata of the pool with the given ID in hex format.
+type FetchFunc func(ctx context.Context, hexID string) (*chain.StakePool, error)
+
+// Store persists the metadata of pools beyond the lifetime of a Cache. It is
+// implemented by db.DB.
+type Store interface {
+
+	// GetStakePool gets the metadata of the stake pool with the given ID in
+	// hex format. Nil will be returned, if no metadata has been written for
+	// this pool.
+	GetStakePool(ctx context.Context, hexID string) (*db.StakePool, error)
+
+	// WriteStakePool writes the given metadata of a stake pool.
+	WriteStakePool(ctx context.Context, pool *db.StakePool) error
+}
+
+// entry is the metadata of a pool in the Cache.
+type entry struct {
+	hexID     string
+	pool      chain.StakePool
+	fetchedAt time.Time
+}
+
+// Cache is a cache for the metadata of pools, which can be shared by the
+// chain.Backend implementations. The most recently used metadata is kept in
+// memory, and all metadata is persisted in a Store. Metadata that is older
+// than the TTL is fetched again, but it is still used, if the fetching failed.
+type Cache struct {
+	lock    sync.Mutex
+	config  *Configuration
+	store   Store
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// NewCache creates a new Cache persisting the metadata in the given Store. The
+// metadata is only kept in memory, if the given Store is nil. The default
+// configuration is used, if the given Configuration is nil.
+func NewCache(store Store, config *Configuration) *Cache {
+	if config == nil {
+		config = DefaultConfig
+	}
+	if config.Clock == nil {
+		withClock := *config
+		withClock.Clock = clock.Real
+		config = &withClock
+	}
+	return &Cache{
+		config:  config,
+		store:   store,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Fetch returns the metadata of the pool with the given ID in hex format. The
+// given function is called to fetch the metadata, if it isn't cached or it is
+// older than the TTL.
+//
+// An error will be returned, if the metadata couldn't be fetched, and it
+// hasn't been cached before.
+func (c *Cache) Fetch(ctx context.Context, hexID string,
+	f FetchFunc) (*chain.StakePool, error) {
+
+	cached, found := c.get(hexID)
+	if !found && c.store != nil {
+		cached, found = c.load(ctx, hexID)
+	}
+	if found && c.config.Clock.Now().Sub(cached.fetchedAt) < c.config.TTL {
+		return &cached.pool, nil
+	}
+	pool, err := f(ctx, hexID)
+	if err != nil {
+		if found {
+			log.Warnf("couldn't refresh the metadata of pool=%s, using the cached one: %s",
+				hexID, err.Error())
+			return &cached.pool, nil
+		}
+		return nil, err
+	}
+	fetched := entry{hexID: hexID, pool: *pool,
+		fetchedAt: c.config.Clock.Now()}
+	c.put(fetched)
+	if c.store != nil {
+		err = c.store.WriteStakePool(ctx, &db.StakePool{
+			HexID:     hexID,
+			Ticker:    pool.Ticker,
+			Name:      pool.Name,
+			UpdatedAt: fetched.fetchedAt,
+		})
+		if err != nil {
+			log.Errorf("couldn't persist the metadata of pool=%s: %s", hexID,
+				err.Error())
+		}
+	}
+	return pool, nil
+}
+
+// get returns the metadata of the pool with the given ID kept in memory, and
+// marks it as most recently used. False is returned, if it isn't in memory.
+func (c *Cache) get(hexID string) (entry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	element, found := c.entries[hexID]
+	if !found {
+		return entry{}, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(entry), true
+}
+
+// put keeps the given metadata in memory as the most recently used one. The
+// least recently used metadata is evicted, if the size is exceeded.
+func (c *Cache) put(e entry) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if element, found := c.entries[e.hexID]; found {
+		element.Value = e
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[e.hexID] = c.order.PushFront(e)
+	for c.order.Len() > c.config.Size && c.order.Len() > 1 {
+		oldest := c.order.Remove(c.order.Back()).(entry)
+		delete(c.entries, oldest.hexID)
+	}
+}
+
+// load loads the metadata of the pool with the given ID from the store, and
+// keeps it in memory. False is returned, if it hasn't been persisted or the
+// loading failed.
+func (c *Cache) load(ctx context.Context, hexID string) (entry, bool) {
+	stored, err := c.store.GetStakePool(ctx, hexID)
+	if err != nil {
+		log.Errorf("couldn't load the metadata of pool=%s: %s", hexID,
+			err.Error())
+		return entry{}, false
+	}
+	if stored == nil {
+		return entry{}, false
+	}
+	e := entry{
+		hexID: hexID,
+		pool: chain.StakePool{
+			HexID:  stored.HexID,
+			Ticker: stored.Ticker,
+			Name:   stored.Name,
+		},
+		fetchedAt: stored.UpdatedAt,
+	}
+	c.put(e)
+	return e, true
+}
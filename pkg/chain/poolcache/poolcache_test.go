@@ -0,0 +1,173 @@
+package poolcache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	"github.com/blockblu-io/leaderlog-api/pkg/db/memdb"
+)
+
+const (
+	poolA = "cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214b"
+	poolB = "1d9302a3fb4b3b1935e02b27f0339798d3f08a55fbfdcd43a449a96f"
+	poolC = "0f292fcaa02b8b2f9b3c8f9fd8e0bb21abedb692a6d5058df3ef2735"
+	// ttl is the TTL of the tested Cache.
+	ttl = time.Hour
+)
+
+// startTime is the time to which the clock is set at the start of a test.
+var startTime = time.Date(2022, 3, 17, 12, 0, 0, 0, time.UTC)
+
+// fetcher fetches the metadata of pools, and counts the fetches of each pool.
+type fetcher struct {
+	calls map[string]int
+	// err is returned by the fetches, if it isn't nil.
+	err error
+	// ticker is the ticker of the fetched pools.
+	ticker string
+}
+
+func newFetcher() *fetcher {
+	return &fetcher{calls: make(map[string]int), ticker: "BLUE"}
+}
+
+func (f *fetcher) fetch(ctx context.Context, hexID string) (*chain.StakePool,
+	error) {
+
+	f.calls[hexID]++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &chain.StakePool{HexID: hexID, Ticker: f.ticker}, nil
+}
+
+// expectFetch fetches the metadata of the given pool with the given Cache,
+// and checks whether it has the given ticker.
+func expectFetch(t *testing.T, c *Cache, f *fetcher, hexID, ticker string) {
+	t.Helper()
+	pool, err := c.Fetch(context.Background(), hexID, f.fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool.HexID != hexID || pool.Ticker != ticker {
+		t.Fatalf("expected pool %s with ticker '%s', got %+v", hexID, ticker,
+			pool)
+	}
+}
+
+// expectCalls checks whether the given pools have been fetched as often as
+// expected.
+func expectCalls(t *testing.T, f *fetcher, expected map[string]int) {
+	t.Helper()
+	for hexID, calls := range expected {
+		if f.calls[hexID] != calls {
+			t.Fatalf("expected %d fetches of pool %s, got %d", calls, hexID,
+				f.calls[hexID])
+		}
+	}
+}
+
+func TestEviction(t *testing.T) {
+	c := NewCache(nil, &Configuration{Size: 2, TTL: ttl,
+		Clock: clock.NewFake(startTime)})
+	f := newFetcher()
+	expectFetch(t, c, f, poolA, "BLUE")
+	expectFetch(t, c, f, poolB, "BLUE")
+	// poolA becomes the most recently used pool, and poolB is evicted.
+	expectFetch(t, c, f, poolA, "BLUE")
+	expectFetch(t, c, f, poolC, "BLUE")
+	expectCalls(t, f, map[string]int{poolA: 1, poolB: 1, poolC: 1})
+	expectFetch(t, c, f, poolA, "BLUE")
+	expectFetch(t, c, f, poolB, "BLUE")
+	expectCalls(t, f, map[string]int{poolA: 1, poolB: 2, poolC: 1})
+}
+
+func TestRefresh(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	c := NewCache(nil, &Configuration{Size: 2, TTL: ttl, Clock: clk})
+	f := newFetcher()
+	expectFetch(t, c, f, poolA, "BLUE")
+	clk.Advance(ttl - time.Second)
+	f.ticker = "BLU"
+	expectFetch(t, c, f, poolA, "BLUE")
+	expectCalls(t, f, map[string]int{poolA: 1})
+	clk.Advance(time.Second)
+	expectFetch(t, c, f, poolA, "BLU")
+	expectCalls(t, f, map[string]int{poolA: 2})
+	// the outdated metadata is used, if it couldn't be refreshed.
+	clk.Advance(ttl)
+	f.err = errors.New("unavailable")
+	expectFetch(t, c, f, poolA, "BLU")
+	expectCalls(t, f, map[string]int{poolA: 3})
+	_, err := c.Fetch(context.Background(), poolB, f.fetch)
+	if err != f.err {
+		t.Fatalf("expected the error of the fetching, got %v", err)
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	idb := memdb.NewMemDB(clk)
+	config := &Configuration{Size: 1, TTL: ttl, Clock: clk}
+	f := newFetcher()
+	expectFetch(t, NewCache(idb, config), f, poolA, "BLUE")
+	stored, err := idb.GetStakePool(context.Background(), poolA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored == nil || stored.Ticker != "BLUE" ||
+		!stored.UpdatedAt.Equal(startTime) {
+		t.Fatalf("expected the metadata to be persisted, got %+v", stored)
+	}
+	// a new cache loads the metadata, and it refreshes it only after the TTL
+	// since the original fetch.
+	c := NewCache(idb, config)
+	clk.Advance(ttl / 2)
+	expectFetch(t, c, f, poolA, "BLUE")
+	expectCalls(t, f, map[string]int{poolA: 1})
+	// evicted metadata is loaded from the store again.
+	expectFetch(t, c, f, poolB, "BLUE")
+	expectFetch(t, c, f, poolA, "BLUE")
+	expectCalls(t, f, map[string]int{poolA: 1, poolB: 1})
+	clk.Advance(ttl / 2)
+	f.ticker = "BLU"
+	expectFetch(t, c, f, poolA, "BLU")
+	expectCalls(t, f, map[string]int{poolA: 2})
+	stored, err = idb.GetStakePool(context.Background(), poolA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored == nil || stored.Ticker != "BLU" {
+		t.Fatalf("expected the refreshed metadata to be persisted, got %+v",
+			stored)
+	}
+}
+
+// failingStore is a Store, whose reads and writes fail.
+type failingStore struct{}
+
+func (failingStore) GetStakePool(ctx context.Context,
+	hexID string) (*db.StakePool, error) {
+
+	return nil, db.ReadError
+}
+
+func (failingStore) WriteStakePool(ctx context.Context,
+	pool *db.StakePool) error {
+
+	return db.WriteError
+}
+
+func TestFailingStore(t *testing.T) {
+	c := NewCache(failingStore{}, &Configuration{Size: 1, TTL: ttl,
+		Clock: clock.NewFake(startTime)})
+	f := newFetcher()
+	expectFetch(t, c, f, poolA, "BLUE")
+	expectFetch(t, c, f, poolA, "BLUE")
+	expectCalls(t, f, map[string]int{poolA: 1})
+}
@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+)
+
+// tokenBucket is a token bucket limiting the rate of requests. The bucket is
+// refilled with rate tokens per second up to burst tokens, and each request
+// takes a token out of it. Moreover, the bucket can be paused, if the rate
+// limit of the API has been exceeded nevertheless.
+type tokenBucket struct {
+	lock        sync.Mutex
+	clock       clock.Clock
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// newTokenBucket creates a new full token bucket with the given rate in
+// tokens per second and the given burst. The rate isn't limited, if the given
+// rate isn't positive.
+func newTokenBucket(clk clock.Clock, rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		clock:  clk,
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clk.Now(),
+	}
+}
+
+// reserve takes a token out of this bucket, if one is available. Otherwise,
+// it returns the duration after which the next token is available.
+func (b *tokenBucket) reserve() (bool, time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	now := b.clock.Now()
+	if now.Before(b.pausedUntil) {
+		return false, b.pausedUntil.Sub(now)
+	}
+	if b.rate <= 0 {
+		return true, 0
+	}
+	if now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	if wait <= 0 {
+		wait = time.Millisecond
+	}
+	return false, wait
+}
+
+// wait blocks until a token could be taken out of this bucket. An error is
+// returned, if the given context has been canceled before.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		ok, d := b.reserve()
+		if ok {
+			return nil
+		}
+		timer := b.clock.NewTimer(d)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// pause pauses this bucket for the given duration, and empties it such that
+// the requests are only resumed at the given rate.
+func (b *tokenBucket) pause(d time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	until := b.clock.Now().Add(d)
+	if until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+	b.tokens = 0
+	b.last = b.pausedUntil
+}
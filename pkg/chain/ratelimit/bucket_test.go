@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+)
+
+// startTime is the time to which the clock is set at the start of a test.
+var startTime = time.Date(2022, 3, 17, 12, 0, 0, 0, time.UTC)
+
+// expectReserve reserves a token of the given bucket, and checks whether it
+// has been taken, or otherwise, whether the wait for the next token is the
+// expected one.
+func expectReserve(t *testing.T, b *tokenBucket, ok bool, wait time.Duration) {
+	t.Helper()
+	gotOk, gotWait := b.reserve()
+	if gotOk != ok || gotWait != wait {
+		t.Fatalf("expected the reservation (%t, %v), got (%t, %v)", ok, wait,
+			gotOk, gotWait)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	b := newTokenBucket(clk, 4, 3)
+	for i := 0; i < 3; i++ {
+		expectReserve(t, b, true, 0)
+	}
+	expectReserve(t, b, false, 250*time.Millisecond)
+	clk.Advance(125 * time.Millisecond)
+	expectReserve(t, b, false, 125*time.Millisecond)
+	clk.Advance(125 * time.Millisecond)
+	expectReserve(t, b, true, 0)
+	// the bucket is refilled only up to the burst.
+	clk.Advance(time.Minute)
+	for i := 0; i < 3; i++ {
+		expectReserve(t, b, true, 0)
+	}
+	expectReserve(t, b, false, 250*time.Millisecond)
+}
+
+func TestTokenBucketUnlimited(t *testing.T) {
+	b := newTokenBucket(clock.NewFake(startTime), 0, 1)
+	for i := 0; i < 100; i++ {
+		expectReserve(t, b, true, 0)
+	}
+}
+
+func TestTokenBucketPause(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	b := newTokenBucket(clk, 4, 3)
+	b.pause(2 * time.Second)
+	expectReserve(t, b, false, 2*time.Second)
+	// a shorter pause doesn't end the longer one.
+	b.pause(time.Second)
+	expectReserve(t, b, false, 2*time.Second)
+	clk.Advance(2 * time.Second)
+	// the bucket has been emptied, and is refilled only after the pause.
+	expectReserve(t, b, false, 250*time.Millisecond)
+	clk.Advance(250 * time.Millisecond)
+	expectReserve(t, b, true, 0)
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	b := newTokenBucket(clk, 4, 1)
+	err := b.wait(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- b.wait(context.Background())
+	}()
+	clk.BlockUntil(1)
+	select {
+	case <-done:
+		t.Fatal("expected the wait to block until the next token")
+	default:
+	}
+	clk.Advance(250 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		done <- b.wait(ctx)
+	}()
+	clk.BlockUntil(1)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected the wait to be canceled, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// MethodMetrics are the metrics of the requests for a single method of a
+// chain.Backend.
+type MethodMetrics struct {
+	// Calls is the number of calls of the method.
+	Calls uint64
+	// Failures is the number of calls, which failed in the end.
+	Failures uint64
+	// Retries is the number of retried requests.
+	Retries uint64
+	// RateLimited is the number of requests, which were rejected, because the
+	// rate limit of the API has been exceeded.
+	RateLimited uint64
+	// Waiting is the total duration the calls waited for the limiter.
+	Waiting time.Duration
+	// Latency is the total duration of the calls including the waiting and
+	// the retries.
+	Latency time.Duration
+}
+
+// metrics are the MethodMetrics of all methods of a backend.
+type metrics struct {
+	lock    sync.Mutex
+	methods map[string]*MethodMetrics
+}
+
+// update applies the given function to the metrics of the given method.
+func (m *metrics) update(method string, f func(mm *MethodMetrics)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.methods == nil {
+		m.methods = make(map[string]*MethodMetrics)
+	}
+	mm, found := m.methods[method]
+	if !found {
+		mm = &MethodMetrics{}
+		m.methods[method] = mm
+	}
+	f(mm)
+}
+
+// snapshot returns a copy of the metrics of all methods.
+func (m *metrics) snapshot() map[string]MethodMetrics {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	snapshot := make(map[string]MethodMetrics, len(m.methods))
+	for method, mm := range m.methods {
+		snapshot[method] = *mm
+	}
+	return snapshot
+}
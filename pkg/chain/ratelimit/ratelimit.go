@@ -0,0 +1,279 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// DefaultConfig stays within the limits of the free plan of Blockfrost
+	// by sending 10 requests per second with a burst of 100 requests. The plan
+	// allows a burst of 500 requests, of which some are left to the other
+	// users of the project.
+	DefaultConfig = &Configuration{
+		Rate:        10,
+		Burst:       100,
+		Concurrency: 8,
+		MaxRetries:  5,
+		MinBackoff:  time.Second,
+		MaxBackoff:  time.Minute,
+		Clock:       clock.Real,
+	}
+)
+
+// Configuration configures the limits of the Backend.
+type Configuration struct {
+	// Rate is the number of requests per second that are sent on average. The
+	// rate isn't limited, if it isn't positive.
+	Rate float64
+	// Burst is the number of requests that can be sent at once, if no
+	// requests have been sent for a while.
+	Burst int
+	// Concurrency is the number of requests that can be pending at the same
+	// time. It isn't bounded, if it isn't positive.
+	Concurrency int
+	// MaxRetries is the number of times a temporarily failed request is
+	// retried, before the failure is returned.
+	MaxRetries uint
+	// MinBackoff is the delay before the first retry. The delay is doubled
+	// for each further retry, and a random jitter is applied to it.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximal delay before a retry.
+	MaxBackoff time.Duration
+	// Clock tells the time for the limiter. The time of the system is used,
+	// if it is nil.
+	Clock clock.Clock
+}
+
+// Backend is a chain.Backend decorating another backend, whose requests are
+// limited by a token bucket as well as a bound on the concurrent requests.
+// Requests that failed temporarily (see chain.IsTemporary) are retried with a
+// jittered exponential backoff, and all requests are paused for the backoff,
+// if the rate limit of the API has been exceeded nevertheless.
+type Backend struct {
+	backend chain.Backend
+	config  *Configuration
+	bucket  *tokenBucket
+	slots   chan struct{}
+	metrics metrics
+}
+
+// followerBackend is a Backend decorating a chain.TipFollower. Following the
+// chain isn't limited.
+type followerBackend struct {
+	*Backend
+	follower chain.TipFollower
+}
+
+// NewBackend creates a new Backend limiting the requests to the given
+// chain.Backend. The returned backend is a chain.TipFollower, if the given
+// backend is one. The default configuration is used, if the given
+// Configuration is nil.
+func NewBackend(backend chain.Backend, config *Configuration) chain.Backend {
+	if config == nil {
+		config = DefaultConfig
+	}
+	if config.Clock == nil {
+		withClock := *config
+		withClock.Clock = clock.Real
+		config = &withClock
+	}
+	b := &Backend{
+		backend: backend,
+		config:  config,
+		bucket:  newTokenBucket(config.Clock, config.Rate, config.Burst),
+	}
+	if config.Concurrency > 0 {
+		b.slots = make(chan struct{}, config.Concurrency)
+	}
+	if follower, ok := backend.(chain.TipFollower); ok {
+		return &followerBackend{Backend: b, follower: follower}
+	}
+	return b
+}
+
+func (b *Backend) Name() string {
+	return b.backend.Name()
+}
+
+// Metrics returns the metrics of the requests for each method of the
+// chain.Backend, which has been called at least once.
+func (b *Backend) Metrics() map[string]MethodMetrics {
+	return b.metrics.snapshot()
+}
+
+func (b *Backend) GetLatestBlock(ctx context.Context) (*chain.Tip, error) {
+	var tip *chain.Tip
+	err := b.call(ctx, "GetLatestBlock", func(ctx context.Context) error {
+		var err error
+		tip, err = b.backend.GetLatestBlock(ctx)
+		return err
+	})
+	return tip, err
+}
+
+func (b *Backend) GetMintedBlock(ctx context.Context,
+	slot uint) (*chain.MintedBlock, error) {
+
+	var mBlock *chain.MintedBlock
+	err := b.call(ctx, "GetMintedBlock", func(ctx context.Context) error {
+		var err error
+		mBlock, err = b.backend.GetMintedBlock(ctx, slot)
+		return err
+	})
+	return mBlock, err
+}
+
+// TraverseAround limits each single query of the decorated backend, if it is
+// a chain.SlotTraverser. Otherwise, the traversal is limited as a whole.
+func (b *Backend) TraverseAround(ctx context.Context, slot uint,
+	interval uint) (*chain.MintedBlock, error) {
+
+	if _, ok := b.backend.(chain.SlotTraverser); ok {
+		return chain.TraverseSlots(ctx, b, slot, interval)
+	}
+	var mBlock *chain.MintedBlock
+	err := b.call(ctx, "TraverseAround", func(ctx context.Context) error {
+		var err error
+		mBlock, err = b.backend.TraverseAround(ctx, slot, interval)
+		return err
+	})
+	return mBlock, err
+}
+
+func (b *Backend) GetStakePool(ctx context.Context,
+	hexID string) (*chain.StakePool, error) {
+
+	var pool *chain.StakePool
+	err := b.call(ctx, "GetStakePool", func(ctx context.Context) error {
+		var err error
+		pool, err = b.backend.GetStakePool(ctx, hexID)
+		return err
+	})
+	return pool, err
+}
+
+// call calls the given function for the given method of the decorated
+// backend within the limits, and retries it, if it failed temporarily.
+func (b *Backend) call(ctx context.Context, method string,
+	f func(ctx context.Context) error) error {
+
+	start := b.config.Clock.Now()
+	var waiting time.Duration
+	var err error
+	retries := uint(0)
+	for {
+		var waited time.Duration
+		waited, err = b.acquire(ctx)
+		waiting += waited
+		if err != nil {
+			break
+		}
+		err = f(ctx)
+		b.release()
+		if err == nil || ctx.Err() != nil || !chain.IsTemporary(err) ||
+			retries >= b.config.MaxRetries {
+			break
+		}
+		retries++
+		delay := b.backoff(retries)
+		var temporaryErr *chain.TemporaryError
+		if errors.As(err, &temporaryErr) {
+			if temporaryErr.RetryAfter > delay {
+				delay = temporaryErr.RetryAfter
+			}
+			if temporaryErr.RateLimited() {
+				b.metrics.update(method, func(mm *MethodMetrics) {
+					mm.RateLimited++
+				})
+				b.bucket.pause(delay)
+			}
+		}
+		log.Warnf("request %s to %s failed temporarily, retrying in %v: %s",
+			method, b.backend.Name(), delay, err.Error())
+		err = b.sleep(ctx, delay)
+		if err != nil {
+			break
+		}
+	}
+	latency := b.config.Clock.Now().Sub(start)
+	b.metrics.update(method, func(mm *MethodMetrics) {
+		mm.Calls++
+		mm.Retries += uint64(retries)
+		mm.Waiting += waiting
+		mm.Latency += latency
+		if err != nil {
+			mm.Failures++
+		}
+	})
+	return err
+}
+
+// acquire blocks until a request can be sent within the limits. It returns
+// the duration of the waiting, or an error, if the given context has been
+// canceled. The acquired request must be released afterwards.
+func (b *Backend) acquire(ctx context.Context) (time.Duration, error) {
+	start := b.config.Clock.Now()
+	if b.slots != nil {
+		select {
+		case b.slots <- struct{}{}:
+		case <-ctx.Done():
+			return b.config.Clock.Now().Sub(start), ctx.Err()
+		}
+	}
+	err := b.bucket.wait(ctx)
+	if err != nil {
+		b.release()
+	}
+	return b.config.Clock.Now().Sub(start), err
+}
+
+// release releases a request, which has been acquired before.
+func (b *Backend) release() {
+	if b.slots != nil {
+		<-b.slots
+	}
+}
+
+// backoff computes the delay before the given retry. The delay is chosen
+// randomly between the half and the full exponential backoff.
+func (b *Backend) backoff(retry uint) time.Duration {
+	delay := b.config.MinBackoff
+	for i := uint(1); i < retry && delay < b.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > b.config.MaxBackoff {
+		delay = b.config.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// sleep blocks for the given duration, or until the given context has been
+// canceled, in which case an error is returned.
+func (b *Backend) sleep(ctx context.Context, d time.Duration) error {
+	timer := b.config.Clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *followerBackend) Follow(ctx context.Context) {
+	b.follower.Follow(ctx)
+}
+
+func (b *followerBackend) SubscribeTip() (<-chan chain.Tip, func()) {
+	return b.follower.SubscribeTip()
+}
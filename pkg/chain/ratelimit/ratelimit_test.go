@@ -0,0 +1,252 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+)
+
+// awaitTimeout is the duration to wait for the backend to react.
+const awaitTimeout = 5 * time.Second
+
+// stubBackend is a chain.Backend, whose queries for minted blocks are answered
+// by a function.
+type stubBackend struct {
+	getMintedBlock func(ctx context.Context, slot uint) (*chain.MintedBlock,
+		error)
+}
+
+func (b *stubBackend) Name() string {
+	return "stub"
+}
+
+func (b *stubBackend) GetLatestBlock(ctx context.Context) (*chain.Tip, error) {
+	return &chain.Tip{}, nil
+}
+
+func (b *stubBackend) GetMintedBlock(ctx context.Context,
+	slot uint) (*chain.MintedBlock, error) {
+
+	return b.getMintedBlock(ctx, slot)
+}
+
+func (b *stubBackend) TraverseAround(ctx context.Context, slot uint,
+	interval uint) (*chain.MintedBlock, error) {
+
+	return chain.TraverseSlots(ctx, b, slot, interval)
+}
+
+func (b *stubBackend) GetStakePool(ctx context.Context,
+	hexID string) (*chain.StakePool, error) {
+
+	return &chain.StakePool{HexID: hexID}, nil
+}
+
+// failingBackend returns a backend, which fails with the given errors, before
+// it answers the queries for minted blocks with the given block.
+func failingBackend(block *chain.MintedBlock, errs ...error) *stubBackend {
+	var lock sync.Mutex
+	return &stubBackend{
+		getMintedBlock: func(ctx context.Context,
+			slot uint) (*chain.MintedBlock, error) {
+
+			lock.Lock()
+			defer lock.Unlock()
+			if len(errs) > 0 {
+				err := errs[0]
+				errs = errs[1:]
+				return nil, err
+			}
+			return block, nil
+		},
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	b := &Backend{config: &Configuration{
+		MinBackoff: time.Second,
+		MaxBackoff: 10 * time.Second,
+	}}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second,
+		8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, full := range expected {
+		retry := uint(i + 1)
+		jittered := false
+		for j := 0; j < 100; j++ {
+			delay := b.backoff(retry)
+			if delay < full/2 || delay > full {
+				t.Fatalf("expected the delay of retry %d to be in [%v, %v], "+
+					"got %v", retry, full/2, full, delay)
+			}
+			jittered = jittered || delay != b.backoff(retry)
+		}
+		if !jittered {
+			t.Errorf("expected the delay of retry %d to be jittered", retry)
+		}
+	}
+	b.config.MinBackoff = 0
+	if delay := b.backoff(3); delay != 0 {
+		t.Errorf("expected no delay without a minimal backoff, got %v", delay)
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	b := NewBackend(&stubBackend{
+		getMintedBlock: func(ctx context.Context,
+			slot uint) (*chain.MintedBlock, error) {
+
+			entered <- struct{}{}
+			<-release
+			return nil, nil
+		},
+	}, &Configuration{Concurrency: 2, Clock: clock.NewFake(startTime)})
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := b.GetMintedBlock(context.Background(), 100)
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-entered:
+		case <-time.After(awaitTimeout):
+			t.Fatal("expected two requests to be sent")
+		}
+	}
+	select {
+	case <-entered:
+		t.Fatal("expected the third request to wait for a pending one")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release <- struct{}{}
+	select {
+	case <-entered:
+	case <-time.After(awaitTimeout):
+		t.Fatal("expected the third request to be sent after the release")
+	}
+	close(release)
+	wg.Wait()
+}
+
+// getMintedBlock queries the given backend for a minted block in a separate
+// goroutine, and returns a channel, which receives the error of the query.
+func getMintedBlock(b chain.Backend) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.GetMintedBlock(context.Background(), 100)
+		done <- err
+	}()
+	return done
+}
+
+func TestRetries(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	temporaryErr := &chain.TemporaryError{
+		StatusCode: http.StatusServiceUnavailable,
+		Err:        errors.New("unavailable"),
+	}
+	b := NewBackend(failingBackend(nil, temporaryErr, temporaryErr),
+		&Configuration{
+			MaxRetries: 3,
+			MinBackoff: time.Second,
+			MaxBackoff: time.Second,
+			Clock:      clk,
+		})
+	done := getMintedBlock(b)
+	for i := 0; i < 2; i++ {
+		clk.BlockUntil(1)
+		clk.Advance(time.Second)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	m := b.(*Backend).Metrics()["GetMintedBlock"]
+	if m.Calls != 1 || m.Retries != 2 || m.Failures != 0 ||
+		m.RateLimited != 0 {
+		t.Errorf("unexpected metrics %+v", m)
+	}
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	temporaryErr := &chain.TemporaryError{
+		StatusCode: http.StatusServiceUnavailable,
+		Err:        errors.New("unavailable"),
+	}
+	b := NewBackend(failingBackend(nil, temporaryErr, temporaryErr),
+		&Configuration{
+			MaxRetries: 1,
+			MinBackoff: time.Second,
+			MaxBackoff: time.Second,
+			Clock:      clk,
+		})
+	done := getMintedBlock(b)
+	clk.BlockUntil(1)
+	clk.Advance(time.Second)
+	if err := <-done; err != temporaryErr {
+		t.Fatalf("expected the temporary error, got %v", err)
+	}
+	// errors, which aren't temporary, aren't retried.
+	permanentErr := errors.New("bad request")
+	b = NewBackend(failingBackend(nil, permanentErr), &Configuration{
+		MaxRetries: 3,
+		MinBackoff: time.Second,
+		Clock:      clk,
+	})
+	if err := <-getMintedBlock(b); err != permanentErr {
+		t.Fatalf("expected the permanent error, got %v", err)
+	}
+	m := b.(*Backend).Metrics()["GetMintedBlock"]
+	if m.Calls != 1 || m.Retries != 0 || m.Failures != 1 {
+		t.Errorf("unexpected metrics %+v", m)
+	}
+}
+
+func TestRateLimitedPause(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	rateLimitedErr := &chain.TemporaryError{
+		StatusCode: http.StatusTooManyRequests,
+		RetryAfter: 5 * time.Second,
+		Err:        errors.New("too many requests"),
+	}
+	b := NewBackend(failingBackend(nil, rateLimitedErr), &Configuration{
+		MaxRetries: 3,
+		MinBackoff: time.Second,
+		MaxBackoff: time.Second,
+		Clock:      clk,
+	})
+	done := getMintedBlock(b)
+	clk.BlockUntil(1)
+	// the retry waits for the requested duration instead of the backoff, and
+	// all the other requests are paused meanwhile.
+	deadline, _ := clk.NextDeadline()
+	if wait := deadline.Sub(clk.Now()); wait != 5*time.Second {
+		t.Fatalf("expected the retry after 5s, got %v", wait)
+	}
+	ok, wait := b.(*Backend).bucket.reserve()
+	if ok || wait != 5*time.Second {
+		t.Fatalf("expected the requests to be paused for 5s, got (%t, %v)", ok,
+			wait)
+	}
+	clk.Advance(5 * time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	m := b.(*Backend).Metrics()["GetMintedBlock"]
+	if m.Calls != 1 || m.Retries != 1 || m.RateLimited != 1 {
+		t.Errorf("unexpected metrics %+v", m)
+	}
+}
@@ -0,0 +1,87 @@
+package syncer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+)
+
+// pendingBlocks keeps track of the assigned blocks that are currently being
+// processed such that their processing can be dropped.
+type pendingBlocks struct {
+	lock    sync.Mutex
+	entries map[uint]map[uint]*pendingEntry
+}
+
+// pendingEntry is the processing of a single pending block. The pointer of an
+// entry identifies a processing, such that a dropped processing doesn't remove
+// a later processing of the same block.
+type pendingEntry struct {
+	cancel context.CancelFunc
+}
+
+// newPendingBlocks creates a new empty register of pending blocks.
+func newPendingBlocks() *pendingBlocks {
+	return &pendingBlocks{
+		entries: make(map[uint]map[uint]*pendingEntry),
+	}
+}
+
+// track registers the given block as pending. It returns a context that is
+// canceled, when the processing of the block shall be dropped, and a function
+// that must be called once the processing finished. False is returned instead,
+// if the given block is already pending.
+func (p *pendingBlocks) track(ctx context.Context,
+	block db.AssignedBlock) (context.Context, context.CancelFunc, bool) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	epochEntries, found := p.entries[block.Epoch]
+	if !found {
+		epochEntries = make(map[uint]*pendingEntry)
+		p.entries[block.Epoch] = epochEntries
+	}
+	if _, found := epochEntries[block.No]; found {
+		return nil, nil, false
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	entry := &pendingEntry{cancel: cancel}
+	epochEntries[block.No] = entry
+	return ctx, func() {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		cancel()
+		// the entry has been removed, if the epoch has been dropped, and the
+		// block might be pending again in the meantime.
+		epochEntries, found := p.entries[block.Epoch]
+		if !found || epochEntries[block.No] != entry {
+			return
+		}
+		delete(epochEntries, block.No)
+		if len(epochEntries) == 0 {
+			delete(p.entries, block.Epoch)
+		}
+	}, true
+}
+
+// count returns the number of pending blocks.
+func (p *pendingBlocks) count() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	n := 0
+	for _, epochEntries := range p.entries {
+		n += len(epochEntries)
+	}
+	return n
+}
+
+// dropEpoch cancels the processing of all pending blocks of the given epoch.
+func (p *pendingBlocks) dropEpoch(epoch uint) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, entry := range p.entries[epoch] {
+		entry.cancel()
+	}
+	delete(p.entries, epoch)
+}
@@ -0,0 +1,53 @@
+package syncer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+)
+
+func TestPendingBlocks(t *testing.T) {
+	p := newPendingBlocks()
+	block := db.AssignedBlock{Epoch: 327, No: 1}
+	ctx, done, ok := p.track(context.Background(), block)
+	if !ok {
+		t.Fatal("expected the block to be tracked")
+	}
+	if _, _, ok := p.track(context.Background(), block); ok {
+		t.Fatal("expected the pending block not to be tracked twice")
+	}
+	done()
+	if ctx.Err() == nil || p.count() != 0 {
+		t.Fatalf("expected the processing to be finished, got %d pending",
+			p.count())
+	}
+}
+
+func TestPendingBlocksDroppedEpoch(t *testing.T) {
+	p := newPendingBlocks()
+	block := db.AssignedBlock{Epoch: 327, No: 1}
+	oldCtx, oldDone, _ := p.track(context.Background(), block)
+	p.dropEpoch(327)
+	if oldCtx.Err() == nil || p.count() != 0 {
+		t.Fatal("expected the processing of the epoch to be dropped")
+	}
+	// the leader log has been uploaded again, and the block is processed
+	// anew, before the dropped processing finished.
+	newCtx, newDone, ok := p.track(context.Background(), block)
+	if !ok {
+		t.Fatal("expected the block to be tracked again")
+	}
+	oldDone()
+	if newCtx.Err() != nil || p.count() != 1 {
+		t.Fatalf("expected the new processing to stay pending, got %d pending",
+			p.count())
+	}
+	if _, _, ok := p.track(context.Background(), block); ok {
+		t.Fatal("expected the block not to be tracked twice")
+	}
+	newDone()
+	if p.count() != 0 {
+		t.Fatalf("expected no pending block, got %d", p.count())
+	}
+}
@@ -0,0 +1,90 @@
+package syncer
+
+import (
+	"context"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// retryInterval is the interval in which the retry queue is checked for
+// blocks that are due to be retried.
+const retryInterval = 30 * time.Second
+
+// runRetries processes the queued blocks, whose next attempt is due. This
+// method is running infinitely unless the given context has been cancelled.
+func (s *Syncer) runRetries(ctx context.Context) {
+	ticker := s.config.Clock.NewTicker(retryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C():
+			entries, err := s.db.GetRetryEntries(ctx, s.poolID)
+			if err != nil {
+				log.Errorf("couldn't scan the retry queue: %s", err.Error())
+				break
+			}
+			now := s.config.Clock.Now()
+			for _, entry := range entries {
+				if entry.NextAttempt.IsZero() || entry.NextAttempt.After(now) {
+					continue
+				}
+				log.Infof("retrying block (%d,%d) after %d failed attempts",
+					entry.Block.Epoch, entry.Block.No, entry.Attempts)
+				go s.processBlock(ctx, entry.Block)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// scheduleRetry queues a retry for the given block, whose status couldn't be
+// gathered because of the given cause. The delay of the retry increases
+// exponentially with the number of failed attempts, and no further retry is
+// scheduled, if the maximal number of attempts has been reached.
+func (s *Syncer) scheduleRetry(ctx context.Context, block db.AssignedBlock,
+	cause error) {
+
+	entry, err := s.db.GetRetryEntry(ctx, s.poolID, block.Epoch, block.No)
+	if err != nil {
+		return
+	}
+	attempts := uint(1)
+	if entry != nil {
+		attempts = entry.Attempts + 1
+	}
+	var nextAttempt time.Time
+	if attempts < s.config.RetryMaxAttempts {
+		nextAttempt = s.config.Clock.Now().Add(s.retryDelay(attempts))
+		log.Warnf("couldn't gather the status of block (%d,%d), retrying at %v: %s",
+			block.Epoch, block.No, nextAttempt, cause.Error())
+	} else {
+		log.Errorf("gave up gathering the status of block (%d,%d) after %d attempts: %s",
+			block.Epoch, block.No, attempts, cause.Error())
+	}
+	err = s.db.WriteRetryEntry(ctx, s.poolID, &db.RetryEntry{
+		Block:       block,
+		Attempts:    attempts,
+		NextAttempt: nextAttempt,
+		LastError:   cause.Error(),
+	})
+	if err != nil {
+		log.Errorf("couldn't queue the retry of block (%d,%d): %s",
+			block.Epoch, block.No, err.Error())
+	}
+}
+
+// retryDelay computes the delay before the next retry given the number of
+// failed attempts.
+func (s *Syncer) retryDelay(attempts uint) time.Duration {
+	delay := s.config.RetryBaseDelay
+	for i := uint(1); i < attempts && delay < s.config.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.config.RetryMaxDelay {
+		delay = s.config.RetryMaxDelay
+	}
+	return delay
+}
@@ -34,10 +34,10 @@ func (s *Syncer) NewScanner() *Scanner {
 // This method is running infinitely unless the given context has been
 // cancelled.
 func (sc *Scanner) Run(ctx context.Context) {
-	observe := func() (chan struct{}, context.CancelFunc) {
-		observeChan := make(chan struct{})
+	observe := func() (chan db.ObserverCode, context.CancelFunc) {
+		observeChan := make(chan db.ObserverCode)
 		ctx, cancel := context.WithCancel(ctx)
-		go sc.observeNewLeaderLog(ctx, observeChan)
+		go sc.observeLeaderLogs(ctx, observeChan)
 		return observeChan, cancel
 	}
 	lookupNext := func() (chan db.AssignedBlock, context.CancelFunc) {
@@ -52,8 +52,10 @@ func (sc *Scanner) Run(ctx context.Context) {
 		obvChan, obvCancel := observe()
 		nextBlockChan, nextCancel := lookupNext()
 		select {
-		case <-obvChan:
-			sc.scanPastBlocks(ctx)
+		case code, ok := <-obvChan:
+			if ok && code != db.ObserveDeletedLeaderLog {
+				sc.scanPastBlocks(ctx)
+			}
 			break
 		case block := <-nextBlockChan:
 			go func() {
@@ -68,16 +70,27 @@ func (sc *Scanner) Run(ctx context.Context) {
 	}
 }
 
-// observeNewLeaderLog sends a signal to the given channel, when a new leader
-// log has been ingested.
-func (sc *Scanner) observeNewLeaderLog(ctx context.Context,
-	signal chan struct{}) {
+// observeLeaderLogs sends the observer code to the given channel, when a new
+// leader log of the pool of this scanner has been ingested, or an existing one
+// has been replaced or deleted. The processing of pending blocks of a deleted
+// leader log is dropped.
+func (sc *Scanner) observeLeaderLogs(ctx context.Context,
+	signal chan db.ObserverCode) {
 
 	for {
 		select {
 		case msg := <-sc.listener:
-			if msg.Code == db.ObserveNewLeaderLog {
-				signal <- struct{}{}
+			if msg.PoolID() != sc.syncer.poolID {
+				break
+			}
+			switch msg.Code {
+			case db.ObserveDeletedLeaderLog:
+				if ref, ok := msg.Response.(db.LeaderLogRef); ok {
+					sc.syncer.pending.dropEpoch(ref.Epoch)
+				}
+				fallthrough
+			case db.ObserveNewLeaderLog, db.ObserveReplacedLeaderLog:
+				signal <- msg.Code
 				close(signal)
 				return
 			}
@@ -96,7 +109,7 @@ func (sc *Scanner) observeNewLeaderLog(ctx context.Context,
 func (sc *Scanner) lookForNextBlock(ctx context.Context,
 	nChan chan db.AssignedBlock) {
 
-	blocks, err := sc.syncer.db.GetAssignedBlocksAfterNow(ctx)
+	blocks, err := sc.syncer.db.GetAssignedBlocksAfterNow(ctx, sc.syncer.poolID)
 	if err != nil {
 		log.Errorf("couldn't scan the unsynced blcoks: %s",
 			err.Error())
@@ -106,15 +119,17 @@ func (sc *Scanner) lookForNextBlock(ctx context.Context,
 		log.Warnf("couldn't find any next block in the database")
 		return
 	}
-	wait := blocks[0].Timestamp.Sub(time.Now())
+	clk := sc.syncer.config.Clock
+	wait := blocks[0].Timestamp.Sub(clk.Now())
 	if wait < 0 {
 		wait = 0
 	}
 	wait = wait + 10*time.Second
 	log.Infof("waiting %s for the next block", wait)
-	timer := time.NewTimer(wait)
+	timer := clk.NewTimer(wait)
+	defer timer.Stop()
 	select {
-	case <-timer.C:
+	case <-timer.C():
 		nChan <- blocks[0]
 		close(nChan)
 	case <-ctx.Done():
@@ -130,7 +145,7 @@ func (sc *Scanner) scanPastBlocks(ctx context.Context) {
 	loaded := false
 	for n := uint(0); !loaded; n += bufferSize {
 		blocks, err := sc.syncer.db.GetAssignedBlocksWithStatusBeforeNow(ctx,
-			db.NotMinted, n, bufferSize)
+			sc.syncer.poolID, db.NotMinted, n, bufferSize)
 		if err != nil {
 			log.Errorf("couldn't scan the unsynced blcoks: %s",
 				err.Error())
@@ -3,43 +3,131 @@ package syncer
 import (
 	"context"
 	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
 	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	"github.com/blockblu-io/leaderlog-api/pkg/metrics"
 	log "github.com/sirupsen/logrus"
 	"time"
 )
 
+var (
+	DefaultSyncerConfig = &SyncerConfiguration{
+		ConfirmationDelay:    3 * time.Minute,
+		FinalityWindow:       2160,
+		VerificationInterval: 10 * time.Minute,
+		RetryBaseDelay:       time.Minute,
+		RetryMaxDelay:        time.Hour,
+		RetryMaxAttempts:     10,
+		Clock:                clock.Real,
+	}
+)
+
+// latencyBounds are the upper bounds in seconds of the buckets for the
+// latency from the slot of an assigned block to its classification.
+var latencyBounds = []float64{60, 120, 180, 240, 300, 600, 900, 1800, 3600,
+	7200, 21600, 86400}
+
+// SyncerConfiguration configures the behaviour of the Syncer.
+type SyncerConfiguration struct {
+	// ConfirmationDelay specifies how long the tip of the chain must be past
+	// the slot of an assigned block, before the status of the block is
+	// gathered.
+	ConfirmationDelay time.Duration
+	// FinalityWindow is the number of blocks after which a block is considered
+	// to be final. Classified blocks within this window are re-verified, since
+	// they can still be rolled back. It defaults to the security parameter k.
+	FinalityWindow uint
+	// VerificationInterval specifies how often the classified blocks within
+	// the finality window are re-verified.
+	VerificationInterval time.Duration
+	// RetryBaseDelay is the delay before the first retry of a block, whose
+	// status couldn't be gathered. The delay is doubled for each further
+	// failed attempt.
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay is the maximal delay between two retries.
+	RetryMaxDelay time.Duration
+	// RetryMaxAttempts is the number of failed attempts after which no
+	// further retry is made for a block.
+	RetryMaxAttempts uint
+	// Clock tells the time for the syncer. The time of the system is used, if
+	// it is nil.
+	Clock clock.Clock
+}
+
 // Syncer is a service, which scans for blocks that are planned for the past and
 // their status is still db.NotMinted. Moreover, it queries to chain to check
 // for the correct status and store it in the db.DB.
 type Syncer struct {
 	poolID        string
+	config        *SyncerConfiguration
 	backend       chain.Backend
 	db            db.DB
 	tipUpdater    *TipUpdater
 	pastBlockChan chan db.AssignedBlock
+	pending       *pendingBlocks
+	latency       *metrics.Histogram
+}
+
+// SyncerMetrics are the metrics of a Syncer.
+type SyncerMetrics struct {
+	// PoolID is the ID in hex format of the pool, whose blocks are synced.
+	PoolID string
+	// Processing is the number of assigned blocks, which are currently being
+	// processed.
+	Processing int
+	// Latency is the histogram of the durations in seconds from the slot of
+	// an assigned block to the classification of it.
+	Latency metrics.HistogramSnapshot
 }
 
 // NewSyncer is creating a new Syncer for the pool with the given ID in hex
-// format. The given backend is used for querying the chain, and the given db.DB
-// is used to query as well as update the blocks and their status.
-func NewSyncer(poolID string, backend chain.Backend, idb db.DB) *Syncer {
-	tu := NewTipUpdater(DefaultTipUpdaterConfig)
+// format. The given backend is used for querying the chain, and the given
+// TipUpdater must be run for this backend. Both can be shared by the syncers
+// of multiple pools. The given db.DB is used to query as well as update the
+// blocks and their status. The default configuration is used, if the given
+// SyncerConfiguration is nil.
+func NewSyncer(poolID string, backend chain.Backend, tu *TipUpdater, idb db.DB,
+	config *SyncerConfiguration) *Syncer {
+
+	if config == nil {
+		config = DefaultSyncerConfig
+	}
+	if config.Clock == nil {
+		withClock := *config
+		withClock.Clock = clock.Real
+		config = &withClock
+	}
 	blockChannel := make(chan db.AssignedBlock)
 	return &Syncer{
 		poolID:        poolID,
+		config:        config,
 		backend:       backend,
 		db:            idb,
 		tipUpdater:    tu,
 		pastBlockChan: blockChannel,
+		pending:       newPendingBlocks(),
+		latency:       metrics.NewHistogram(latencyBounds...),
+	}
+}
+
+// Metrics returns the current metrics of this syncer.
+func (s *Syncer) Metrics() SyncerMetrics {
+	return SyncerMetrics{
+		PoolID:     s.poolID,
+		Processing: s.pending.count(),
+		Latency:    s.latency.Snapshot(),
 	}
 }
 
-// Run starts this sync service. This method is blocking.
+// Run starts this sync service. The TipUpdater of this syncer must be run
+// separately. This method is blocking.
 func (s *Syncer) Run(ctx context.Context) {
-	log.Infof("started to sync blocks using '%s'", s.backend.Name())
+	log.Infof("started to sync blocks of pool-id=%s using '%s'", s.poolID,
+		s.backend.Name())
 	scanner := s.NewScanner()
-	go s.tipUpdater.Run(ctx, s.backend)
 	go scanner.Run(ctx)
+	go s.runVerifier(ctx)
+	go s.runRetries(ctx)
 	for {
 		select {
 		case b := <-s.pastBlockChan:
@@ -52,8 +140,16 @@ func (s *Syncer) Run(ctx context.Context) {
 }
 
 // processBlock gathers the status of the assigned block and updates the status
-// in the database.
+// in the database. The processing is skipped, if the block is already being
+// processed, and it is aborted, if the leader log of the block gets deleted.
 func (s *Syncer) processBlock(ctx context.Context, block db.AssignedBlock) {
+	ctx, done, ok := s.pending.track(ctx, block)
+	if !ok {
+		log.Debugf("block (%d,%d) is already being processed",
+			block.Epoch, block.No)
+		return
+	}
+	defer done()
 	log.Infof("processing block at (%d,%d) with no=%d for pool-id=%s",
 		block.Epoch, block.EpochSlot, block.No, s.poolID)
 	sub, cancel := s.tipUpdater.Subscribe()
@@ -62,7 +158,7 @@ func (s *Syncer) processBlock(ctx context.Context, block db.AssignedBlock) {
 		tip := s.tipUpdater.GetTip()
 		if tip != nil {
 			diff := time.Unix(int64(tip.Timestamp), 0).Sub(block.Timestamp)
-			if diff > 3*time.Minute {
+			if diff > s.config.ConfirmationDelay {
 				break
 			}
 		}
@@ -74,25 +170,41 @@ func (s *Syncer) processBlock(ctx context.Context, block db.AssignedBlock) {
 		}
 	}
 	status, mintedBlock, err := s.getStatusOfBlock(ctx, block.Slot)
+	if err == nil {
+		err = s.storeStatus(ctx, block, status, mintedBlock)
+	}
 	if err != nil {
+		if ctx.Err() == nil {
+			s.scheduleRetry(ctx, block, err)
+		}
 		return
 	}
-	var mintedBlockID *uint
+	s.latency.Observe(s.config.Clock.Now().Sub(block.Timestamp).Seconds())
 	if mintedBlock != nil {
-		mintedBlockID, err = s.db.WriteMintedBlock(ctx, mintedBlock.ToDTO())
-		if err != nil {
-			return
-		}
+		log.Infof("updated the status of block (%d,%d) to %d as served by '%s'",
+			block.Epoch, block.No, status, mintedBlock.Source)
+		return
+	}
+	log.Infof("updated the status of block (%d,%d) to %d",
+		block.Epoch, block.No, status)
+}
+
+// storeStatus stores the given status and minted block for the given assigned
+// block in the database. An error will be returned, if the storing failed.
+func (s *Syncer) storeStatus(ctx context.Context, block db.AssignedBlock,
+	status db.BlockStatus, mintedBlock *chain.MintedBlock) error {
+
+	var relevant *db.MintedBlock
+	if mintedBlock != nil {
+		relevant = mintedBlock.ToDTO()
 	}
-	err = s.db.UpdateStatusForAssignment(ctx, block.Epoch, block.No, status,
-		mintedBlockID)
+	err := s.db.UpdateStatusForAssignment(ctx, s.poolID, block.Epoch, block.No,
+		status, relevant)
 	if err != nil {
-		log.Errorf("couldn't update the status for block (%d,%d): %s",
-			block.Epoch, block.No, err.Error())
-	} else {
-		log.Infof("updated the status of block (%d,%d) to %d",
-			block.Epoch, block.No, status)
+		log.Errorf("couldn't update the status for block (%d,%d) of pool-id=%s: %s",
+			block.Epoch, block.No, s.poolID, err.Error())
 	}
+	return err
 }
 
 // getStatusOfBlock gathers the status of an assigned block with the given slot
@@ -0,0 +1,358 @@
+package syncer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain/blockfrost"
+	"github.com/blockblu-io/leaderlog-api/pkg/chain/blockfrost/fake"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	"github.com/blockblu-io/leaderlog-api/pkg/db/memdb"
+	bf "github.com/blockfrost/blockfrost-go"
+)
+
+const (
+	// poolA is the pool whose blocks are synced.
+	poolA   = "cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214b"
+	bech32A = "pool1ekhy5xsgjaq38em75vevk8df0k0rljju77tljw288ys5kumqce5"
+	// poolB is another pool, which mints blocks on the same chain.
+	poolB   = "1d9302a3fb4b3b1935e02b27f0339798d3f08a55fbfdcd43a449a96f"
+	bech32B = "pool1rkfs9glmfva3jd0q9vnlqvuhnrflpzj4l07u6sayfx5k7d788us"
+	// projectID is the project ID of the fake Blockfrost server.
+	projectID = "test"
+	// awaitTimeout is the duration to wait for the syncer to react.
+	awaitTimeout = 5 * time.Second
+)
+
+// slotTime returns the time of the given slot on the mainnet.
+func slotTime(t *testing.T, slot uint) time.Time {
+	tm, err := chain.Mainnet.SlotTime(slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tm
+}
+
+// chainBlock returns a block of the Blockfrost API, which has been minted by
+// the pool with the given bech32 ID in the given slot of the mainnet.
+func chainBlock(t *testing.T, slot uint, height int, hash,
+	bech32ID string) bf.Block {
+
+	epoch, epochSlot, err := chain.Mainnet.EpochOfSlot(slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bf.Block{
+		Slot:       int(slot),
+		Epoch:      int(epoch),
+		EpochSlot:  int(epochSlot),
+		Height:     height,
+		Hash:       hash,
+		Time:       int(slotTime(t, slot).Unix()),
+		SlotLeader: bech32ID,
+	}
+}
+
+// assignedBlock returns the block with the given number, which has been
+// assigned to a pool in the given slot of the mainnet.
+func assignedBlock(t *testing.T, no, slot uint) db.AssignedBlock {
+	epoch, epochSlot, err := chain.Mainnet.EpochOfSlot(slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.AssignedBlock{
+		Epoch:     epoch,
+		No:        no,
+		EpochSlot: epochSlot,
+		Slot:      slot,
+		Timestamp: slotTime(t, slot),
+	}
+}
+
+// writeLeaderLog writes a leader log of poolA for the epoch of the given
+// blocks.
+func writeLeaderLog(t *testing.T, idb db.DB, blocks ...db.AssignedBlock) {
+	_, err := idb.WriteLeaderLog(context.Background(), &db.LeaderLog{
+		PoolID:              poolA,
+		Epoch:               blocks[0].Epoch,
+		Blocks:              blocks,
+		ExpectedBlockNumber: float32(len(blocks)),
+		MaxPerformance:      1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newFakeChain starts a fake Blockfrost server serving the given blocks, and
+// creates a backend for it.
+func newFakeChain(t *testing.T,
+	blocks ...bf.Block) (chain.Backend, *fake.Server) {
+
+	t.Setenv("BLU_BLOCKFROST_API_KEY", projectID)
+	server := fake.NewServer(&fake.Chain{
+		ProjectID: projectID,
+		Blocks:    blocks,
+		Pools: []bf.PoolMetadata{
+			{PoolID: bech32A, Hex: poolA, Ticker: "BLUE"},
+			{PoolID: bech32B, Hex: poolB, Ticker: "RED"},
+		},
+	})
+	t.Cleanup(server.Close)
+	backend, err := blockfrost.NewBlockFrostBackend(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return backend, server
+}
+
+// startSyncer runs a syncer for poolA with the given backend and database,
+// whose time is told by the given clock. The re-verification of classified
+// blocks is disabled.
+func startSyncer(t *testing.T, backend chain.Backend, idb db.DB,
+	clk clock.Clock) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	tu := NewTipUpdater(&TipUpdaterConfiguration{
+		Interval: time.Minute,
+		Clock:    clk,
+	})
+	tu.Run(ctx, backend)
+	config := *DefaultSyncerConfig
+	config.VerificationInterval = 0
+	config.Clock = clk
+	go NewSyncer(poolA, backend, tu, idb, &config).Run(ctx)
+}
+
+// awaitWaiters waits until the given number of timers and tickers are waiting
+// on the given clock, and fails the test, if they aren't in time.
+func awaitWaiters(t *testing.T, clk *clock.Fake, n int) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		clk.BlockUntil(n)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(awaitTimeout):
+		t.Fatalf("expected %d timers to wait on the clock, got %d", n,
+			clk.Waiters())
+	}
+}
+
+// getBlock gets the assigned block of poolA with the given epoch and number,
+// whose slot must be in the past.
+func getBlock(t *testing.T, idb db.DB, epoch, no uint) db.AssignedBlock {
+	t.Helper()
+	blocks, err := idb.GetAssignedBlocksBeforeNow(context.Background(), poolA,
+		epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range blocks {
+		if block.No == no {
+			return block
+		}
+	}
+	t.Fatalf("the block (%d,%d) isn't assigned before now", epoch, no)
+	return db.AssignedBlock{}
+}
+
+// awaitStatus waits until the assigned block of poolA with the given epoch and
+// number has the given status, and fails the test, if it doesn't in time.
+func awaitStatus(t *testing.T, idb db.DB, epoch, no uint,
+	status db.BlockStatus) db.AssignedBlock {
+
+	t.Helper()
+	deadline := time.Now().Add(awaitTimeout)
+	for {
+		block := getBlock(t, idb, epoch, no)
+		if block.Status == status {
+			return block
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the block (%d,%d) to have status %d, got %d",
+				epoch, no, status, block.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSyncAcrossEpoch(t *testing.T) {
+	// the last block of epoch 327 and the first one of epoch 328 have been
+	// assigned to the pool, and the time is an hour into epoch 327.
+	lastOf327 := assignedBlock(t, 1, 56332700)
+	firstOf328 := assignedBlock(t, 1, 56332900)
+	clk := clock.NewFake(slotTime(t, 55904400))
+	idb := memdb.NewMemDB(clk)
+	writeLeaderLog(t, idb, lastOf327)
+	writeLeaderLog(t, idb, firstOf328)
+	backend, server := newFakeChain(t,
+		chainBlock(t, 55904400, 7000000, "0a00", bech32B))
+	startSyncer(t, backend, idb, clk)
+
+	// the tip updater, the retry queue and the scanner waiting for the last
+	// block of epoch 327.
+	awaitWaiters(t, clk, 3)
+	server.AddBlock(chainBlock(t, 56332700, 7021000, "0a01", bech32A))
+	server.AddBlock(chainBlock(t, 56332890, 7021001, "0a02", bech32B))
+	clk.Set(slotTime(t, 56332895))
+	block := awaitStatus(t, idb, 327, 1, db.Minted)
+	if block.RelevantBlock == nil || block.RelevantBlock.Hash != "0a01" {
+		t.Errorf("expected the minted block 0a01, got %+v", block.RelevantBlock)
+	}
+	// the scanner waits for the first block of epoch 328.
+	awaitWaiters(t, clk, 3)
+	if n := server.Requests("/blocks/slot/56332900"); n != 0 {
+		t.Errorf("expected the future slot 56332900 not to be looked up, got %d requests",
+			n)
+	}
+
+	server.AddBlock(chainBlock(t, 56333200, 7021002, "0a03", bech32B))
+	clk.Set(slotTime(t, 56333210))
+	awaitStatus(t, idb, 328, 1, db.GHOSTED)
+	if n := server.Requests("/blocks/slot/56332900"); n != 1 {
+		t.Errorf("expected the slot 56332900 to be looked up once, got %d",
+			n)
+	}
+}
+
+// awaitRetry waits until a retry has been queued for the assigned block of
+// poolA with the given epoch and number, and fails the test, if it hasn't in
+// time.
+func awaitRetry(t *testing.T, idb db.DB, epoch, no uint) db.RetryEntry {
+	t.Helper()
+	deadline := time.Now().Add(awaitTimeout)
+	for {
+		entry, err := idb.GetRetryEntry(context.Background(), poolA, epoch, no)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry != nil {
+			return *entry
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a retry to be queued for the block (%d,%d)",
+				epoch, no)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClassifyBlocks(t *testing.T) {
+	clk := clock.NewFake(slotTime(t, 55901810))
+	idb := memdb.NewMemDB(clk)
+	writeLeaderLog(t, idb,
+		assignedBlock(t, 1, 55900900),
+		assignedBlock(t, 2, 55901000),
+		assignedBlock(t, 3, 55901100),
+		assignedBlock(t, 4, 55901200),
+	)
+	backend, _ := newFakeChain(t,
+		chainBlock(t, 55900900, 7000001, "0a01", bech32A),
+		chainBlock(t, 55901000, 7000002, "0a02", bech32B),
+		chainBlock(t, 55901102, 7000003, "0a03", bech32B),
+		chainBlock(t, 55901800, 7000004, "0a04", bech32B),
+	)
+	startSyncer(t, backend, idb, clk)
+
+	tests := []struct {
+		no     uint
+		status db.BlockStatus
+		hash   string
+		poolID string
+	}{
+		// the pool has minted the block in its slot.
+		{1, db.Minted, "0a01", poolA},
+		// another pool has minted a block in the same slot.
+		{2, db.DoubleAssignment, "0a02", poolB},
+		// another pool has minted a block in a close slot.
+		{3, db.HeightBattle, "0a03", poolB},
+		// no block has been minted around the slot.
+		{4, db.GHOSTED, "", ""},
+	}
+	for _, tc := range tests {
+		block := awaitStatus(t, idb, 327, tc.no, tc.status)
+		relevant := block.RelevantBlock
+		switch {
+		case tc.hash == "" && relevant != nil:
+			t.Errorf("expected no relevant block for block (327,%d), got %+v",
+				tc.no, relevant)
+		case tc.hash != "" && (relevant == nil || relevant.Hash != tc.hash ||
+			relevant.PoolID != tc.poolID):
+			t.Errorf("expected the relevant block %s of pool %s for block (327,%d), got %+v",
+				tc.hash, tc.poolID, tc.no, relevant)
+		}
+	}
+}
+
+func TestRetryTemporaryFailures(t *testing.T) {
+	clk := clock.NewFake(slotTime(t, 55901810))
+	idb := memdb.NewMemDB(clk)
+	writeLeaderLog(t, idb,
+		assignedBlock(t, 1, 55900900),
+		assignedBlock(t, 2, 55901000),
+	)
+	backend, server := newFakeChain(t,
+		chainBlock(t, 55900900, 7000001, "0a01", bech32A),
+		chainBlock(t, 55901000, 7000002, "0a02", bech32B),
+		chainBlock(t, 55901800, 7000004, "0a04", bech32B),
+	)
+	server.AddFault(fake.Fault{
+		Path:       "/blocks/slot/55900900",
+		StatusCode: 429,
+		Times:      1,
+	})
+	server.AddFault(fake.Fault{
+		Path:       "/blocks/slot/55901000",
+		StatusCode: 503,
+		Times:      1,
+	})
+	startSyncer(t, backend, idb, clk)
+
+	retryAt := clk.Now().Add(DefaultSyncerConfig.RetryBaseDelay)
+	for _, tc := range []struct {
+		no         uint
+		slot       string
+		statusCode string
+	}{
+		{1, "55900900", "429"},
+		{2, "55901000", "503"},
+	} {
+		entry := awaitRetry(t, idb, 327, tc.no)
+		if entry.Attempts != 1 || !entry.NextAttempt.Equal(retryAt) ||
+			!strings.Contains(entry.LastError, tc.statusCode) {
+			t.Errorf("expected the block (327,%d) to be retried at %v after status %s, got %+v",
+				tc.no, retryAt, tc.statusCode, entry)
+		}
+		// the failure must reach the syncer without being retried by the
+		// backend itself.
+		if n := server.Requests("/blocks/slot/" + tc.slot); n != 1 {
+			t.Errorf("expected the slot %s to be looked up once, got %d",
+				tc.slot, n)
+		}
+	}
+	if block := getBlock(t, idb, 327, 1); block.Status != db.NotMinted {
+		t.Errorf("expected the block (327,1) to be pending, got status %d",
+			block.Status)
+	}
+
+	// the tip updater and the retry queue.
+	awaitWaiters(t, clk, 2)
+	clk.Advance(DefaultSyncerConfig.RetryBaseDelay + retryInterval)
+	awaitStatus(t, idb, 327, 1, db.Minted)
+	awaitStatus(t, idb, 327, 2, db.DoubleAssignment)
+	for _, no := range []uint{1, 2} {
+		entry, err := idb.GetRetryEntry(context.Background(), poolA, 327, no)
+		if err != nil || entry != nil {
+			t.Errorf("expected the retry of block (327,%d) to be dequeued, got %+v (%v)",
+				no, entry, err)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package syncer
 import (
 	"context"
 	"github.com/blockblu-io/leaderlog-api/pkg/chain"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
 	log "github.com/sirupsen/logrus"
 	"math"
 	"sync"
@@ -12,6 +13,7 @@ import (
 var (
 	DefaultTipUpdaterConfig = &TipUpdaterConfiguration{
 		Interval: 1 * time.Minute,
+		Clock:    clock.Real,
 	}
 )
 
@@ -20,6 +22,9 @@ type TipUpdaterConfiguration struct {
 	// Interval specifies the interval at which the tip of the network will
 	// be fetched.
 	Interval time.Duration
+	// Clock tells the time for the TipUpdater. The time of the system is used,
+	// if it is nil.
+	Clock clock.Clock
 }
 
 // TipUpdater fetches the tip of the Cardano network regularly. The interval
@@ -33,6 +38,7 @@ type TipUpdater struct {
 type tip struct {
 	lock  sync.RWMutex
 	value *chain.Tip
+	err   error
 }
 
 type observer struct {
@@ -63,9 +69,14 @@ func (o *observer) getObserverID() int {
 // NewTipUpdater creates a new TipUpdater with the given
 // TipUpdaterConfiguration.
 func NewTipUpdater(config *TipUpdaterConfiguration) *TipUpdater {
-	if config != nil {
+	if config == nil {
 		config = DefaultTipUpdaterConfig
 	}
+	if config.Clock == nil {
+		withClock := *config
+		withClock.Clock = clock.Real
+		config = &withClock
+	}
 	return &TipUpdater{
 		config: config,
 	}
@@ -138,31 +149,60 @@ func (tu *TipUpdater) GetTip() *chain.Tip {
 	return tu.tip.value
 }
 
+// GetLastError returns the error of the last attempt to fetch the tip. Nil is
+// returned, if the last attempt succeeded.
+func (tu *TipUpdater) GetLastError() error {
+	tu.tip.lock.RLock()
+	defer tu.tip.lock.RUnlock()
+	return tu.tip.err
+}
+
+// setTip sets the given tip as the latest gathered tip, and notifies all the
+// subscribers.
+func (tu *TipUpdater) setTip(tip *chain.Tip) {
+	tu.tip.lock.Lock()
+	defer tu.tip.lock.Unlock()
+	tu.tip.value = tip
+	tu.tip.err = nil
+	go tu.observer.notify()
+}
+
+// setError sets the given error as the error of the last attempt to fetch the
+// tip. The latest gathered tip is kept.
+func (tu *TipUpdater) setError(err error) {
+	tu.tip.lock.Lock()
+	defer tu.tip.lock.Unlock()
+	tu.tip.err = err
+}
+
 // Run runs the TipUpdater using the given chain.Backend. The run of this method
 // can be canceled over the given context. Otherwise, this method is running
-// infinitely.
+// infinitely. If the backend is a chain.TipFollower, then it is following the
+// chain and pushing the tip instead of being polled.
 func (tu *TipUpdater) Run(ctx context.Context, backend chain.Backend) {
+	if follower, ok := backend.(chain.TipFollower); ok {
+		go tu.follow(ctx, follower)
+		return
+	}
 	gather := func(ctx context.Context, backend chain.Backend) {
 		tip, err := backend.GetLatestBlock(ctx)
 		if err != nil {
 			log.Errorf("tip cpuldn't be gathered: %s", err.Error())
+			tu.setError(err)
 		} else {
-			log.Infof("fetched the tip (%d,%d) with hash=%s (minted at %s)",
+			log.Infof("fetched the tip (%d,%d) with hash=%s (minted at %s) from '%s'",
 				tip.Epoch, tip.SlotInEpoch, tip.Hash,
-				time.Unix(int64(tip.Timestamp), 0))
-			tu.tip.lock.Lock()
-			defer tu.tip.lock.Unlock()
-			tu.tip.value = tip
-			go tu.observer.notify()
+				time.Unix(int64(tip.Timestamp), 0), tip.Source)
+			tu.setTip(tip)
 		}
 	}
 	go func() {
 		gather(ctx, backend)
 		keepOn := true
 		for keepOn {
-			timer := time.NewTimer(1 * time.Minute)
+			timer := tu.config.Clock.NewTimer(tu.config.Interval)
 			select {
-			case <-timer.C:
+			case <-timer.C():
 				gather(ctx, backend)
 			case <-ctx.Done():
 				keepOn = false
@@ -171,3 +211,21 @@ func (tu *TipUpdater) Run(ctx context.Context, backend chain.Backend) {
 		}
 	}()
 }
+
+// follow lets the given chain.TipFollower follow the chain, and sets each tip
+// pushed by it until the given context has been canceled.
+func (tu *TipUpdater) follow(ctx context.Context, follower chain.TipFollower) {
+	tips, cancel := follower.SubscribeTip()
+	defer cancel()
+	go follower.Follow(ctx)
+	for {
+		select {
+		case tip := <-tips:
+			log.Debugf("received the tip (%d,%d) with hash=%s",
+				tip.Epoch, tip.SlotInEpoch, tip.Hash)
+			tu.setTip(&tip)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
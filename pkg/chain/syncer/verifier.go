@@ -0,0 +1,95 @@
+package syncer
+
+import (
+	"context"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// slotsPerBlock is the expected number of slots between two blocks given the
+// active slot coefficient of 1/20. It is used to express the finality window
+// in slots for blocks without linked minted block.
+const slotsPerBlock = 20
+
+// runVerifier re-verifies the classified blocks within the finality window in
+// the configured interval. This method is running infinitely unless the given
+// context has been cancelled.
+func (s *Syncer) runVerifier(ctx context.Context) {
+	if s.config.FinalityWindow == 0 || s.config.VerificationInterval <= 0 {
+		return
+	}
+	ticker := s.config.Clock.NewTicker(s.config.VerificationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C():
+			s.verifyBlocks(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// verifyBlocks re-verifies all classified blocks, which could still be changed
+// by a rollback of the chain given the current tip.
+func (s *Syncer) verifyBlocks(ctx context.Context) {
+	tip := s.tipUpdater.GetTip()
+	if tip == nil {
+		return
+	}
+	height, slot := uint(0), uint(0)
+	if tip.Height > s.config.FinalityWindow {
+		height = tip.Height - s.config.FinalityWindow
+	}
+	if tip.Slot > s.config.FinalityWindow*slotsPerBlock {
+		slot = tip.Slot - s.config.FinalityWindow*slotsPerBlock
+	}
+	blocks, err := s.db.GetClassifiedBlocksWithinWindow(ctx, s.poolID, height,
+		slot)
+	if err != nil {
+		log.Errorf("couldn't scan the blocks within the finality window: %s",
+			err.Error())
+		return
+	}
+	for _, block := range blocks {
+		s.verifyBlock(ctx, block)
+	}
+}
+
+// verifyBlock checks whether the relevant minted block of the given classified
+// block is still on the chain. The block is reclassified, if the minted block
+// has been rolled back, or if the block has been ghosted. The verification is
+// skipped, if the block is already being processed.
+func (s *Syncer) verifyBlock(ctx context.Context, block db.AssignedBlock) {
+	ctx, done, ok := s.pending.track(ctx, block)
+	if !ok {
+		return
+	}
+	defer done()
+	if block.RelevantBlock != nil {
+		mintedBlock, err := s.backend.GetMintedBlock(ctx, block.RelevantBlock.Slot)
+		if err != nil {
+			log.Warnf("couldn't verify block (%d,%d): %s", block.Epoch,
+				block.No, err.Error())
+			return
+		}
+		if mintedBlock != nil && mintedBlock.Hash == block.RelevantBlock.Hash {
+			return
+		}
+		log.Warnf("minted block '%s' of block (%d,%d) has been rolled back",
+			block.RelevantBlock.Hash, block.Epoch, block.No)
+	}
+	status, mintedBlock, err := s.getStatusOfBlock(ctx, block.Slot)
+	if err != nil {
+		return
+	}
+	if status == block.Status && mintedBlock == nil && block.RelevantBlock == nil {
+		return
+	}
+	err = s.storeStatus(ctx, block, status, mintedBlock)
+	if err == nil {
+		log.Infof("reclassified block (%d,%d) from status %d to %d",
+			block.Epoch, block.No, block.Status, status)
+	}
+}
@@ -0,0 +1,84 @@
+package clock
+
+import "time"
+
+// Clock tells the current time and creates timers as well as tickers. It
+// allows to replace the time of the system, e.g. with a Fake clock in tests.
+type Clock interface {
+
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer creates a new Timer, which sends the current time on its
+	// channel after at least the given duration.
+	NewTimer(d time.Duration) Timer
+
+	// NewTicker creates a new Ticker, which sends the current time on its
+	// channel after each period of the given duration.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a single event in the future like time.Timer.
+type Timer interface {
+
+	// C returns the channel on which the time is delivered.
+	C() <-chan time.Time
+
+	// Stop prevents the Timer from firing. It returns false, if the timer has
+	// already expired or been stopped.
+	Stop() bool
+}
+
+// Ticker is delivering ticks in an interval like time.Ticker.
+type Ticker interface {
+
+	// C returns the channel on which the ticks are delivered.
+	C() <-chan time.Time
+
+	// Stop turns off the ticker.
+	Stop()
+}
+
+// Real is the Clock of the system.
+var Real Clock = realClock{}
+
+// realClock is a Clock delegating to the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+// realTimer is a Timer wrapping a time.Timer.
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// realTicker is a Ticker wrapping a time.Ticker.
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t realTicker) Stop() {
+	t.ticker.Stop()
+}
@@ -0,0 +1,177 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock, whose time only moves, when it is advanced. Timers and
+// tickers fire, when the time is advanced past their deadline. It is meant to
+// be used in tests.
+type Fake struct {
+	lock    sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter is a timer or ticker of a Fake clock.
+type fakeWaiter struct {
+	clock    *Fake
+	deadline time.Time
+	period   time.Duration
+	c        chan time.Time
+}
+
+// NewFake creates a new Fake clock, which is set to the given time.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.lock)
+	return f
+}
+
+func (f *Fake) Now() time.Time {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.now
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return f.newWaiter(d, 0)
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for clock.Fake.NewTicker")
+	}
+	return fakeTicker{f.newWaiter(d, d)}
+}
+
+// newWaiter registers a new waiter, which fires after the given duration and
+// then in the given period, if it is positive.
+func (f *Fake) newWaiter(d, period time.Duration) *fakeWaiter {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	w := &fakeWaiter{
+		clock:    f,
+		deadline: f.now.Add(d),
+		period:   period,
+		c:        make(chan time.Time, 1),
+	}
+	if d <= 0 && period == 0 {
+		w.c <- f.now
+		return w
+	}
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+	return w
+}
+
+// Advance moves the time of this clock forward by the given duration, and
+// fires all timers and tickers, whose deadline has been reached. Like for the
+// tickers of the time package, ticks are dropped for slow receivers.
+func (f *Fake) Advance(d time.Duration) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.now = f.now.Add(d)
+	waiters := make([]*fakeWaiter, 0, len(f.waiters))
+	for _, w := range f.waiters {
+		if w.deadline.After(f.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		select {
+		case w.c <- f.now:
+		default:
+		}
+		if w.period > 0 {
+			for !w.deadline.After(f.now) {
+				w.deadline = w.deadline.Add(w.period)
+			}
+			waiters = append(waiters, w)
+		}
+	}
+	f.waiters = waiters
+	f.cond.Broadcast()
+}
+
+// Set moves the time of this clock forward to the given time. Nothing is
+// changed, if the given time is before the current time of this clock.
+func (f *Fake) Set(t time.Time) {
+	d := t.Sub(f.Now())
+	if d > 0 {
+		f.Advance(d)
+	}
+}
+
+// Waiters returns the number of timers and tickers, which haven't fired or
+// been stopped yet.
+func (f *Fake) Waiters() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil blocks until at least the given number of timers and tickers are
+// waiting on this clock. It allows tests to advance the time, once the code
+// under test is waiting for it.
+func (f *Fake) BlockUntil(n int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// NextDeadline returns the earliest deadline of the timers and tickers waiting
+// on this clock. False is returned, if no timer or ticker is waiting.
+func (f *Fake) NextDeadline() (time.Time, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if len(f.waiters) == 0 {
+		return time.Time{}, false
+	}
+	next := f.waiters[0].deadline
+	for _, w := range f.waiters[1:] {
+		if w.deadline.Before(next) {
+			next = w.deadline
+		}
+	}
+	return next, true
+}
+
+// remove removes the given waiter from this clock. It returns false, if the
+// waiter has already been removed.
+func (f *Fake) remove(w *fakeWaiter) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for i, other := range f.waiters {
+		if other == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			f.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.c
+}
+
+func (w *fakeWaiter) Stop() bool {
+	return w.clock.remove(w)
+}
+
+// fakeTicker is a Ticker of a Fake clock.
+type fakeTicker struct {
+	waiter *fakeWaiter
+}
+
+func (t fakeTicker) C() <-chan time.Time {
+	return t.waiter.c
+}
+
+func (t fakeTicker) Stop() {
+	t.waiter.Stop()
+}
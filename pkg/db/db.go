@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 // Ordering specified in which order a list shall be sorted.
@@ -20,41 +21,145 @@ type DB interface {
 	// Observer is returning a db.Observer for this db.DB instance.
 	Observer() *Observer
 
-	// GetRegisteredEpochs gets a list of all registered epochs.
-	GetRegisteredEpochs(ctx context.Context, ordering Ordering,
+	// GetRegisteredEpochs gets a list of all registered epochs of the pool
+	// with the given ID.
+	GetRegisteredEpochs(ctx context.Context, poolID string, ordering Ordering,
 		limit uint) ([]uint, error)
 
-	// GetLeaderLog gets the leader log for the given epoch.
-	GetLeaderLog(ctx context.Context, epoch uint) (*LeaderLog, error)
+	// GetLeaderLog gets the leader log of the pool with the given ID for the
+	// given epoch.
+	GetLeaderLog(ctx context.Context, poolID string,
+		epoch uint) (*LeaderLog, error)
 
-	// GetAssignedBlocksAfterNow gets the assigned blocks that have been planned
-	// after now.
-	GetAssignedBlocksAfterNow(ctx context.Context) ([]AssignedBlock, error)
+	// GetPerformance gets the performance of the pool with the given ID for
+	// each epoch from the given epoch up to the other given epoch (inclusive),
+	// for which a leader log has been registered. The performances are ordered
+	// by epoch.
+	GetPerformance(ctx context.Context, poolID string, from,
+		to uint) ([]EpochPerformance, error)
 
-	// GetAssignedBlocksBeforeNow gets the assigned blocks that have been
-	// planned before now for the given epoch.
-	GetAssignedBlocksBeforeNow(ctx context.Context,
+	// GetAssignedBlocksAfterNow gets the assigned blocks of the pool with the
+	// given ID that have been planned after now.
+	GetAssignedBlocksAfterNow(ctx context.Context,
+		poolID string) ([]AssignedBlock, error)
+
+	// GetAssignedBlocksBeforeNow gets the assigned blocks of the pool with the
+	// given ID that have been planned before now for the given epoch.
+	GetAssignedBlocksBeforeNow(ctx context.Context, poolID string,
 		epoch uint) ([]AssignedBlock, error)
 
-	// GetAssignedBlocksWithStatusBeforeNow gets the assigned blocks that have
-	// been planned before current time and have the given BlockStatus.
-	GetAssignedBlocksWithStatusBeforeNow(ctx context.Context,
+	// GetAssignedBlocksWithStatusBeforeNow gets the assigned blocks of the
+	// pool with the given ID that have been planned before current time and
+	// have the given BlockStatus.
+	GetAssignedBlocksWithStatusBeforeNow(ctx context.Context, poolID string,
 		status BlockStatus, offset, limit uint) ([]AssignedBlock, error)
 
+	// GetClassifiedBlocksWithinWindow gets the assigned blocks of the pool with
+	// the given ID that have already been classified, and whose classification
+	// could still be changed by a rollback of the chain. These are the blocks
+	// linked to a minted block with at least the given height, and the blocks
+	// without minted block that have been planned at or after the given slot.
+	GetClassifiedBlocksWithinWindow(ctx context.Context, poolID string, height,
+		slot uint) ([]AssignedBlock, error)
+
+	// GetStatusTransitions gets the recorded status transitions of the
+	// assigned blocks of the pool with the given ID for the given epoch in
+	// the order, in which they have been recorded.
+	GetStatusTransitions(ctx context.Context, poolID string,
+		epoch uint) ([]StatusTransition, error)
+
+	// GetRetryEntries gets the queued retries for assigned blocks of the pool
+	// with the given ID, whose status couldn't be gathered, ordered by the time
+	// of the next attempt.
+	GetRetryEntries(ctx context.Context, poolID string) ([]RetryEntry, error)
+
+	// GetRetryEntry gets the queued retry for the assigned block of the given
+	// pool and epoch with the specified unique id called "no". Nil will be
+	// returned, if no retry is queued for this block.
+	GetRetryEntry(ctx context.Context, poolID string, epoch,
+		no uint) (*RetryEntry, error)
+
+	// WriteRetryEntry queues the given retry for its assigned block of the
+	// pool with the given ID. A retry that has already been queued for this
+	// block is overwritten.
+	WriteRetryEntry(ctx context.Context, poolID string, entry *RetryEntry) error
+
 	// UpdateStatusForAssignment updates the status for the block assignment of
-	// the given epoch with the specified unique id called "no".
-	UpdateStatusForAssignment(ctx context.Context, epoch, no uint,
-		status BlockStatus, mintedBlockID *uint) error
-
-	// WriteMintedBlock writes the given minted block to the database and
-	// returns the ID primary key for this entry. If the writing fails, an error
-	// will be returned otherwise.
-	WriteMintedBlock(ctx context.Context, block *MintedBlock) (*uint, error)
-
-	// WriteLeaderLog writes the given list of assigned blocks for the given
-	// epoch to the DB. If a leader log has already written for this epoch, then
-	// the old leader log will be overwritten.
-	WriteLeaderLog(ctx context.Context, log *LeaderLog) error
+	// the given pool and epoch with the specified unique id called "no". The
+	// given minted block, which explains the status, is written and linked to
+	// the block assignment in the same transaction, such that no minted block
+	// is left behind, if the update fails. It can be nil. A change of the
+	// status or of the relevant minted block is recorded as StatusTransition.
+	// A minted block that was linked before is removed. A queued retry for the
+	// block assignment is resolved.
+	UpdateStatusForAssignment(ctx context.Context, poolID string, epoch,
+		no uint, status BlockStatus, mintedBlock *MintedBlock) error
+
+	// WriteLeaderLog writes the given list of assigned blocks for the pool and
+	// epoch of the given leader log to the DB. If a leader log has already
+	// written for this pool and epoch, then the old leader log will be
+	// overwritten. The status and relevant minted block of assigned slots that
+	// are part of the old as well as the new leader log are preserved. A
+	// summary of the changes is returned.
+	WriteLeaderLog(ctx context.Context, log *LeaderLog) (*LeaderLogDiff, error)
+
+	// DeleteLeaderLog deletes the leader log of the pool with the given ID for
+	// the given epoch including all its assigned blocks and the minted blocks
+	// linked to them. True will be returned, if a leader log has been
+	// registered for this pool and epoch. Otherwise, false.
+	DeleteLeaderLog(ctx context.Context, poolID string, epoch uint) (bool, error)
+
+	// GetStakePool gets the metadata of the stake pool with the given ID in
+	// hex format. Nil will be returned, if no metadata has been written for
+	// this pool.
+	GetStakePool(ctx context.Context, hexID string) (*StakePool, error)
+
+	// WriteStakePool writes the given metadata of a stake pool to the
+	// database. Metadata that has already been written for this pool is
+	// overwritten.
+	WriteStakePool(ctx context.Context, pool *StakePool) error
+
+	// GetEventsAfter gets the events of the pool with the given ID, whose ID
+	// is greater than the given one, in the order in which they have been
+	// recorded. At most the given number of events is returned. An event is
+	// recorded with each change, for which an ObserverMessage is published.
+	GetEventsAfter(ctx context.Context, poolID string, id,
+		limit uint) ([]Event, error)
+
+	// GetLastEventID gets the ID of the event of the pool with the given ID,
+	// which has been recorded last. Zero will be returned, if no event has
+	// been recorded for this pool.
+	GetLastEventID(ctx context.Context, poolID string) (uint, error)
+
+	// QueueWebhookDelivery queues the given delivery as pending, such that it
+	// is attempted immediately. True will be returned, if the delivery has
+	// been queued, and false, if a delivery to the same URL about the same
+	// status transition to the same status of the same assigned block has
+	// been queued before.
+	QueueWebhookDelivery(ctx context.Context,
+		delivery *WebhookDelivery) (bool, error)
+
+	// ClaimWebhookDeliveries claims at most the given number of pending
+	// deliveries, whose next attempt is due. The deliveries, which are due the
+	// longest, are claimed first. The next attempt of the claimed deliveries
+	// is postponed by the given lease, such that they aren't claimed again in
+	// the meantime.
+	ClaimWebhookDeliveries(ctx context.Context, lease time.Duration,
+		limit uint) ([]WebhookDelivery, error)
+
+	// UpdateWebhookDelivery updates the state, the attempts, the next attempt,
+	// the last error and the response status of the given delivery.
+	UpdateWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error
+
+	// GetWebhookDeliveries gets at most the given number of deliveries after
+	// skipping the given offset, ordered from the latest queued one to the
+	// earliest one.
+	GetWebhookDeliveries(ctx context.Context, offset,
+		limit uint) ([]WebhookDelivery, error)
+
+	// Ping checks whether the database can be reached and written. An error
+	// will be returned, if it can't.
+	Ping(ctx context.Context) error
 
 	// Close closes this database and all connections.
 	Close() error
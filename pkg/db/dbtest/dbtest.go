@@ -0,0 +1,1215 @@
+package dbtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+)
+
+// Factory creates a new and empty db.DB for a single test of the suite, which
+// must use the given clock.Clock. The suite closes the db.DB at the end of the
+// test.
+type Factory func(t *testing.T, clk clock.Clock) db.DB
+
+const (
+	// poolA is the ID of the pool for which most leader logs are written.
+	poolA = "cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214b"
+	// poolB is the ID of another pool, which mustn't be affected by changes
+	// to the leader logs of poolA.
+	poolB = "1d9302a3fb4b3b1935e02b27f0339798d3f08a55fbfdcd43a449a96f"
+	// epochLength is the number of slots per epoch used by the suite.
+	epochLength = 432000
+	// observerTimeout is the duration to wait for an observer message.
+	observerTimeout = 5 * time.Second
+)
+
+// startTime is the time to which the clock is set at the start of a test.
+var startTime = time.Date(2022, 3, 17, 12, 0, 0, 0, time.UTC)
+
+// Run runs the conformance suite against the db.DB implementation created by
+// the given factory. Every test is run with a new db.DB, whose time is
+// controlled by the suite with a clock.Fake.
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, idb db.DB, clk *clock.Fake)
+	}{
+		{"Ping", testPing},
+		{"RegisteredEpochs", testRegisteredEpochs},
+		{"GetLeaderLog", testGetLeaderLog},
+		{"Performance", testPerformance},
+		{"ReplaceLeaderLog", testReplaceLeaderLog},
+		{"DeleteLeaderLog", testDeleteLeaderLog},
+		{"AssignedBlocksAroundNow", testAssignedBlocksAroundNow},
+		{"AdvancingClock", testAdvancingClock},
+		{"AssignedBlocksWithStatus", testAssignedBlocksWithStatus},
+		{"UpdateStatusForAssignment", testUpdateStatusForAssignment},
+		{"StatusTransitions", testStatusTransitions},
+		{"ClassifiedBlocksWithinWindow", testClassifiedBlocksWithinWindow},
+		{"RetryEntries", testRetryEntries},
+		{"InvalidWrites", testInvalidWrites},
+		{"StakePools", testStakePools},
+		{"Events", testEvents},
+		{"WebhookDeliveries", testWebhookDeliveries},
+		{"ObserverMessages", testObserverMessages},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			clk := clock.NewFake(startTime)
+			idb := factory(t, clk)
+			defer idb.Close()
+			tc.test(t, idb, clk)
+		})
+	}
+}
+
+// assignedBlock creates the assigned block of the given epoch with the given
+// number, which is scheduled for the given slot of the epoch and time.
+func assignedBlock(epoch, no, epochSlot uint, timestamp time.Time) db.AssignedBlock {
+	return db.AssignedBlock{
+		Epoch:     epoch,
+		No:        no,
+		EpochSlot: epochSlot,
+		Slot:      epoch*epochLength + epochSlot,
+		Timestamp: timestamp.Truncate(time.Second),
+	}
+}
+
+// leaderLog creates the leader log of the given pool and epoch with the given
+// assigned blocks.
+func leaderLog(poolID string, epoch uint, blocks ...db.AssignedBlock) *db.LeaderLog {
+	return &db.LeaderLog{
+		PoolID:              poolID,
+		Epoch:               epoch,
+		Blocks:              blocks,
+		ExpectedBlockNumber: 14.25,
+		MaxPerformance:      float32(len(blocks)) / 14.25,
+	}
+}
+
+// mintedBlock creates a minted block of the given pool in the slot of the
+// given assigned block.
+func mintedBlock(block db.AssignedBlock, poolID, hash string,
+	height uint) *db.MintedBlock {
+
+	return &db.MintedBlock{
+		Epoch:     block.Epoch,
+		EpochSlot: block.EpochSlot,
+		Slot:      block.Slot,
+		Hash:      hash,
+		Height:    height,
+		PoolID:    poolID,
+	}
+}
+
+// writeLeaderLog writes the given leader log and fails the test, if the
+// writing fails.
+func writeLeaderLog(t *testing.T, idb db.DB, log *db.LeaderLog) *db.LeaderLogDiff {
+	t.Helper()
+	diff, err := idb.WriteLeaderLog(context.Background(), log)
+	if err != nil {
+		t.Fatalf("writing the leader log of epoch %d failed: %s", log.Epoch,
+			err.Error())
+	}
+	return diff
+}
+
+// writeStatus links the given status and minted block, if it isn't nil, to
+// the assigned block. The test fails, if the writing fails.
+func writeStatus(t *testing.T, idb db.DB, poolID string,
+	block db.AssignedBlock, status db.BlockStatus, minted *db.MintedBlock) {
+
+	t.Helper()
+	err := idb.UpdateStatusForAssignment(context.Background(), poolID,
+		block.Epoch, block.No, status, minted)
+	if err != nil {
+		t.Fatalf("updating the status of block (%d,%d) failed: %s",
+			block.Epoch, block.No, err.Error())
+	}
+}
+
+// checkSlots fails the test, if the given assigned blocks aren't scheduled for
+// exactly the expected slots in the given order.
+func checkSlots(t *testing.T, what string, blocks []db.AssignedBlock,
+	expected ...uint) {
+
+	t.Helper()
+	slots := make([]uint, len(blocks))
+	for i, block := range blocks {
+		slots[i] = block.Slot
+	}
+	checkUints(t, what, slots, expected...)
+}
+
+// checkUints fails the test, if the given values aren't exactly the expected
+// values in the given order.
+func checkUints(t *testing.T, what string, values []uint, expected ...uint) {
+	t.Helper()
+	if values == nil {
+		t.Errorf("%s must be an empty list instead of nil", what)
+	}
+	if len(values) != len(expected) {
+		t.Errorf("%s must be %v, but was %v", what, expected, values)
+		return
+	}
+	for i := range values {
+		if values[i] != expected[i] {
+			t.Errorf("%s must be %v, but was %v", what, expected, values)
+			return
+		}
+	}
+}
+
+// checkBlock fails the test, if the given assigned block doesn't match the
+// expected one.
+func checkBlock(t *testing.T, block, expected db.AssignedBlock) {
+	t.Helper()
+	if block.Epoch != expected.Epoch || block.No != expected.No ||
+		block.EpochSlot != expected.EpochSlot || block.Slot != expected.Slot ||
+		!block.Timestamp.Equal(expected.Timestamp) ||
+		block.Status != expected.Status {
+		t.Errorf("the block must be %+v, but was %+v", expected, block)
+	}
+}
+
+func testPing(t *testing.T, idb db.DB, clk *clock.Fake) {
+	err := idb.Ping(context.Background())
+	if err != nil {
+		t.Fatalf("pinging the database failed: %s", err.Error())
+	}
+}
+
+func testRegisteredEpochs(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	now := clk.Now()
+	for _, epoch := range []uint{301, 303, 302} {
+		writeLeaderLog(t, idb, leaderLog(poolA, epoch,
+			assignedBlock(epoch, 1, 100, now)))
+	}
+	writeLeaderLog(t, idb, leaderLog(poolB, 304, assignedBlock(304, 1, 100, now)))
+
+	epochs, err := idb.GetRegisteredEpochs(ctx, poolA, db.OrderingAsc, 10)
+	if err != nil {
+		t.Fatalf("querying the registered epochs failed: %s", err.Error())
+	}
+	checkUints(t, "the ascending epochs", epochs, 301, 302, 303)
+	epochs, err = idb.GetRegisteredEpochs(ctx, poolA, db.OrderingDesc, 2)
+	if err != nil {
+		t.Fatalf("querying the registered epochs failed: %s", err.Error())
+	}
+	checkUints(t, "the limited descending epochs", epochs, 303, 302)
+	epochs, err = idb.GetRegisteredEpochs(ctx, "unknown", db.OrderingAsc, 10)
+	if err != nil {
+		t.Fatalf("querying the registered epochs failed: %s", err.Error())
+	}
+	checkUints(t, "the epochs of an unknown pool", epochs)
+}
+
+func testGetLeaderLog(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	now := clk.Now()
+	expected := leaderLog(poolA, 310,
+		assignedBlock(310, 1, 100, now.Add(-time.Hour)),
+		assignedBlock(310, 3, 300, now.Add(time.Hour)),
+		assignedBlock(310, 2, 200, now),
+	)
+	diff := writeLeaderLog(t, idb, expected)
+	if diff.Replaced || diff.PoolID != poolA || diff.Epoch != 310 {
+		t.Errorf("the diff of a new leader log is wrong: %+v", diff)
+	}
+	checkUints(t, "the added slots", diff.Added, expected.Blocks[0].Slot,
+		expected.Blocks[2].Slot, expected.Blocks[1].Slot)
+	checkUints(t, "the removed slots", diff.Removed)
+	checkUints(t, "the kept slots", diff.Kept)
+
+	registered, err := idb.GetLeaderLog(ctx, poolA, 310)
+	if err != nil {
+		t.Fatalf("querying the leader log failed: %s", err.Error())
+	}
+	if registered == nil {
+		t.Fatalf("the leader log must be found")
+	}
+	if registered.PoolID != poolA || registered.Epoch != 310 ||
+		registered.ExpectedBlockNumber != expected.ExpectedBlockNumber ||
+		registered.MaxPerformance != expected.MaxPerformance {
+		t.Errorf("the leader log must be %+v, but was %+v", expected, registered)
+	}
+	if len(registered.Blocks) != 3 {
+		t.Fatalf("the leader log must have 3 blocks, but had %d",
+			len(registered.Blocks))
+	}
+	checkBlock(t, registered.Blocks[0], expected.Blocks[0])
+	checkBlock(t, registered.Blocks[1], expected.Blocks[2])
+	checkBlock(t, registered.Blocks[2], expected.Blocks[1])
+
+	for _, ref := range []db.LeaderLogRef{
+		{PoolID: poolA, Epoch: 311},
+		{PoolID: poolB, Epoch: 310},
+	} {
+		registered, err = idb.GetLeaderLog(ctx, ref.PoolID, ref.Epoch)
+		if err != nil {
+			t.Fatalf("querying the leader log failed: %s", err.Error())
+		}
+		if registered != nil {
+			t.Errorf("no leader log must be found for %+v", ref)
+		}
+	}
+}
+
+func testPerformance(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	now := clk.Now()
+	minted := assignedBlock(320, 1, 100, now.Add(-3*time.Hour))
+	ghosted := assignedBlock(320, 2, 200, now.Add(-2*time.Hour))
+	battle := assignedBlock(321, 1, 100, now.Add(-time.Hour))
+	writeLeaderLog(t, idb, leaderLog(poolA, 320, minted, ghosted,
+		assignedBlock(320, 3, 300, now.Add(time.Hour))))
+	writeLeaderLog(t, idb, leaderLog(poolA, 321, battle))
+	writeLeaderLog(t, idb, leaderLog(poolA, 322))
+	writeLeaderLog(t, idb, leaderLog(poolA, 323, assignedBlock(323, 1, 100,
+		now.Add(2*time.Hour))))
+	writeLeaderLog(t, idb, leaderLog(poolB, 321, assignedBlock(321, 1, 100,
+		now.Add(-time.Hour))))
+	writeStatus(t, idb, poolA, minted, db.Minted,
+		mintedBlock(minted, poolA, "a", 10))
+	writeStatus(t, idb, poolA, ghosted, db.GHOSTED, nil)
+	writeStatus(t, idb, poolA, battle, db.HeightBattle,
+		mintedBlock(battle, poolB, "b", 11))
+
+	performances, err := idb.GetPerformance(ctx, poolA, 320, 322)
+	if err != nil {
+		t.Fatalf("querying the performance failed: %s", err.Error())
+	}
+	expected := []db.EpochPerformance{
+		{Epoch: 320, ExpectedBlockNumber: 14.25, AssignedBlocks: 3,
+			StatusCounts: map[db.BlockStatus]uint{db.NotMinted: 1,
+				db.Minted: 1, db.GHOSTED: 1}},
+		{Epoch: 321, ExpectedBlockNumber: 14.25, AssignedBlocks: 1,
+			StatusCounts: map[db.BlockStatus]uint{db.HeightBattle: 1}},
+		{Epoch: 322, ExpectedBlockNumber: 14.25},
+	}
+	if len(performances) != len(expected) {
+		t.Fatalf("the performance of %d epochs must be returned, but was %+v",
+			len(expected), performances)
+	}
+	for i, performance := range performances {
+		if performance.Epoch != expected[i].Epoch ||
+			performance.ExpectedBlockNumber != expected[i].ExpectedBlockNumber ||
+			performance.AssignedBlocks != expected[i].AssignedBlocks {
+			t.Errorf("the performance must be %+v, but was %+v", expected[i],
+				performance)
+		}
+		for status := db.NotMinted; status <= db.GHOSTED; status++ {
+			if performance.StatusCounts[status] != expected[i].StatusCounts[status] {
+				t.Errorf("the performance of epoch %d must count %d blocks with status %d, but counted %d",
+					performance.Epoch, expected[i].StatusCounts[status], status,
+					performance.StatusCounts[status])
+			}
+		}
+	}
+
+	performances, err = idb.GetPerformance(ctx, poolB, 322, 330)
+	if err != nil {
+		t.Fatalf("querying the performance failed: %s", err.Error())
+	}
+	if len(performances) != 0 {
+		t.Errorf("no performance must be returned, but was %+v", performances)
+	}
+}
+
+func testReplaceLeaderLog(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	past := clk.Now().Add(-time.Hour)
+	kept := assignedBlock(320, 1, 100, past)
+	removed := assignedBlock(320, 2, 200, past)
+	writeLeaderLog(t, idb, leaderLog(poolA, 320, kept, removed))
+	writeStatus(t, idb, poolA, kept, db.Minted,
+		mintedBlock(kept, poolA, "kept", 1000))
+	writeStatus(t, idb, poolA, removed, db.HeightBattle,
+		mintedBlock(removed, poolB, "removed", 1001))
+
+	added := assignedBlock(320, 1, 50, past)
+	keptAgain := kept
+	keptAgain.No = 2
+	replacement := leaderLog(poolA, 320, added, keptAgain)
+	replacement.ExpectedBlockNumber = 10
+	diff := writeLeaderLog(t, idb, replacement)
+	if !diff.Replaced || diff.PoolID != poolA || diff.Epoch != 320 {
+		t.Errorf("the diff of a replaced leader log is wrong: %+v", diff)
+	}
+	checkUints(t, "the added slots", diff.Added, added.Slot)
+	checkUints(t, "the removed slots", diff.Removed, removed.Slot)
+	checkUints(t, "the kept slots", diff.Kept, kept.Slot)
+
+	registered, err := idb.GetLeaderLog(ctx, poolA, 320)
+	if err != nil {
+		t.Fatalf("querying the leader log failed: %s", err.Error())
+	}
+	if registered == nil || registered.ExpectedBlockNumber != 10 {
+		t.Fatalf("the leader log must have been replaced, but was %+v",
+			registered)
+	}
+	blocks, err := idb.GetAssignedBlocksBeforeNow(ctx, poolA, 320)
+	if err != nil {
+		t.Fatalf("querying the blocks before now failed: %s", err.Error())
+	}
+	checkSlots(t, "the blocks before now", blocks, added.Slot, kept.Slot)
+	if len(blocks) != 2 {
+		return
+	}
+	if blocks[0].Status != db.NotMinted || blocks[0].RelevantBlock != nil {
+		t.Errorf("the added block must not be classified, but was %+v",
+			blocks[0])
+	}
+	if blocks[1].No != 2 || blocks[1].Status != db.Minted ||
+		blocks[1].RelevantBlock == nil ||
+		blocks[1].RelevantBlock.Hash != "kept" {
+		t.Errorf("the status of the kept block must be preserved, but was %+v",
+			blocks[1])
+	}
+}
+
+func testDeleteLeaderLog(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	past := clk.Now().Add(-time.Hour)
+	block := assignedBlock(330, 1, 100, past)
+	writeLeaderLog(t, idb, leaderLog(poolA, 330, block))
+	writeLeaderLog(t, idb, leaderLog(poolB, 330, block))
+	err := idb.WriteRetryEntry(ctx, poolA, &db.RetryEntry{
+		Block:       block,
+		Attempts:    1,
+		NextAttempt: clk.Now().Add(time.Minute),
+		LastError:   "unavailable",
+	})
+	if err != nil {
+		t.Fatalf("queueing a retry failed: %s", err.Error())
+	}
+
+	found, err := idb.DeleteLeaderLog(ctx, poolA, 330)
+	if err != nil {
+		t.Fatalf("deleting the leader log failed: %s", err.Error())
+	}
+	if !found {
+		t.Errorf("the deleted leader log must have been found")
+	}
+	registered, err := idb.GetLeaderLog(ctx, poolA, 330)
+	if err != nil {
+		t.Fatalf("querying the leader log failed: %s", err.Error())
+	}
+	if registered != nil {
+		t.Errorf("the leader log must have been deleted")
+	}
+	entries, err := idb.GetRetryEntries(ctx, poolA)
+	if err != nil {
+		t.Fatalf("querying the queued retries failed: %s", err.Error())
+	}
+	if len(entries) != 0 {
+		t.Errorf("the queued retries must have been deleted, but were %+v",
+			entries)
+	}
+	registered, err = idb.GetLeaderLog(ctx, poolB, 330)
+	if err != nil {
+		t.Fatalf("querying the leader log failed: %s", err.Error())
+	}
+	if registered == nil || len(registered.Blocks) != 1 {
+		t.Errorf("the leader log of another pool must not be deleted")
+	}
+
+	found, err = idb.DeleteLeaderLog(ctx, poolA, 330)
+	if err != nil {
+		t.Fatalf("deleting the leader log failed: %s", err.Error())
+	}
+	if found {
+		t.Errorf("a leader log mustn't be found after its deletion")
+	}
+}
+
+func testAssignedBlocksAroundNow(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	now := clk.Now()
+	before1 := assignedBlock(340, 1, 100, now.Add(-2*time.Hour))
+	before2 := assignedBlock(340, 2, 200, now.Add(-time.Hour))
+	after1 := assignedBlock(340, 3, 300, now.Add(time.Hour))
+	after2 := assignedBlock(341, 1, 100, now.Add(2*time.Hour))
+	writeLeaderLog(t, idb, leaderLog(poolA, 340, after1, before2, before1))
+	writeLeaderLog(t, idb, leaderLog(poolA, 341, after2))
+	writeLeaderLog(t, idb, leaderLog(poolB, 340,
+		assignedBlock(340, 1, 150, now.Add(-90*time.Minute)),
+		assignedBlock(340, 2, 250, now.Add(90*time.Minute))))
+
+	blocks, err := idb.GetAssignedBlocksBeforeNow(ctx, poolA, 340)
+	if err != nil {
+		t.Fatalf("querying the blocks before now failed: %s", err.Error())
+	}
+	checkSlots(t, "the blocks before now", blocks, before1.Slot, before2.Slot)
+	if len(blocks) == 2 {
+		checkBlock(t, blocks[0], before1)
+	}
+	blocks, err = idb.GetAssignedBlocksBeforeNow(ctx, poolA, 341)
+	if err != nil {
+		t.Fatalf("querying the blocks before now failed: %s", err.Error())
+	}
+	checkSlots(t, "the blocks before now of the next epoch", blocks)
+	blocks, err = idb.GetAssignedBlocksAfterNow(ctx, poolA)
+	if err != nil {
+		t.Fatalf("querying the blocks after now failed: %s", err.Error())
+	}
+	checkSlots(t, "the blocks after now", blocks, after1.Slot, after2.Slot)
+	if len(blocks) == 2 {
+		checkBlock(t, blocks[1], after2)
+	}
+}
+
+func testAdvancingClock(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	block := assignedBlock(345, 1, 100, clk.Now().Add(time.Hour))
+	writeLeaderLog(t, idb, leaderLog(poolA, 345, block))
+
+	clk.Advance(time.Hour - time.Second)
+	blocks, err := idb.GetAssignedBlocksAfterNow(ctx, poolA)
+	if err != nil {
+		t.Fatalf("querying the blocks after now failed: %s", err.Error())
+	}
+	checkSlots(t, "the blocks after now", blocks, block.Slot)
+	clk.Advance(time.Second)
+	blocks, err = idb.GetAssignedBlocksAfterNow(ctx, poolA)
+	if err != nil {
+		t.Fatalf("querying the blocks after now failed: %s", err.Error())
+	}
+	checkSlots(t, "the blocks after now", blocks)
+	blocks, err = idb.GetAssignedBlocksBeforeNow(ctx, poolA, 345)
+	if err != nil {
+		t.Fatalf("querying the blocks before now failed: %s", err.Error())
+	}
+	checkSlots(t, "the blocks before now", blocks, block.Slot)
+	blocks, err = idb.GetAssignedBlocksWithStatusBeforeNow(ctx, poolA,
+		db.NotMinted, 0, 10)
+	if err != nil {
+		t.Fatalf("querying the not minted blocks failed: %s", err.Error())
+	}
+	checkSlots(t, "the not minted blocks", blocks, block.Slot)
+}
+
+func testAssignedBlocksWithStatus(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	now := clk.Now()
+	blocks := make([]db.AssignedBlock, 0)
+	for no := uint(1); no <= 5; no++ {
+		blocks = append(blocks, assignedBlock(350, no, no*100,
+			now.Add(-time.Duration(6-no)*time.Hour)))
+	}
+	future := assignedBlock(350, 6, 600, now.Add(time.Hour))
+	writeLeaderLog(t, idb, leaderLog(poolA, 350, append(blocks, future)...))
+	writeLeaderLog(t, idb, leaderLog(poolB, 350, blocks...))
+	writeStatus(t, idb, poolA, blocks[1], db.Minted,
+		mintedBlock(blocks[1], poolA, "minted", 2000))
+
+	notMinted, err := idb.GetAssignedBlocksWithStatusBeforeNow(ctx, poolA,
+		db.NotMinted, 0, 10)
+	if err != nil {
+		t.Fatalf("querying the not minted blocks failed: %s", err.Error())
+	}
+	checkSlots(t, "the not minted blocks", notMinted, blocks[0].Slot,
+		blocks[2].Slot, blocks[3].Slot, blocks[4].Slot)
+	page, err := idb.GetAssignedBlocksWithStatusBeforeNow(ctx, poolA,
+		db.NotMinted, 1, 2)
+	if err != nil {
+		t.Fatalf("querying a page of not minted blocks failed: %s", err.Error())
+	}
+	checkSlots(t, "the page of not minted blocks", page, blocks[2].Slot,
+		blocks[3].Slot)
+	page, err = idb.GetAssignedBlocksWithStatusBeforeNow(ctx, poolA,
+		db.NotMinted, 4, 2)
+	if err != nil {
+		t.Fatalf("querying a page of not minted blocks failed: %s", err.Error())
+	}
+	checkSlots(t, "the page after the last not minted block", page)
+	minted, err := idb.GetAssignedBlocksWithStatusBeforeNow(ctx, poolA,
+		db.Minted, 0, 10)
+	if err != nil {
+		t.Fatalf("querying the minted blocks failed: %s", err.Error())
+	}
+	checkSlots(t, "the minted blocks", minted, blocks[1].Slot)
+}
+
+func testUpdateStatusForAssignment(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	past := clk.Now().Add(-time.Hour)
+	block := assignedBlock(360, 1, 100, past)
+	other := assignedBlock(360, 2, 200, past)
+	writeLeaderLog(t, idb, leaderLog(poolA, 360, block, other))
+	err := idb.WriteRetryEntry(ctx, poolA, &db.RetryEntry{
+		Block:       block,
+		Attempts:    2,
+		NextAttempt: clk.Now().Add(time.Minute),
+		LastError:   "unavailable",
+	})
+	if err != nil {
+		t.Fatalf("queueing a retry failed: %s", err.Error())
+	}
+	writeStatus(t, idb, poolA, block, db.Minted,
+		mintedBlock(block, poolA, "first", 3000))
+	writeStatus(t, idb, poolA, other, db.GHOSTED, nil)
+
+	blocks, err := idb.GetAssignedBlocksBeforeNow(ctx, poolA, 360)
+	if err != nil {
+		t.Fatalf("querying the blocks before now failed: %s", err.Error())
+	}
+	checkSlots(t, "the blocks before now", blocks, block.Slot, other.Slot)
+	if len(blocks) != 2 {
+		return
+	}
+	relevant := blocks[0].RelevantBlock
+	if blocks[0].Status != db.Minted || relevant == nil ||
+		relevant.ID == nil || relevant.Hash != "first" ||
+		relevant.Height != 3000 || relevant.Epoch != 360 ||
+		relevant.PoolID != poolA {
+		t.Errorf("the block must be minted with the written block, but was %+v",
+			blocks[0])
+	}
+	if blocks[1].Status != db.GHOSTED || blocks[1].RelevantBlock != nil {
+		t.Errorf("the block must be ghosted without minted block, but was %+v",
+			blocks[1])
+	}
+	entry, err := idb.GetRetryEntry(ctx, poolA, 360, 1)
+	if err != nil {
+		t.Fatalf("querying the queued retry failed: %s", err.Error())
+	}
+	if entry != nil {
+		t.Errorf("the queued retry must be resolved by the update")
+	}
+
+	competing := mintedBlock(block, poolB, "second", 3001)
+	competing.PoolTicker = "BBB"
+	competing.PoolName = "Pool B"
+	writeStatus(t, idb, poolA, block, db.HeightBattle, competing)
+	blocks, err = idb.GetAssignedBlocksBeforeNow(ctx, poolA, 360)
+	if err != nil {
+		t.Fatalf("querying the blocks before now failed: %s", err.Error())
+	}
+	if len(blocks) != 2 || blocks[0].Status != db.HeightBattle ||
+		blocks[0].RelevantBlock == nil ||
+		blocks[0].RelevantBlock.Hash != "second" ||
+		blocks[0].RelevantBlock.PoolID != poolB ||
+		blocks[0].RelevantBlock.PoolTicker != "BBB" ||
+		blocks[0].RelevantBlock.PoolName != "Pool B" {
+		t.Errorf("the block must have been reclassified, but was %+v", blocks)
+	}
+}
+
+// checkTransitions fails the test, if the given status transitions don't
+// match the expected ones in the given order. The IDs of the transitions are
+// only checked to be increasing.
+func checkTransitions(t *testing.T, what string,
+	transitions []db.StatusTransition, expected ...db.StatusTransition) {
+
+	t.Helper()
+	if transitions == nil {
+		t.Errorf("%s must be an empty list instead of nil", what)
+	}
+	if len(transitions) != len(expected) {
+		t.Errorf("%s must be %+v, but was %+v", what, expected, transitions)
+		return
+	}
+	for i, transition := range transitions {
+		if !transition.Timestamp.Equal(expected[i].Timestamp) {
+			t.Errorf("%s must be %+v, but was %+v", what, expected, transitions)
+			return
+		}
+		if transition.ID == 0 || (i > 0 && transition.ID <= transitions[i-1].ID) {
+			t.Errorf("the IDs of %s must be increasing, but were %+v", what,
+				transitions)
+			return
+		}
+		transition.ID = expected[i].ID
+		transition.Timestamp = expected[i].Timestamp
+		if transition != expected[i] {
+			t.Errorf("%s must be %+v, but was %+v", what, expected, transitions)
+			return
+		}
+	}
+}
+
+func testStatusTransitions(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	past := clk.Now().Add(-time.Hour)
+	block := assignedBlock(365, 1, 100, past)
+	other := assignedBlock(365, 2, 200, past)
+	writeLeaderLog(t, idb, leaderLog(poolA, 365, block, other))
+	writeLeaderLog(t, idb, leaderLog(poolB, 365, block))
+
+	classifiedAt := clk.Now().Truncate(time.Second)
+	writeStatus(t, idb, poolA, block, db.Minted,
+		mintedBlock(block, poolA, "first", 5000))
+	writeStatus(t, idb, poolA, other, db.GHOSTED, nil)
+	writeStatus(t, idb, poolB, block, db.DoubleAssignment,
+		mintedBlock(block, poolA, "first-b", 5000))
+	clk.Advance(10 * time.Minute)
+	reclassifiedAt := clk.Now().Truncate(time.Second)
+	writeStatus(t, idb, poolA, block, db.HeightBattle,
+		mintedBlock(block, poolB, "second", 5001))
+	// an unchanged status isn't a transition.
+	writeStatus(t, idb, poolA, other, db.GHOSTED, nil)
+
+	transitions, err := idb.GetStatusTransitions(ctx, poolA, 365)
+	if err != nil {
+		t.Fatalf("querying the status transitions failed: %s", err.Error())
+	}
+	minted := db.StatusTransition{PoolID: poolA, Epoch: 365, No: 1,
+		Slot: block.Slot, From: db.NotMinted, To: db.Minted, ToHash: "first",
+		Timestamp: classifiedAt}
+	ghosted := db.StatusTransition{PoolID: poolA, Epoch: 365, No: 2,
+		Slot: other.Slot, From: db.NotMinted, To: db.GHOSTED,
+		Timestamp: classifiedAt}
+	lost := db.StatusTransition{PoolID: poolA, Epoch: 365, No: 1,
+		Slot: block.Slot, From: db.Minted, To: db.HeightBattle,
+		FromHash: "first", ToHash: "second", Timestamp: reclassifiedAt}
+	checkTransitions(t, "the status transitions", transitions, minted,
+		ghosted, lost)
+	transitions, err = idb.GetStatusTransitions(ctx, poolA, 366)
+	if err != nil {
+		t.Fatalf("querying the status transitions failed: %s", err.Error())
+	}
+	checkTransitions(t, "the status transitions of another epoch",
+		transitions)
+
+	// the history of a slot, which is removed from the leader log, is
+	// removed as well.
+	writeLeaderLog(t, idb, leaderLog(poolA, 365, block))
+	transitions, err = idb.GetStatusTransitions(ctx, poolA, 365)
+	if err != nil {
+		t.Fatalf("querying the status transitions failed: %s", err.Error())
+	}
+	checkTransitions(t, "the status transitions after the replacement",
+		transitions, minted, lost)
+	_, err = idb.DeleteLeaderLog(ctx, poolA, 365)
+	if err != nil {
+		t.Fatalf("deleting the leader log failed: %s", err.Error())
+	}
+	transitions, err = idb.GetStatusTransitions(ctx, poolA, 365)
+	if err != nil {
+		t.Fatalf("querying the status transitions failed: %s", err.Error())
+	}
+	checkTransitions(t, "the status transitions after the deletion",
+		transitions)
+	transitions, err = idb.GetStatusTransitions(ctx, poolB, 365)
+	if err != nil {
+		t.Fatalf("querying the status transitions failed: %s", err.Error())
+	}
+	checkTransitions(t, "the status transitions of another pool",
+		transitions, db.StatusTransition{PoolID: poolB, Epoch: 365, No: 1,
+			Slot: block.Slot, From: db.NotMinted, To: db.DoubleAssignment,
+			ToHash: "first-b", Timestamp: classifiedAt})
+}
+
+func testClassifiedBlocksWithinWindow(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	past := clk.Now().Add(-time.Hour)
+	oldMinted := assignedBlock(370, 1, 100, past)
+	newMinted := assignedBlock(370, 2, 200, past)
+	oldGhosted := assignedBlock(370, 3, 300, past)
+	newGhosted := assignedBlock(370, 4, 400, past)
+	unclassified := assignedBlock(370, 5, 500, past)
+	writeLeaderLog(t, idb, leaderLog(poolA, 370, oldMinted, newMinted,
+		oldGhosted, newGhosted, unclassified))
+	writeStatus(t, idb, poolA, oldMinted, db.Minted,
+		mintedBlock(oldMinted, poolA, "old", 4000))
+	writeStatus(t, idb, poolA, newMinted, db.DoubleAssignment,
+		mintedBlock(newMinted, poolB, "new", 4010))
+	writeStatus(t, idb, poolA, oldGhosted, db.GHOSTED, nil)
+	writeStatus(t, idb, poolA, newGhosted, db.GHOSTED, nil)
+
+	blocks, err := idb.GetClassifiedBlocksWithinWindow(ctx, poolA, 4005,
+		newGhosted.Slot)
+	if err != nil {
+		t.Fatalf("querying the classified blocks failed: %s", err.Error())
+	}
+	checkSlots(t, "the classified blocks within the window", blocks,
+		newMinted.Slot, newGhosted.Slot)
+	if len(blocks) == 2 && (blocks[0].RelevantBlock == nil ||
+		blocks[0].RelevantBlock.Hash != "new") {
+		t.Errorf("the minted block must be linked, but was %+v", blocks[0])
+	}
+	blocks, err = idb.GetClassifiedBlocksWithinWindow(ctx, poolB, 0, 0)
+	if err != nil {
+		t.Fatalf("querying the classified blocks failed: %s", err.Error())
+	}
+	checkSlots(t, "the classified blocks of another pool", blocks)
+}
+
+func testRetryEntries(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	now := clk.Now().Truncate(time.Second)
+	first := assignedBlock(380, 1, 100, now.Add(-2*time.Hour))
+	second := assignedBlock(380, 2, 200, now.Add(-time.Hour))
+	writeLeaderLog(t, idb, leaderLog(poolA, 380, first, second))
+	writeLeaderLog(t, idb, leaderLog(poolB, 380, first))
+	entries := []*db.RetryEntry{
+		{Block: first, Attempts: 1, NextAttempt: now.Add(2 * time.Minute),
+			LastError: "first"},
+		{Block: second, Attempts: 1, NextAttempt: now.Add(time.Minute),
+			LastError: "second"},
+	}
+	for _, entry := range entries {
+		err := idb.WriteRetryEntry(ctx, poolA, entry)
+		if err != nil {
+			t.Fatalf("queueing a retry failed: %s", err.Error())
+		}
+	}
+
+	queued, err := idb.GetRetryEntries(ctx, poolA)
+	if err != nil {
+		t.Fatalf("querying the queued retries failed: %s", err.Error())
+	}
+	if len(queued) != 2 {
+		t.Fatalf("two retries must be queued, but were %+v", queued)
+	}
+	checkBlock(t, queued[0].Block, second)
+	if queued[0].Attempts != 1 || !queued[0].NextAttempt.Equal(entries[1].NextAttempt) ||
+		queued[0].LastError != "second" {
+		t.Errorf("the queued retry must be %+v, but was %+v", entries[1],
+			queued[0])
+	}
+	queued, err = idb.GetRetryEntries(ctx, poolB)
+	if err != nil {
+		t.Fatalf("querying the queued retries failed: %s", err.Error())
+	}
+	if len(queued) != 0 {
+		t.Errorf("no retries must be queued for another pool, but were %+v",
+			queued)
+	}
+
+	err = idb.WriteRetryEntry(ctx, poolA, &db.RetryEntry{
+		Block:     first,
+		Attempts:  2,
+		LastError: "given up",
+	})
+	if err != nil {
+		t.Fatalf("queueing a retry failed: %s", err.Error())
+	}
+	entry, err := idb.GetRetryEntry(ctx, poolA, 380, 1)
+	if err != nil {
+		t.Fatalf("querying the queued retry failed: %s", err.Error())
+	}
+	if entry == nil || entry.Attempts != 2 || !entry.NextAttempt.IsZero() ||
+		entry.LastError != "given up" {
+		t.Errorf("the queued retry must have been overwritten, but was %+v",
+			entry)
+	}
+	entry, err = idb.GetRetryEntry(ctx, poolA, 380, 3)
+	if err != nil {
+		t.Fatalf("querying the queued retry failed: %s", err.Error())
+	}
+	if entry != nil {
+		t.Errorf("no retry must be queued for an unknown block, but was %+v",
+			entry)
+	}
+}
+
+func testInvalidWrites(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	past := clk.Now().Add(-time.Hour)
+	block := assignedBlock(385, 1, 100, past)
+	writeLeaderLog(t, idb, leaderLog(poolA, 385, block))
+
+	_, err := idb.WriteLeaderLog(ctx, leaderLog(poolA, 385,
+		assignedBlock(385, 1, 200, past), assignedBlock(385, 1, 300, past)))
+	if err == nil {
+		t.Errorf("writing a leader log with duplicated numbers must fail")
+	}
+	registered, err := idb.GetLeaderLog(ctx, poolA, 385)
+	if err != nil {
+		t.Fatalf("querying the leader log failed: %s", err.Error())
+	}
+	if registered == nil {
+		t.Fatalf("the leader log must be found")
+	}
+	checkSlots(t, "the blocks of the unchanged leader log", registered.Blocks,
+		block.Slot)
+
+	err = idb.UpdateStatusForAssignment(ctx, poolA, 385, 2, db.Minted, nil)
+	if err == nil {
+		t.Errorf("updating the status of an unknown block must fail")
+	}
+	err = idb.UpdateStatusForAssignment(ctx, poolA, 385, 2, db.Minted,
+		mintedBlock(assignedBlock(385, 2, 200, past), poolA, "unknown", 5000))
+	if err == nil {
+		t.Errorf("linking a minted block to an unknown block must fail")
+	}
+	err = idb.WriteRetryEntry(ctx, poolA, &db.RetryEntry{
+		Block:    assignedBlock(385, 2, 200, past),
+		Attempts: 1,
+	})
+	if err != nil {
+		t.Fatalf("queueing a retry failed: %s", err.Error())
+	}
+	entries, err := idb.GetRetryEntries(ctx, poolA)
+	if err != nil {
+		t.Fatalf("querying the queued retries failed: %s", err.Error())
+	}
+	if len(entries) != 0 {
+		t.Errorf("no retry must be queued for an unknown block, but was %+v",
+			entries)
+	}
+}
+
+func testStakePools(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	pool, err := idb.GetStakePool(ctx, poolA)
+	if err != nil {
+		t.Fatalf("querying the stake pool failed: %s", err.Error())
+	}
+	if pool != nil {
+		t.Errorf("no stake pool must have been written, but was %+v", pool)
+	}
+
+	written := &db.StakePool{
+		HexID:     poolA,
+		Ticker:    "AAA",
+		Name:      "Pool A",
+		UpdatedAt: clk.Now().Truncate(time.Second),
+	}
+	for _, ticker := range []string{"OLD", "AAA"} {
+		written.Ticker = ticker
+		err = idb.WriteStakePool(ctx, written)
+		if err != nil {
+			t.Fatalf("writing the stake pool failed: %s", err.Error())
+		}
+	}
+	pool, err = idb.GetStakePool(ctx, poolA)
+	if err != nil {
+		t.Fatalf("querying the stake pool failed: %s", err.Error())
+	}
+	if pool == nil || pool.HexID != written.HexID || pool.Ticker != "AAA" ||
+		pool.Name != written.Name || !pool.UpdatedAt.Equal(written.UpdatedAt) {
+		t.Errorf("the stake pool must be %+v, but was %+v", written, pool)
+	}
+	pool, err = idb.GetStakePool(ctx, poolB)
+	if err != nil {
+		t.Fatalf("querying the stake pool failed: %s", err.Error())
+	}
+	if pool != nil {
+		t.Errorf("no stake pool must have been written for another pool, but was %+v",
+			pool)
+	}
+}
+
+func testEvents(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	past := clk.Now().Add(-time.Hour)
+	block := assignedBlock(400, 1, 100, past)
+	writeLeaderLog(t, idb, leaderLog(poolA, 400, block))
+	writeLeaderLog(t, idb, leaderLog(poolB, 400, block))
+	clk.Advance(time.Minute)
+	writeStatus(t, idb, poolA, block, db.Minted,
+		mintedBlock(block, poolA, "first", 6000))
+	writeStatus(t, idb, poolA, block, db.Minted,
+		mintedBlock(block, poolA, "first", 6000))
+	clk.Advance(time.Minute)
+	_, err := idb.DeleteLeaderLog(ctx, poolA, 400)
+	if err != nil {
+		t.Fatalf("deleting the leader log failed: %s", err.Error())
+	}
+
+	events, err := idb.GetEventsAfter(ctx, poolA, 0, 10)
+	if err != nil {
+		t.Fatalf("querying the events failed: %s", err.Error())
+	}
+	expected := []db.Event{
+		{PoolID: poolA, Type: db.EventEpochRegistered, Epoch: 400,
+			Timestamp: startTime},
+		{PoolID: poolA, Type: db.EventBlockStatus, Epoch: 400, No: 1,
+			Status: db.Minted, Timestamp: startTime.Add(time.Minute)},
+		{PoolID: poolA, Type: db.EventEpochDeleted, Epoch: 400,
+			Timestamp: startTime.Add(2 * time.Minute)},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("the events must be %+v, but were %+v", expected, events)
+	}
+	for i := range expected {
+		expected[i].ID = events[i].ID
+		if eventWithoutTime(events[i]) != eventWithoutTime(expected[i]) ||
+			!events[i].Timestamp.Equal(expected[i].Timestamp) {
+			t.Errorf("the event must be %+v, but was %+v", expected[i],
+				events[i])
+		}
+		if i > 0 && events[i].ID <= events[i-1].ID {
+			t.Errorf("the ID of the event must be greater than %d, but was %d",
+				events[i-1].ID, events[i].ID)
+		}
+	}
+
+	lastID, err := idb.GetLastEventID(ctx, poolA)
+	if err != nil {
+		t.Fatalf("querying the last event failed: %s", err.Error())
+	}
+	if lastID != events[2].ID {
+		t.Errorf("the ID of the last event must be %d, but was %d",
+			events[2].ID, lastID)
+	}
+	events, err = idb.GetEventsAfter(ctx, poolA, events[0].ID, 1)
+	if err != nil {
+		t.Fatalf("querying the events failed: %s", err.Error())
+	}
+	if len(events) != 1 || events[0].Type != db.EventBlockStatus {
+		t.Errorf("only the block event must be returned, but was %+v", events)
+	}
+	events, err = idb.GetEventsAfter(ctx, poolA, lastID, 10)
+	if err != nil {
+		t.Fatalf("querying the events failed: %s", err.Error())
+	}
+	if len(events) != 0 {
+		t.Errorf("no events must be returned, but was %+v", events)
+	}
+	lastID, err = idb.GetLastEventID(ctx, "unknown")
+	if err != nil {
+		t.Fatalf("querying the last event failed: %s", err.Error())
+	}
+	if lastID != 0 {
+		t.Errorf("no event must have been recorded for an unknown pool, but was %d",
+			lastID)
+	}
+}
+
+// eventWithoutTime returns the given event without its timestamp, such that
+// it can be compared regardless of the location of the timestamp.
+func eventWithoutTime(event db.Event) db.Event {
+	event.Timestamp = time.Time{}
+	return event
+}
+
+// claimDeliveries claims the due webhook deliveries with a lease of a minute,
+// and fails the test, if the claiming fails.
+func claimDeliveries(t *testing.T, idb db.DB) []db.WebhookDelivery {
+	t.Helper()
+	deliveries, err := idb.ClaimWebhookDeliveries(context.Background(),
+		time.Minute, 10)
+	if err != nil {
+		t.Fatalf("claiming the webhook deliveries failed: %s", err.Error())
+	}
+	return deliveries
+}
+
+func testWebhookDeliveries(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	first := &db.WebhookDelivery{URL: "http://localhost/a", PoolID: poolA,
+		Epoch: 410, No: 1, Status: db.Minted, TransitionID: 7,
+		Payload: `{"no":1}`}
+	second := &db.WebhookDelivery{URL: "http://localhost/b", PoolID: poolA,
+		Epoch: 410, No: 1, Status: db.Minted, TransitionID: 7,
+		Payload: `{"no":1}`}
+	for i, delivery := range []*db.WebhookDelivery{first, second, first} {
+		queued, err := idb.QueueWebhookDelivery(ctx, delivery)
+		if err != nil {
+			t.Fatalf("queueing the webhook delivery failed: %s", err.Error())
+		}
+		if queued != (i < 2) {
+			t.Errorf("the delivery to %s must be queued only once",
+				delivery.URL)
+		}
+	}
+
+	claimed := claimDeliveries(t, idb)
+	if len(claimed) != 2 || claimed[0].URL != first.URL ||
+		claimed[1].URL != second.URL {
+		t.Fatalf("both deliveries must be claimed, but was %+v", claimed)
+	}
+	if claimed[0].State != db.DeliveryPending || claimed[0].Attempts != 0 ||
+		claimed[0].Payload != first.Payload || claimed[0].PoolID != poolA ||
+		claimed[0].Epoch != 410 || claimed[0].No != 1 ||
+		claimed[0].Status != db.Minted || claimed[0].TransitionID != 7 ||
+		!claimed[0].CreatedAt.Equal(startTime) {
+		t.Errorf("the claimed delivery must be queued as %+v, but was %+v",
+			first, claimed[0])
+	}
+	if leased := claimDeliveries(t, idb); len(leased) != 0 {
+		t.Errorf("leased deliveries mustn't be claimed, but was %+v", leased)
+	}
+
+	delivered := claimed[0]
+	delivered.State = db.DeliveryDelivered
+	delivered.ResponseStatus = 200
+	retried := claimed[1]
+	retried.Attempts = 1
+	retried.NextAttempt = clk.Now().Add(5 * time.Minute)
+	retried.LastError = "receiver responded with status 503"
+	retried.ResponseStatus = 503
+	for _, delivery := range []*db.WebhookDelivery{&delivered, &retried} {
+		err := idb.UpdateWebhookDelivery(ctx, delivery)
+		if err != nil {
+			t.Fatalf("updating the webhook delivery failed: %s", err.Error())
+		}
+	}
+	clk.Advance(2 * time.Minute)
+	if due := claimDeliveries(t, idb); len(due) != 0 {
+		t.Errorf("no delivery must be due, but was %+v", due)
+	}
+	clk.Advance(5 * time.Minute)
+	due := claimDeliveries(t, idb)
+	if len(due) != 1 || due[0].ID != retried.ID || due[0].Attempts != 1 ||
+		due[0].LastError != retried.LastError ||
+		due[0].ResponseStatus != 503 {
+		t.Errorf("the retried delivery must be due, but was %+v", due)
+	}
+
+	deliveries, err := idb.GetWebhookDeliveries(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("querying the webhook deliveries failed: %s", err.Error())
+	}
+	if len(deliveries) != 2 || deliveries[0].ID != retried.ID ||
+		deliveries[1].ID != delivered.ID ||
+		deliveries[1].State != db.DeliveryDelivered ||
+		deliveries[1].ResponseStatus != 200 ||
+		!deliveries[1].UpdatedAt.Equal(startTime) {
+		t.Errorf("the deliveries must be listed from the latest one, but was %+v",
+			deliveries)
+	}
+	deliveries, err = idb.GetWebhookDeliveries(ctx, 1, 10)
+	if err != nil {
+		t.Fatalf("querying the webhook deliveries failed: %s", err.Error())
+	}
+	if len(deliveries) != 1 || deliveries[0].ID != delivered.ID {
+		t.Errorf("only the earliest delivery must be listed, but was %+v",
+			deliveries)
+	}
+
+	// a block, which is reclassified back to the same status, is notified
+	// again for the new transition.
+	again := *first
+	again.TransitionID = 9
+	queued, err := idb.QueueWebhookDelivery(ctx, &again)
+	if err != nil {
+		t.Fatalf("queueing the webhook delivery failed: %s", err.Error())
+	}
+	if !queued {
+		t.Errorf("the delivery about another transition must be queued")
+	}
+	due = claimDeliveries(t, idb)
+	if len(due) != 1 || due[0].URL != first.URL || due[0].TransitionID != 9 {
+		t.Errorf("the delivery about the new transition must be due, but was %+v",
+			due)
+	}
+}
+
+// observer subscribes to the observer of the given db.DB and returns the
+// channel on which the messages are received.
+func observer(idb db.DB) <-chan db.ObserverMessage {
+	c := make(chan db.ObserverMessage, 16)
+	idb.Observer().Sub(c)
+	return c
+}
+
+// awaitMessage waits for the next message on the given channel, and fails the
+// test, if the message hasn't the expected code or no message is received in
+// time.
+func awaitMessage(t *testing.T, c <-chan db.ObserverMessage,
+	code db.ObserverCode) db.ObserverMessage {
+
+	t.Helper()
+	select {
+	case msg := <-c:
+		if msg.Code != code {
+			t.Fatalf("the observer message must have code %d, but was %+v",
+				code, msg)
+		}
+		return msg
+	case <-time.After(observerTimeout):
+		t.Fatalf("no observer message with code %d has been received", code)
+	}
+	return db.ObserverMessage{}
+}
+
+// expectNoMessage fails the test, if a message is received on the given
+// channel within a short time.
+func expectNoMessage(t *testing.T, c <-chan db.ObserverMessage) {
+	t.Helper()
+	select {
+	case msg := <-c:
+		t.Errorf("no observer message must be received, but was %+v", msg)
+	case <-time.After(observerTimeout / 10):
+	}
+}
+
+func testObserverMessages(t *testing.T, idb db.DB, clk *clock.Fake) {
+	ctx := context.Background()
+	c := observer(idb)
+	past := clk.Now().Add(-time.Hour)
+	block := assignedBlock(390, 1, 100, past)
+
+	writeLeaderLog(t, idb, leaderLog(poolA, 390, block))
+	msg := awaitMessage(t, c, db.ObserveNewLeaderLog)
+	if ref, ok := msg.Response.(db.LeaderLogRef); !ok ||
+		ref != (db.LeaderLogRef{PoolID: poolA, Epoch: 390}) {
+		t.Errorf("the message must refer to the new leader log, but was %+v",
+			msg)
+	}
+
+	added := assignedBlock(390, 2, 200, past)
+	writeLeaderLog(t, idb, leaderLog(poolA, 390, block, added))
+	msg = awaitMessage(t, c, db.ObserveReplacedLeaderLog)
+	if diff, ok := msg.Response.(*db.LeaderLogDiff); !ok ||
+		diff.PoolID != poolA || diff.Epoch != 390 || !diff.Replaced {
+		t.Errorf("the message must describe the replacement, but was %+v", msg)
+	} else {
+		checkUints(t, "the added slots", diff.Added, added.Slot)
+		checkUints(t, "the kept slots", diff.Kept, block.Slot)
+	}
+
+	writeStatus(t, idb, poolA, block, db.Minted,
+		mintedBlock(block, poolA, "first", 5000))
+	msg = awaitMessage(t, c, db.ObserveUpdatedBlockStatus)
+	if ref, ok := msg.Response.(db.AssignmentRef); !ok ||
+		ref != (db.AssignmentRef{PoolID: poolA, Epoch: 390, No: 1}) {
+		t.Errorf("the message must refer to the updated block, but was %+v",
+			msg)
+	}
+
+	writeStatus(t, idb, poolA, block, db.HeightBattle,
+		mintedBlock(block, poolB, "second", 5000))
+	msg = awaitMessage(t, c, db.ObserveReclassifiedBlock)
+	if transition, ok := msg.Response.(*db.StatusTransition); !ok ||
+		transition.PoolID != poolA || transition.Epoch != 390 ||
+		transition.No != 1 || transition.Slot != block.Slot ||
+		transition.From != db.Minted || transition.To != db.HeightBattle ||
+		transition.FromHash != "first" || transition.ToHash != "second" {
+		t.Errorf("the message must describe the transition, but was %+v", msg)
+	}
+
+	blocks, err := idb.GetAssignedBlocksBeforeNow(ctx, poolA, 390)
+	if err != nil || len(blocks) == 0 || blocks[0].RelevantBlock == nil {
+		t.Fatalf("querying the blocks before now failed")
+	}
+	err = idb.UpdateStatusForAssignment(ctx, poolA, 390, 1, db.HeightBattle,
+		blocks[0].RelevantBlock)
+	if err != nil {
+		t.Fatalf("updating the status failed: %s", err.Error())
+	}
+	expectNoMessage(t, c)
+
+	_, err = idb.DeleteLeaderLog(ctx, poolA, 390)
+	if err != nil {
+		t.Fatalf("deleting the leader log failed: %s", err.Error())
+	}
+	msg = awaitMessage(t, c, db.ObserveDeletedLeaderLog)
+	if ref, ok := msg.Response.(db.LeaderLogRef); !ok ||
+		ref != (db.LeaderLogRef{PoolID: poolA, Epoch: 390}) {
+		t.Errorf("the message must refer to the deleted leader log, but was %+v",
+			msg)
+	}
+	_, err = idb.DeleteLeaderLog(ctx, poolA, 390)
+	if err != nil {
+		t.Fatalf("deleting the leader log failed: %s", err.Error())
+	}
+	expectNoMessage(t, c)
+
+	unsubscribed := make(chan db.ObserverMessage)
+	idb.Observer().Sub(unsubscribed)
+	idb.Observer().Unsub(unsubscribed)
+	writeLeaderLog(t, idb, leaderLog(poolA, 391, block))
+	awaitMessage(t, c, db.ObserveNewLeaderLog)
+	expectNoMessage(t, unsubscribed)
+}
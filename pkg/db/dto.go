@@ -20,6 +20,62 @@ type LeaderLog struct {
 	MaxPerformance float32
 }
 
+// EpochPerformance summarizes the outcome of the blocks assigned to a pool in
+// an epoch.
+type EpochPerformance struct {
+	// Epoch is the epoch of the leader log.
+	Epoch uint
+	// ExpectedBlockNumber is the number of blocks the pool was expected to
+	// mint in the epoch.
+	ExpectedBlockNumber float32
+	// AssignedBlocks is the number of blocks assigned to the pool in the
+	// epoch.
+	AssignedBlocks uint
+	// StatusCounts is the number of assigned blocks with each BlockStatus.
+	StatusCounts map[BlockStatus]uint
+}
+
+// LeaderLogDiff summarizes the changes to the assigned blocks of an epoch that
+// have been caused by writing a leader log. The assigned blocks are referred
+// to by their slot number.
+type LeaderLogDiff struct {
+	// PoolID is the id in hex format of the pool for which the leader log has
+	// been written.
+	PoolID string
+	// Epoch is the epoch for which the leader log has been written.
+	Epoch uint
+	// Replaced is true, if a leader log has already been registered for the
+	// epoch and was replaced.
+	Replaced bool
+	// Added is a list of slots that haven't been assigned in a previously
+	// registered leader log.
+	Added []uint
+	// Removed is a list of slots that have been assigned in the previously
+	// registered leader log, but aren't assigned anymore.
+	Removed []uint
+	// Kept is a list of slots that are assigned in the previously registered
+	// as well as the new leader log. The status of these blocks is preserved.
+	Kept []uint
+}
+
+// LeaderLogRef refers to the leader log of a pool in an epoch.
+type LeaderLogRef struct {
+	// PoolID is the id in hex format of the pool.
+	PoolID string
+	// Epoch is the epoch of the leader log.
+	Epoch uint
+}
+
+// AssignmentRef refers to a block assigned to a pool in an epoch.
+type AssignmentRef struct {
+	// PoolID is the id in hex format of the pool.
+	PoolID string
+	// Epoch is the epoch for which the block has been scheduled.
+	Epoch uint
+	// No is the unique number of the block in the leader log.
+	No uint
+}
+
 // BlockStatus refers to the status of an assigned block.
 type BlockStatus uint
 
@@ -53,6 +109,49 @@ type AssignedBlock struct {
 	RelevantBlock *MintedBlock
 }
 
+// StatusTransition is a change of the status or the relevant minted block of
+// an assigned block.
+type StatusTransition struct {
+	// ID is the unique identifier of the transition.
+	ID uint
+	// PoolID is the id in hex format of the pool to which the block has been
+	// assigned.
+	PoolID string
+	// Epoch is the epoch for which the block has been scheduled.
+	Epoch uint
+	// No is the unique number of the block in the leader log at the time of
+	// the transition.
+	No uint
+	// Slot is the slot number for which the block has been scheduled.
+	Slot uint
+	// From is the status of the block before the transition.
+	From BlockStatus
+	// To is the status of the block after the transition.
+	To BlockStatus
+	// FromHash is the hash of the relevant minted block before the transition,
+	// or empty, if no minted block was linked.
+	FromHash string
+	// ToHash is the hash of the relevant minted block after the transition, or
+	// empty, if no minted block is linked.
+	ToHash string
+	// Timestamp is the time at which the transition happened.
+	Timestamp time.Time
+}
+
+// RetryEntry is an assigned block, whose status couldn't be gathered, and which
+// is queued to be retried.
+type RetryEntry struct {
+	// Block is the assigned block whose status couldn't be gathered.
+	Block AssignedBlock
+	// Attempts is the number of failed attempts to gather the status.
+	Attempts uint
+	// NextAttempt is the time after which the next attempt shall be made. It is
+	// the zero time, if no further attempt shall be made.
+	NextAttempt time.Time
+	// LastError describes why the last attempt failed.
+	LastError string
+}
+
 // MintedBlock is a block that has been persisted on the chain.
 type MintedBlock struct {
 	// ID is an unique identifier of the minted block entry in the database.
@@ -72,4 +171,114 @@ type MintedBlock struct {
 	// PoolID is the unique identifier of the pool that minted the block. It is
 	// represented in hex format.
 	PoolID string
+	// PoolTicker is the ticker of the pool that minted the block. It is empty,
+	// if the pool has no metadata.
+	PoolTicker string
+	// PoolName is the name of the pool that minted the block. It is empty, if
+	// the pool has no metadata.
+	PoolName string
+}
+
+// StakePool is the metadata of a stake pool.
+type StakePool struct {
+	// HexID is the unique identifier of the pool in hex format.
+	HexID string
+	// Ticker is the short name of the pool.
+	Ticker string
+	// Name is the full name of the pool.
+	Name string
+	// UpdatedAt is the time at which the metadata has been fetched.
+	UpdatedAt time.Time
+}
+
+// EventType is the type of an Event.
+type EventType string
+
+const (
+	// EventEpochRegistered is the type of events for leader logs that have
+	// been registered or replaced.
+	EventEpochRegistered EventType = "epoch-registered"
+	// EventEpochDeleted is the type of events for leader logs that have been
+	// deleted.
+	EventEpochDeleted EventType = "epoch-deleted"
+	// EventBlockStatus is the type of events for assigned blocks, whose status
+	// has been updated or reclassified.
+	EventBlockStatus EventType = "block-status"
+)
+
+// Event is an entry of the event log, which records the changes to the leader
+// logs of a pool.
+type Event struct {
+	// ID is the unique identifier of the event. It is increasing in the order
+	// in which the events have been recorded.
+	ID uint
+	// PoolID is the id in hex format of the pool to which the event refers.
+	PoolID string
+	// Type is the type of the event.
+	Type EventType
+	// Epoch is the epoch to which the event refers.
+	Epoch uint
+	// No is the unique number of the assigned block to which the event refers.
+	// It is only specified for block events.
+	No uint
+	// Status is the status of the assigned block after the change. It is only
+	// specified for block events.
+	Status BlockStatus
+	// Timestamp is the time at which the event has been recorded.
+	Timestamp time.Time
+}
+
+// DeliveryState is the state of a WebhookDelivery.
+type DeliveryState string
+
+const (
+	// DeliveryPending is the state of deliveries, which haven't been delivered
+	// yet, but which are still attempted.
+	DeliveryPending DeliveryState = "pending"
+	// DeliveryDelivered is the state of deliveries, which have been accepted
+	// by the receiver.
+	DeliveryDelivered DeliveryState = "delivered"
+	// DeliveryFailed is the state of deliveries, for which no further attempt
+	// is made.
+	DeliveryFailed DeliveryState = "failed"
+)
+
+// WebhookDelivery is the notification of a webhook about the status of an
+// assigned block.
+type WebhookDelivery struct {
+	// ID is the unique identifier of the delivery.
+	ID uint
+	// URL is the URL of the webhook, to which the notification is sent.
+	URL string
+	// PoolID is the id in hex format of the pool, to which the assigned block
+	// has been assigned.
+	PoolID string
+	// Epoch is the epoch for which the block has been scheduled.
+	Epoch uint
+	// No is the unique number of the block in an overall leader log.
+	No uint
+	// Status is the status of the assigned block, about which is notified.
+	Status BlockStatus
+	// TransitionID is the ID of the status transition, about which is
+	// notified, or zero, if it is unknown. A block, which is reclassified back
+	// to an earlier status, is notified again, since the transition differs.
+	TransitionID uint
+	// Payload is the body of the notification.
+	Payload string
+	// State is the state of the delivery.
+	State DeliveryState
+	// Attempts is the number of failed attempts to deliver the notification.
+	Attempts uint
+	// NextAttempt is the time after which the next attempt is made, if the
+	// delivery is pending.
+	NextAttempt time.Time
+	// LastError describes why the last attempt failed.
+	LastError string
+	// ResponseStatus is the HTTP status code of the last response of the
+	// receiver, or zero, if no response has been received.
+	ResponseStatus int
+	// CreatedAt is the time at which the delivery has been queued.
+	CreatedAt time.Time
+	// UpdatedAt is the time at which the delivery has been updated last.
+	UpdatedAt time.Time
 }
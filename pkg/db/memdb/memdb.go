@@ -0,0 +1,183 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+)
+
+// leaderLogKey identifies the leader log of a pool in an epoch.
+type leaderLogKey struct {
+	poolID string
+	epoch  uint
+}
+
+// slotKey identifies the block assigned to a pool in a slot of an epoch.
+type slotKey struct {
+	poolID string
+	epoch  uint
+	slot   uint
+}
+
+// leaderLogSummary is the stored summary of a leader log. The assigned blocks
+// are stored separately.
+type leaderLogSummary struct {
+	expectedBlockNumber float32
+	maxPerformance      float32
+}
+
+// assignment is a stored assigned block, which refers to its relevant minted
+// block by ID.
+type assignment struct {
+	block    db.AssignedBlock
+	relevant *uint
+}
+
+// retry is a stored retry for an assigned block.
+type retry struct {
+	attempts    uint
+	nextAttempt int64
+	lastError   string
+}
+
+// MemDB is a db.DB keeping all the data in memory. It is meant to be used in
+// tests, and its content is lost, when it is closed.
+type MemDB struct {
+	lock             sync.RWMutex
+	obv              *db.Observer
+	leaderLogs       map[leaderLogKey]leaderLogSummary
+	assignments      map[leaderLogKey][]assignment
+	mintedBlocks     map[uint]db.MintedBlock
+	lastBlockID      uint
+	transitions      []db.StatusTransition
+	lastTransitionID uint
+	retries          map[slotKey]retry
+	stakePools       map[string]db.StakePool
+	events           []db.Event
+	deliveries       []db.WebhookDelivery
+	clock            clock.Clock
+}
+
+// NewMemDB creates a new and empty in-memory database. The given clock.Clock
+// tells the time, which is "now" for the queries.
+func NewMemDB(clk clock.Clock) db.DB {
+	return &MemDB{
+		obv:          &db.Observer{},
+		leaderLogs:   make(map[leaderLogKey]leaderLogSummary),
+		assignments:  make(map[leaderLogKey][]assignment),
+		mintedBlocks: make(map[uint]db.MintedBlock),
+		retries:      make(map[slotKey]retry),
+		stakePools:   make(map[string]db.StakePool),
+		clock:        clk,
+	}
+}
+
+func (l *MemDB) Observer() *db.Observer {
+	return l.obv
+}
+
+// Ping always succeeds, since the data is kept in memory.
+func (l *MemDB) Ping(context.Context) error {
+	return nil
+}
+
+func (l *MemDB) Close() error {
+	l.obv.Close()
+	return nil
+}
+
+// selectAssignments selects the assigned blocks of the given pool, which are
+// accepted by the given filter. They are sorted by their timestamp.
+func (l *MemDB) selectAssignments(poolID string,
+	filter func(a *assignment) bool) []assignment {
+
+	selected := make([]assignment, 0)
+	for key, assignments := range l.assignments {
+		if key.poolID != poolID {
+			continue
+		}
+		for i := range assignments {
+			if filter(&assignments[i]) {
+				selected = append(selected, assignments[i])
+			}
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		a, b := selected[i].block, selected[j].block
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp)
+		}
+		if a.Epoch != b.Epoch {
+			return a.Epoch < b.Epoch
+		}
+		return a.No < b.No
+	})
+	return selected
+}
+
+// findAssignment finds the block assigned to the given pool and epoch with the
+// specified unique id called "no". Nil is returned, if no such block exists.
+func (l *MemDB) findAssignment(poolID string, epoch, no uint) *assignment {
+	assignments := l.assignments[leaderLogKey{poolID: poolID, epoch: epoch}]
+	for i := range assignments {
+		if assignments[i].block.No == no {
+			return &assignments[i]
+		}
+	}
+	return nil
+}
+
+// findAssignmentInSlot finds the block assigned to the given pool and epoch in
+// the given slot. Nil is returned, if no such block exists.
+func (l *MemDB) findAssignmentInSlot(key slotKey) *assignment {
+	assignments := l.assignments[leaderLogKey{poolID: key.poolID,
+		epoch: key.epoch}]
+	for i := range assignments {
+		if assignments[i].block.Slot == key.slot {
+			return &assignments[i]
+		}
+	}
+	return nil
+}
+
+// withRelevantBlock returns the assigned block of the given assignment with
+// its relevant minted block linked.
+func (l *MemDB) withRelevantBlock(a assignment) db.AssignedBlock {
+	block := a.block
+	if a.relevant != nil {
+		mintedBlock, found := l.mintedBlocks[*a.relevant]
+		if found {
+			id := *a.relevant
+			mintedBlock.ID = &id
+			block.RelevantBlock = &mintedBlock
+		}
+	}
+	return block
+}
+
+// recordEvent records the given event in the event log with the next ID.
+func (l *MemDB) recordEvent(event db.Event) {
+	event.ID = uint(len(l.events)) + 1
+	event.Timestamp = unixTime(event.Timestamp)
+	l.events = append(l.events, event)
+}
+
+// assignedBlocks returns the assigned blocks of the given assignments without their
+// relevant minted block.
+func assignedBlocks(assignments []assignment) []db.AssignedBlock {
+	blocks := make([]db.AssignedBlock, len(assignments))
+	for i, a := range assignments {
+		blocks[i] = a.block
+	}
+	return blocks
+}
+
+// unixTime returns the given time with the precision of seconds, which is the
+// precision of timestamps in the other databases.
+func unixTime(t time.Time) time.Time {
+	return time.Unix(t.Unix(), 0)
+}
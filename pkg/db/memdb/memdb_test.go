@@ -0,0 +1,15 @@
+package memdb
+
+import (
+	"testing"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	"github.com/blockblu-io/leaderlog-api/pkg/db/dbtest"
+)
+
+func TestMemDB(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T, clk clock.Clock) db.DB {
+		return NewMemDB(clk)
+	})
+}
@@ -0,0 +1,276 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+)
+
+func (l *MemDB) GetRegisteredEpochs(ctx context.Context, poolID string,
+	ordering db.Ordering, limit uint) ([]uint, error) {
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	epochs := make([]uint, 0)
+	for key := range l.leaderLogs {
+		if key.poolID == poolID {
+			epochs = append(epochs, key.epoch)
+		}
+	}
+	sort.Slice(epochs, func(i, j int) bool {
+		if ordering == db.OrderingDesc {
+			return epochs[i] > epochs[j]
+		}
+		return epochs[i] < epochs[j]
+	})
+	if uint(len(epochs)) > limit {
+		epochs = epochs[:limit]
+	}
+	return epochs, nil
+}
+
+func (l *MemDB) GetLeaderLog(ctx context.Context, poolID string,
+	epoch uint) (*db.LeaderLog, error) {
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	stored, found := l.leaderLogs[leaderLogKey{poolID: poolID, epoch: epoch}]
+	if !found {
+		return nil, nil
+	}
+	assignments := l.selectAssignments(poolID, func(a *assignment) bool {
+		return a.block.Epoch == epoch
+	})
+	return &db.LeaderLog{
+		PoolID:              poolID,
+		Epoch:               epoch,
+		Blocks:              assignedBlocks(assignments),
+		ExpectedBlockNumber: stored.expectedBlockNumber,
+		MaxPerformance:      stored.maxPerformance,
+	}, nil
+}
+
+func (l *MemDB) GetPerformance(ctx context.Context, poolID string, from,
+	to uint) ([]db.EpochPerformance, error) {
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	performances := make([]db.EpochPerformance, 0)
+	for key, stored := range l.leaderLogs {
+		if key.poolID != poolID || key.epoch < from || key.epoch > to {
+			continue
+		}
+		performance := db.EpochPerformance{
+			Epoch:               key.epoch,
+			ExpectedBlockNumber: stored.expectedBlockNumber,
+			StatusCounts:        make(map[db.BlockStatus]uint),
+		}
+		for status := db.NotMinted; status <= db.GHOSTED; status++ {
+			performance.StatusCounts[status] = 0
+		}
+		for _, a := range l.assignments[key] {
+			performance.AssignedBlocks++
+			performance.StatusCounts[a.block.Status]++
+		}
+		performances = append(performances, performance)
+	}
+	sort.Slice(performances, func(i, j int) bool {
+		return performances[i].Epoch < performances[j].Epoch
+	})
+	return performances, nil
+}
+
+func (l *MemDB) GetAssignedBlocksAfterNow(ctx context.Context,
+	poolID string) ([]db.AssignedBlock, error) {
+
+	now := l.clock.Now().Unix()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	assignments := l.selectAssignments(poolID, func(a *assignment) bool {
+		return a.block.Timestamp.Unix() > now
+	})
+	return assignedBlocks(assignments), nil
+}
+
+func (l *MemDB) GetAssignedBlocksBeforeNow(ctx context.Context,
+	poolID string, epoch uint) ([]db.AssignedBlock, error) {
+
+	now := l.clock.Now().Unix()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	assignments := l.selectAssignments(poolID, func(a *assignment) bool {
+		return a.block.Epoch == epoch && a.block.Timestamp.Unix() <= now
+	})
+	blocks := make([]db.AssignedBlock, len(assignments))
+	for i, a := range assignments {
+		blocks[i] = l.withRelevantBlock(a)
+	}
+	return blocks, nil
+}
+
+func (l *MemDB) GetAssignedBlocksWithStatusBeforeNow(ctx context.Context,
+	poolID string, status db.BlockStatus, offset,
+	limit uint) ([]db.AssignedBlock, error) {
+
+	now := l.clock.Now().Unix()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	assignments := l.selectAssignments(poolID, func(a *assignment) bool {
+		return a.block.Status == status && a.block.Timestamp.Unix() <= now
+	})
+	if offset >= uint(len(assignments)) {
+		return []db.AssignedBlock{}, nil
+	}
+	assignments = assignments[offset:]
+	if uint(len(assignments)) > limit {
+		assignments = assignments[:limit]
+	}
+	return assignedBlocks(assignments), nil
+}
+
+func (l *MemDB) GetClassifiedBlocksWithinWindow(ctx context.Context,
+	poolID string, height, slot uint) ([]db.AssignedBlock, error) {
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	assignments := l.selectAssignments(poolID, func(a *assignment) bool {
+		if a.block.Status == db.NotMinted {
+			return false
+		}
+		if a.relevant == nil {
+			return a.block.Slot >= slot
+		}
+		mintedBlock, found := l.mintedBlocks[*a.relevant]
+		return found && mintedBlock.Height >= height
+	})
+	blocks := make([]db.AssignedBlock, len(assignments))
+	for i, a := range assignments {
+		blocks[i] = l.withRelevantBlock(a)
+	}
+	return blocks, nil
+}
+
+func (l *MemDB) GetStatusTransitions(ctx context.Context, poolID string,
+	epoch uint) ([]db.StatusTransition, error) {
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	transitions := make([]db.StatusTransition, 0)
+	for _, transition := range l.transitions {
+		if transition.PoolID == poolID && transition.Epoch == epoch {
+			transitions = append(transitions, transition)
+		}
+	}
+	return transitions, nil
+}
+
+// retryEntry returns the retry entry for the given assigned block and queued
+// retry.
+func retryEntry(a *assignment, r retry) db.RetryEntry {
+	entry := db.RetryEntry{
+		Block:     a.block,
+		Attempts:  r.attempts,
+		LastError: r.lastError,
+	}
+	if r.nextAttempt > 0 {
+		entry.NextAttempt = time.Unix(r.nextAttempt, 0)
+	}
+	return entry
+}
+
+func (l *MemDB) GetRetryEntries(ctx context.Context,
+	poolID string) ([]db.RetryEntry, error) {
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	entries := make([]db.RetryEntry, 0)
+	for key, r := range l.retries {
+		if key.poolID != poolID {
+			continue
+		}
+		a := l.findAssignmentInSlot(key)
+		if a == nil {
+			continue
+		}
+		entries = append(entries, retryEntry(a, r))
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].NextAttempt.Before(entries[j].NextAttempt)
+	})
+	return entries, nil
+}
+
+func (l *MemDB) GetRetryEntry(ctx context.Context, poolID string, epoch,
+	no uint) (*db.RetryEntry, error) {
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	a := l.findAssignment(poolID, epoch, no)
+	if a == nil {
+		return nil, nil
+	}
+	r, found := l.retries[slotKey{poolID: poolID, epoch: epoch,
+		slot: a.block.Slot}]
+	if !found {
+		return nil, nil
+	}
+	entry := retryEntry(a, r)
+	return &entry, nil
+}
+
+func (l *MemDB) GetStakePool(ctx context.Context,
+	hexID string) (*db.StakePool, error) {
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	pool, found := l.stakePools[hexID]
+	if !found {
+		return nil, nil
+	}
+	return &pool, nil
+}
+
+func (l *MemDB) GetEventsAfter(ctx context.Context, poolID string, id,
+	limit uint) ([]db.Event, error) {
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	events := make([]db.Event, 0)
+	for _, event := range l.events {
+		if uint(len(events)) >= limit {
+			break
+		}
+		if event.PoolID == poolID && event.ID > id {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (l *MemDB) GetLastEventID(ctx context.Context, poolID string) (uint, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	for i := len(l.events) - 1; i >= 0; i-- {
+		if l.events[i].PoolID == poolID {
+			return l.events[i].ID, nil
+		}
+	}
+	return 0, nil
+}
+
+func (l *MemDB) GetWebhookDeliveries(ctx context.Context, offset,
+	limit uint) ([]db.WebhookDelivery, error) {
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	deliveries := make([]db.WebhookDelivery, 0)
+	for i := len(l.deliveries) - 1 - int(offset); i >= 0; i-- {
+		if uint(len(deliveries)) >= limit {
+			break
+		}
+		deliveries = append(deliveries, l.deliveries[i])
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,341 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	log "github.com/sirupsen/logrus"
+)
+
+func (l *MemDB) WriteLeaderLog(ctx context.Context,
+	leaderLog *db.LeaderLog) (*db.LeaderLogDiff, error) {
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	key := leaderLogKey{poolID: leaderLog.PoolID, epoch: leaderLog.Epoch}
+	numbers := make(map[uint]bool)
+	for _, block := range leaderLog.Blocks {
+		if numbers[block.No] {
+			log.Errorf("assigned block insertion for epoch '%d' failed: the number %d isn't unique",
+				leaderLog.Epoch, block.No)
+			return nil, db.WriteError
+		}
+		numbers[block.No] = true
+	}
+	states := make(map[uint]assignment)
+	for _, a := range l.assignments[key] {
+		states[a.block.Slot] = a
+	}
+	_, replaced := l.leaderLogs[key]
+	diff := &db.LeaderLogDiff{
+		PoolID:   leaderLog.PoolID,
+		Epoch:    leaderLog.Epoch,
+		Replaced: replaced,
+		Added:    []uint{},
+		Removed:  []uint{},
+		Kept:     []uint{},
+	}
+	assignments := make([]assignment, len(leaderLog.Blocks))
+	for i, block := range leaderLog.Blocks {
+		state, found := states[block.Slot]
+		if found {
+			delete(states, block.Slot)
+			diff.Kept = append(diff.Kept, block.Slot)
+		} else {
+			diff.Added = append(diff.Added, block.Slot)
+		}
+		block.Epoch = leaderLog.Epoch
+		block.Timestamp = unixTime(block.Timestamp)
+		block.Status = state.block.Status
+		block.RelevantBlock = nil
+		assignments[i] = assignment{block: block, relevant: state.relevant}
+	}
+	for slot, state := range states {
+		diff.Removed = append(diff.Removed, slot)
+		l.removeHistory(func(key slotKey) bool {
+			return key == slotKey{poolID: leaderLog.PoolID,
+				epoch: leaderLog.Epoch, slot: slot}
+		})
+		if state.relevant != nil {
+			delete(l.mintedBlocks, *state.relevant)
+		}
+	}
+	l.leaderLogs[key] = leaderLogSummary{
+		expectedBlockNumber: leaderLog.ExpectedBlockNumber,
+		maxPerformance:      leaderLog.MaxPerformance,
+	}
+	l.assignments[key] = assignments
+	sortSlots(diff.Added)
+	sortSlots(diff.Removed)
+	sortSlots(diff.Kept)
+	l.recordEvent(db.Event{
+		PoolID:    leaderLog.PoolID,
+		Type:      db.EventEpochRegistered,
+		Epoch:     leaderLog.Epoch,
+		Timestamp: l.clock.Now(),
+	})
+	if diff.Replaced {
+		go l.obv.Pub(db.ObserverMessage{
+			Code:     db.ObserveReplacedLeaderLog,
+			Response: diff,
+		})
+	} else {
+		go l.obv.Pub(db.ObserverMessage{
+			Code: db.ObserveNewLeaderLog,
+			Response: db.LeaderLogRef{
+				PoolID: leaderLog.PoolID,
+				Epoch:  leaderLog.Epoch,
+			},
+		})
+	}
+	return diff, nil
+}
+
+// removeHistory removes the status transitions and queued retries of the
+// assigned blocks, whose key is accepted by the given filter.
+func (l *MemDB) removeHistory(filter func(key slotKey) bool) {
+	transitions := make([]db.StatusTransition, 0, len(l.transitions))
+	for _, transition := range l.transitions {
+		key := slotKey{poolID: transition.PoolID, epoch: transition.Epoch,
+			slot: transition.Slot}
+		if !filter(key) {
+			transitions = append(transitions, transition)
+		}
+	}
+	l.transitions = transitions
+	for key := range l.retries {
+		if filter(key) {
+			delete(l.retries, key)
+		}
+	}
+}
+
+// sortSlots sorts the given list of slots in ascending order.
+func sortSlots(slots []uint) {
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i] < slots[j]
+	})
+}
+
+func (l *MemDB) WriteRetryEntry(ctx context.Context, poolID string,
+	entry *db.RetryEntry) error {
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	a := l.findAssignment(poolID, entry.Block.Epoch, entry.Block.No)
+	if a == nil {
+		return nil
+	}
+	r := retry{
+		attempts:  entry.Attempts,
+		lastError: entry.LastError,
+	}
+	if !entry.NextAttempt.IsZero() {
+		r.nextAttempt = entry.NextAttempt.Unix()
+	}
+	l.retries[slotKey{poolID: poolID, epoch: a.block.Epoch,
+		slot: a.block.Slot}] = r
+	return nil
+}
+
+func (l *MemDB) UpdateStatusForAssignment(ctx context.Context,
+	poolID string, epoch, no uint, status db.BlockStatus,
+	mintedBlock *db.MintedBlock) error {
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	a := l.findAssignment(poolID, epoch, no)
+	if a == nil {
+		log.Errorf("querying the status of block (%d,%d) failed: no such block",
+			epoch, no)
+		return db.WriteError
+	}
+	transition := &db.StatusTransition{
+		PoolID:    poolID,
+		Epoch:     epoch,
+		No:        no,
+		Slot:      a.block.Slot,
+		From:      a.block.Status,
+		To:        status,
+		Timestamp: unixTime(l.clock.Now()),
+	}
+	if a.relevant != nil {
+		transition.FromHash = l.mintedBlocks[*a.relevant].Hash
+	}
+	var relevant *uint
+	if mintedBlock != nil {
+		l.lastBlockID++
+		stored := *mintedBlock
+		stored.ID = nil
+		l.mintedBlocks[l.lastBlockID] = stored
+		transition.ToHash = mintedBlock.Hash
+		id := l.lastBlockID
+		relevant = &id
+	}
+	if a.relevant != nil && (relevant == nil || *a.relevant != *relevant) {
+		delete(l.mintedBlocks, *a.relevant)
+	}
+	a.block.Status = status
+	a.relevant = relevant
+	delete(l.retries, slotKey{poolID: poolID, epoch: epoch,
+		slot: a.block.Slot})
+	changed := transition.From != transition.To ||
+		transition.FromHash != transition.ToHash
+	if changed {
+		l.lastTransitionID++
+		transition.ID = l.lastTransitionID
+		l.transitions = append(l.transitions, *transition)
+	}
+	if changed || transition.From == db.NotMinted {
+		l.recordEvent(db.Event{
+			PoolID:    poolID,
+			Type:      db.EventBlockStatus,
+			Epoch:     epoch,
+			No:        no,
+			Status:    status,
+			Timestamp: transition.Timestamp,
+		})
+	}
+	if transition.From != db.NotMinted {
+		if changed {
+			go l.obv.Pub(db.ObserverMessage{
+				Code:     db.ObserveReclassifiedBlock,
+				Response: transition,
+			})
+		}
+	} else {
+		go l.obv.Pub(db.ObserverMessage{
+			Code: db.ObserveUpdatedBlockStatus,
+			Response: db.AssignmentRef{
+				PoolID: poolID,
+				Epoch:  epoch,
+				No:     no,
+			},
+		})
+	}
+	return nil
+}
+
+func (l *MemDB) DeleteLeaderLog(ctx context.Context, poolID string,
+	epoch uint) (bool, error) {
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	key := leaderLogKey{poolID: poolID, epoch: epoch}
+	for _, a := range l.assignments[key] {
+		if a.relevant != nil {
+			delete(l.mintedBlocks, *a.relevant)
+		}
+	}
+	delete(l.assignments, key)
+	l.removeHistory(func(key slotKey) bool {
+		return key.poolID == poolID && key.epoch == epoch
+	})
+	_, found := l.leaderLogs[key]
+	if !found {
+		return false, nil
+	}
+	delete(l.leaderLogs, key)
+	l.recordEvent(db.Event{
+		PoolID:    poolID,
+		Type:      db.EventEpochDeleted,
+		Epoch:     epoch,
+		Timestamp: l.clock.Now(),
+	})
+	go l.obv.Pub(db.ObserverMessage{
+		Code: db.ObserveDeletedLeaderLog,
+		Response: db.LeaderLogRef{
+			PoolID: poolID,
+			Epoch:  epoch,
+		},
+	})
+	return true, nil
+}
+
+func (l *MemDB) WriteStakePool(ctx context.Context, pool *db.StakePool) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	stored := *pool
+	stored.UpdatedAt = unixTime(pool.UpdatedAt)
+	l.stakePools[pool.HexID] = stored
+	return nil
+}
+
+func (l *MemDB) QueueWebhookDelivery(ctx context.Context,
+	delivery *db.WebhookDelivery) (bool, error) {
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, queued := range l.deliveries {
+		if queued.URL == delivery.URL && queued.PoolID == delivery.PoolID &&
+			queued.Epoch == delivery.Epoch && queued.No == delivery.No &&
+			queued.Status == delivery.Status &&
+			queued.TransitionID == delivery.TransitionID {
+			return false, nil
+		}
+	}
+	now := unixTime(l.clock.Now())
+	l.deliveries = append(l.deliveries, db.WebhookDelivery{
+		ID:           uint(len(l.deliveries)) + 1,
+		URL:          delivery.URL,
+		PoolID:       delivery.PoolID,
+		Epoch:        delivery.Epoch,
+		No:           delivery.No,
+		Status:       delivery.Status,
+		TransitionID: delivery.TransitionID,
+		Payload:      delivery.Payload,
+		State:        db.DeliveryPending,
+		NextAttempt:  now,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	})
+	return true, nil
+}
+
+func (l *MemDB) ClaimWebhookDeliveries(ctx context.Context,
+	lease time.Duration, limit uint) ([]db.WebhookDelivery, error) {
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := unixTime(l.clock.Now())
+	due := make([]int, 0)
+	for i, delivery := range l.deliveries {
+		if delivery.State == db.DeliveryPending &&
+			!delivery.NextAttempt.After(now) {
+			due = append(due, i)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return l.deliveries[due[i]].NextAttempt.Before(
+			l.deliveries[due[j]].NextAttempt)
+	})
+	if uint(len(due)) > limit {
+		due = due[:limit]
+	}
+	claimed := make([]db.WebhookDelivery, len(due))
+	for i, index := range due {
+		l.deliveries[index].NextAttempt = unixTime(now.Add(lease))
+		claimed[i] = l.deliveries[index]
+	}
+	return claimed, nil
+}
+
+func (l *MemDB) UpdateWebhookDelivery(ctx context.Context,
+	delivery *db.WebhookDelivery) error {
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if delivery.ID == 0 || delivery.ID > uint(len(l.deliveries)) {
+		return nil
+	}
+	stored := &l.deliveries[delivery.ID-1]
+	stored.State = delivery.State
+	stored.Attempts = delivery.Attempts
+	stored.NextAttempt = unixTime(delivery.NextAttempt)
+	stored.LastError = delivery.LastError
+	stored.ResponseStatus = delivery.ResponseStatus
+	stored.UpdatedAt = unixTime(l.clock.Now())
+	return nil
+}
@@ -7,9 +7,15 @@ import (
 // ObserverCode is referring to the type of observer notification.
 type ObserverCode int
 
+// The response of a message is a LeaderLogRef for new and deleted leader logs,
+// a *LeaderLogDiff for replaced leader logs, an AssignmentRef for updated block
+// statuses and a *StatusTransition for reclassified blocks.
 const (
 	ObserveNewLeaderLog       = 0
 	ObserveUpdatedBlockStatus = 1
+	ObserveReplacedLeaderLog  = 2
+	ObserveDeletedLeaderLog   = 3
+	ObserveReclassifiedBlock  = 4
 )
 
 // ObserverMessage is a message describing a change of a db.DB update.
@@ -18,10 +24,32 @@ type ObserverMessage struct {
 	Response interface{}
 }
 
+// PoolID returns the ID of the pool to which the change described by this
+// message refers. An empty string is returned, if the response is unknown.
+func (msg ObserverMessage) PoolID() string {
+	switch response := msg.Response.(type) {
+	case LeaderLogRef:
+		return response.PoolID
+	case AssignmentRef:
+		return response.PoolID
+	case *LeaderLogDiff:
+		return response.PoolID
+	case *StatusTransition:
+		return response.PoolID
+	}
+	return ""
+}
+
 // Observer allows registering change listeners for a db.DB instance.
 type Observer struct {
-	channels []chan<- ObserverMessage
-	lock     sync.Mutex
+	subscriptions []*subscription
+	lock          sync.Mutex
+}
+
+// subscription is a channel subscribed to an Observer.
+type subscription struct {
+	c    chan<- ObserverMessage
+	done chan struct{}
 }
 
 // Sub subscribes the given channel to get notification, when the db.DB got
@@ -29,7 +57,26 @@ type Observer struct {
 func (obv *Observer) Sub(c chan<- ObserverMessage) {
 	obv.lock.Lock()
 	defer obv.lock.Unlock()
-	obv.channels = append(obv.channels, c)
+	obv.subscriptions = append(obv.subscriptions, &subscription{
+		c:    c,
+		done: make(chan struct{}),
+	})
+}
+
+// Unsub unsubscribes the given channel, which then gets no further
+// notifications. Pending notifications, which haven't been received yet, are
+// dropped. The channel isn't closed.
+func (obv *Observer) Unsub(c chan<- ObserverMessage) {
+	obv.lock.Lock()
+	defer obv.lock.Unlock()
+	for i, s := range obv.subscriptions {
+		if s.c == c {
+			close(s.done)
+			obv.subscriptions = append(obv.subscriptions[:i],
+				obv.subscriptions[i+1:]...)
+			return
+		}
+	}
 }
 
 // Pub publishes the given message, which is distributed over all subscribed
@@ -37,21 +84,26 @@ func (obv *Observer) Sub(c chan<- ObserverMessage) {
 func (obv *Observer) Pub(msg ObserverMessage) {
 	obv.lock.Lock()
 	defer obv.lock.Unlock()
-	for _, c := range obv.channels {
-		go push(c, msg)
+	for _, s := range obv.subscriptions {
+		go s.push(msg)
 	}
 }
 
-// push is pushing a message to the given channel.
-func push(c chan<- ObserverMessage, msg ObserverMessage) {
-	c <- msg
+// push is pushing a message to the channel of this subscription, unless it
+// has been unsubscribed in the meantime.
+func (s *subscription) push(msg ObserverMessage) {
+	select {
+	case s.c <- msg:
+	case <-s.done:
+	}
 }
 
 // Close is closing this observer.
 func (obv *Observer) Close() {
-	if obv.channels != nil {
-		for _, channel := range obv.channels {
-			close(channel)
-		}
+	obv.lock.Lock()
+	defer obv.lock.Unlock()
+	for _, s := range obv.subscriptions {
+		close(s.c)
 	}
+	obv.subscriptions = nil
 }
@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// migrationFiles are the up-migrations of the schema. The name of each file
+// starts with the version of the schema, to which the file migrates, followed
+// by an underscore and a short description (e.g. '0001_initial').
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the key of the advisory lock, which is held while the
+// schema is migrated such that replicas don't migrate concurrently.
+const migrationLockID = 7305163
+
+// Migration is a migration of the database schema to a certain version.
+type Migration struct {
+	// Version is the version of the schema after this migration.
+	Version uint
+	// Name is a short description of the migration.
+	Name string
+	// SQL are the statements of the migration.
+	SQL string
+}
+
+// loadMigrations loads all the embedded migrations in ascending order of their
+// version. An error will be returned, if the name of a file doesn't follow the
+// naming scheme.
+func loadMigrations() ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		parts := strings.SplitN(name, "_", 2)
+		version, err := strconv.ParseUint(parts[0], 10, 32)
+		if err != nil || len(parts) != 2 || version == 0 {
+			return nil, fmt.Errorf("the migration file '%s' must be named '<version>_<name>.sql'",
+				entry.Name())
+		}
+		content, err := migrationFiles.ReadFile(path.Join("migrations",
+			entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{
+			Version: uint(version),
+			Name:    parts[1],
+			SQL:     string(content),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// migrate applies all the pending migrations to the given database in
+// ascending order of their version within one transaction. The pending
+// migrations are only returned without being applied, if dryRun is true. An
+// error will be returned, if a migration failed, and then none of them is
+// applied.
+func migrate(sqlDB *sql.DB, dryRun bool) ([]Migration, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1);`,
+		migrationLockID)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS schema_version (
+	version BIGINT NOT NULL PRIMARY KEY,
+	name TEXT NOT NULL,
+	appliedAt BIGINT NOT NULL
+);
+`)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	var version sql.NullInt64
+	err = tx.QueryRowContext(ctx, `SELECT max(version) FROM schema_version;`).
+		Scan(&version)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	pending := make([]Migration, 0)
+	for _, migration := range migrations {
+		if migration.Version > uint(version.Int64) {
+			pending = append(pending, migration)
+		}
+	}
+	if dryRun {
+		_ = tx.Rollback()
+		return pending, nil
+	}
+	for _, migration := range pending {
+		_, err = tx.ExecContext(ctx, migration.SQL)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, `
+INSERT INTO schema_version (version, name, appliedAt) VALUES ($1, $2, $3);
+`, migration.Version, migration.Name, time.Now().Unix())
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("migration to version %d (%s) failed: %s",
+				migration.Version, migration.Name, err.Error())
+		}
+		log.Infof("migrated the database to version %d (%s)",
+			migration.Version, migration.Name)
+	}
+	return pending, tx.Commit()
+}
+
+// Migrate applies all the pending migrations to the PostgreSQL database, which
+// is reachable with the given DSN, and returns them. The pending migrations
+// are only returned without being applied, if dryRun is true.
+func Migrate(dsn string, dryRun bool) ([]Migration, error) {
+	sqlDB, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDB.Close()
+	return migrate(sqlDB, dryRun)
+}
@@ -0,0 +1,54 @@
+CREATE TABLE LeaderLog (
+	poolID TEXT NOT NULL,
+	epoch BIGINT NOT NULL,
+	expectedBlockNr REAL NOT NULL,
+	maxPerformance REAL NOT NULL,
+	PRIMARY KEY(poolID, epoch)
+);
+
+CREATE TABLE MintedBlock (
+	id BIGSERIAL PRIMARY KEY,
+	epoch BIGINT NOT NULL,
+	slotNr BIGINT NOT NULL,
+	slotInEpochNr BIGINT NOT NULL,
+	hash TEXT NOT NULL,
+	height BIGINT NOT NULL,
+	poolID TEXT NOT NULL
+);
+
+CREATE TABLE AssignedBlock (
+	poolID TEXT NOT NULL,
+	epoch BIGINT NOT NULL,
+	no BIGINT NOT NULL,
+	slotNr BIGINT NOT NULL,
+	slotInEpochNr BIGINT NOT NULL,
+	timestamp BIGINT NOT NULL,
+	status INTEGER NOT NULL DEFAULT 0,
+	relevant BIGINT,
+	PRIMARY KEY(poolID, epoch, no)
+);
+
+CREATE INDEX AssignedBlockTimestampIdx ON AssignedBlock (poolID, timestamp);
+
+CREATE TABLE StatusTransition (
+	id BIGSERIAL PRIMARY KEY,
+	poolID TEXT NOT NULL,
+	epoch BIGINT NOT NULL,
+	no BIGINT NOT NULL,
+	slotNr BIGINT NOT NULL,
+	fromStatus INTEGER NOT NULL,
+	toStatus INTEGER NOT NULL,
+	fromHash TEXT,
+	toHash TEXT,
+	timestamp BIGINT NOT NULL
+);
+
+CREATE TABLE RetryQueue (
+	poolID TEXT NOT NULL,
+	epoch BIGINT NOT NULL,
+	slotNr BIGINT NOT NULL,
+	attempts BIGINT NOT NULL,
+	nextAttempt BIGINT NOT NULL,
+	lastError TEXT NOT NULL,
+	PRIMARY KEY(poolID, epoch, slotNr)
+);
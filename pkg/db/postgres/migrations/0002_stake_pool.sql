@@ -0,0 +1,6 @@
+CREATE TABLE StakePool (
+	hexID TEXT PRIMARY KEY,
+	ticker TEXT NOT NULL,
+	name TEXT NOT NULL,
+	updatedAt BIGINT NOT NULL
+);
@@ -0,0 +1,4 @@
+-- the metadata of the pool that minted a block, as it was known when the
+-- block has been written.
+ALTER TABLE MintedBlock ADD COLUMN poolTicker TEXT NOT NULL DEFAULT '';
+ALTER TABLE MintedBlock ADD COLUMN poolName TEXT NOT NULL DEFAULT '';
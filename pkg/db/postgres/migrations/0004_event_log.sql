@@ -0,0 +1,11 @@
+CREATE TABLE Event (
+	id BIGSERIAL PRIMARY KEY,
+	poolID TEXT NOT NULL,
+	type TEXT NOT NULL,
+	epoch BIGINT NOT NULL,
+	no BIGINT NOT NULL,
+	status INTEGER NOT NULL,
+	timestamp BIGINT NOT NULL
+);
+
+CREATE INDEX EventPoolIdx ON Event (poolID, id);
@@ -0,0 +1,19 @@
+CREATE TABLE WebhookDelivery (
+	id BIGSERIAL PRIMARY KEY,
+	url TEXT NOT NULL,
+	poolID TEXT NOT NULL,
+	epoch BIGINT NOT NULL,
+	no BIGINT NOT NULL,
+	status INTEGER NOT NULL,
+	payload TEXT NOT NULL,
+	state TEXT NOT NULL,
+	attempts BIGINT NOT NULL,
+	nextAttempt BIGINT NOT NULL,
+	lastError TEXT NOT NULL,
+	responseStatus INTEGER NOT NULL,
+	createdAt BIGINT NOT NULL,
+	updatedAt BIGINT NOT NULL,
+	UNIQUE (url, poolID, epoch, no, status)
+);
+
+CREATE INDEX WebhookDeliveryPendingIdx ON WebhookDelivery (state, nextAttempt);
@@ -0,0 +1,6 @@
+-- deliveries are unique per status transition, such that a block, which is
+-- reclassified back to an earlier status, is notified again. The deliveries
+-- queued before are linked to no transition.
+ALTER TABLE WebhookDelivery ADD COLUMN transitionID BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE WebhookDelivery DROP CONSTRAINT webhookdelivery_url_poolid_epoch_no_status_key;
+ALTER TABLE WebhookDelivery ADD UNIQUE (url, poolID, epoch, no, status, transitionID);
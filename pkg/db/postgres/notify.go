@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+)
+
+// observerChannel is the channel on which the messages of the db.Observer are
+// sent to all the replicas.
+const observerChannel = "leaderlog_observer"
+
+// notification is the payload of a notification on the observer channel.
+type notification struct {
+	Code     db.ObserverCode `json:"code"`
+	Response json.RawMessage `json:"response"`
+}
+
+// notify sends the given message on the observer channel within the given
+// transaction. The message is only delivered, if the transaction is committed.
+func notify(ctx context.Context, tx *sql.Tx, msg db.ObserverMessage) error {
+	payload, err := encodeNotification(msg)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `SELECT pg_notify($1, $2);`, observerChannel,
+		payload)
+	return err
+}
+
+// encodeNotification encodes the given message for the db.Observer into the
+// payload of a notification.
+func encodeNotification(msg db.ObserverMessage) (string, error) {
+	response, err := json.Marshal(msg.Response)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(&notification{
+		Code:     msg.Code,
+		Response: response,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(payload), nil
+}
+
+// decodeNotification decodes the given payload of a notification into a
+// message for the db.Observer. The response is decoded into the type that is
+// expected for the code of the message.
+func decodeNotification(payload string) (*db.ObserverMessage, error) {
+	var n notification
+	err := json.Unmarshal([]byte(payload), &n)
+	if err != nil {
+		return nil, err
+	}
+	var response interface{}
+	switch n.Code {
+	case db.ObserveNewLeaderLog, db.ObserveDeletedLeaderLog:
+		var ref db.LeaderLogRef
+		err = json.Unmarshal(n.Response, &ref)
+		response = ref
+	case db.ObserveUpdatedBlockStatus:
+		var ref db.AssignmentRef
+		err = json.Unmarshal(n.Response, &ref)
+		response = ref
+	case db.ObserveReplacedLeaderLog:
+		diff := &db.LeaderLogDiff{}
+		err = json.Unmarshal(n.Response, diff)
+		response = diff
+	case db.ObserveReclassifiedBlock:
+		transition := &db.StatusTransition{}
+		err = json.Unmarshal(n.Response, transition)
+		response = transition
+	default:
+		return nil, fmt.Errorf("the observer code %d is unknown", n.Code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &db.ObserverMessage{
+		Code:     n.Code,
+		Response: response,
+	}, nil
+}
@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+)
+
+// PostgresDB is a db.DB making use of a PostgreSQL database. It can be shared
+// by multiple replicas of the API, since the messages of the db.Observer are
+// distributed to all of them with LISTEN/NOTIFY.
+type PostgresDB struct {
+	db       *sql.DB
+	obv      *db.Observer
+	listener *pq.Listener
+	done     chan struct{}
+	stopped  chan struct{}
+	clock    clock.Clock
+}
+
+// NewPostgresDB opens the PostgreSQL database, which is reachable with the
+// given DSN (e.g. 'postgres://user@localhost/leaderlog'). The schema of the
+// database is migrated to the latest version. The given clock.Clock tells the
+// time, which is "now" for the queries. It returns an DB instance with which
+// the database can be queried, or an error, if opening or migrating the
+// database has failed.
+func NewPostgresDB(dsn string, clk clock.Clock) (db.DB, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("the DSN for the postgres database hasn't been specified")
+	}
+	sqlDB, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	_, err = migrate(sqlDB, false)
+	if err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+	listener := pq.NewListener(dsn, time.Second, time.Minute,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Errorf("the listener of the postgres database failed: %s",
+					err.Error())
+			}
+		})
+	err = listener.Listen(observerChannel)
+	if err != nil {
+		_ = listener.Close()
+		_ = sqlDB.Close()
+		return nil, err
+	}
+	p := &PostgresDB{
+		db:       sqlDB,
+		obv:      &db.Observer{},
+		listener: listener,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+		clock:    clk,
+	}
+	go p.listen()
+	return p, nil
+}
+
+// listen publishes the messages received on the observer channel to the
+// db.Observer of this database until it is closed.
+func (l *PostgresDB) listen() {
+	defer close(l.stopped)
+	for {
+		select {
+		case n := <-l.listener.Notify:
+			if n == nil {
+				log.Warnf("reconnected to the postgres database, notifications might have been missed")
+				break
+			}
+			msg, err := decodeNotification(n.Extra)
+			if err != nil {
+				log.Errorf("couldn't decode the notification '%s': %s",
+					n.Extra, err.Error())
+				break
+			}
+			l.obv.Pub(*msg)
+		case <-time.After(90 * time.Second):
+			go func() {
+				_ = l.listener.Ping()
+			}()
+		case <-l.done:
+			return
+		}
+	}
+}
+
+func (l *PostgresDB) Observer() *db.Observer {
+	return l.obv
+}
+
+// Ping checks whether the database can be reached, and makes a statement,
+// which would write to the database, but doesn't match any rows. This
+// statement fails, if the database is read-only.
+func (l *PostgresDB) Ping(ctx context.Context) error {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Errorf("couldn't start a transaction to ping the database: %s",
+			err.Error())
+		return db.WriteError
+	}
+	defer func() { _ = tx.Rollback() }()
+	_, err = tx.ExecContext(ctx, "DELETE FROM LeaderLog WHERE 1 = 0")
+	if err != nil {
+		log.Errorf("pinging the database failed: %s", err.Error())
+		return db.WriteError
+	}
+	return nil
+}
+
+func (l *PostgresDB) Close() error {
+	close(l.done)
+	<-l.stopped
+	_ = l.listener.Close()
+	l.obv.Close()
+	return l.db.Close()
+}
@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/internal/pgtest"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	"github.com/blockblu-io/leaderlog-api/pkg/db/dbtest"
+)
+
+const (
+	// dsnEnv is the environment variable, in which the DSN of a disposable
+	// PostgreSQL database for the tests can be specified.
+	dsnEnv = "BLU_TEST_POSTGRES_DSN"
+	// poolA is the ID of the pool for which leader logs are written.
+	poolA = "cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214b"
+	// notifyTimeout is the duration to wait for a notification.
+	notifyTimeout = 5 * time.Second
+)
+
+func TestPostgres(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T, clk clock.Clock) db.DB {
+		idb, err := NewPostgresDB(pgtest.DSN(t, dsnEnv), clk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return idb
+	})
+}
+
+// awaitNotification waits for the next message on the given channel, and
+// fails the test, if none has been received in time.
+func awaitNotification(t *testing.T, name string,
+	c <-chan db.ObserverMessage) db.ObserverMessage {
+
+	t.Helper()
+	select {
+	case msg := <-c:
+		return msg
+	case <-time.After(notifyTimeout):
+		t.Fatalf("replica %s hasn't been notified", name)
+	}
+	return db.ObserverMessage{}
+}
+
+func TestNotifyFanOut(t *testing.T) {
+	dsn := pgtest.DSN(t, dsnEnv)
+	clk := clock.NewFake(time.Date(2022, 3, 17, 12, 0, 0, 0, time.UTC))
+	replicas := make(map[string]chan db.ObserverMessage)
+	var writer db.DB
+	for _, name := range []string{"A", "B"} {
+		idb, err := NewPostgresDB(dsn, clk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer idb.Close()
+		c := make(chan db.ObserverMessage)
+		idb.Observer().Sub(c)
+		replicas[name] = c
+		if writer == nil {
+			writer = idb
+		}
+	}
+
+	ctx := context.Background()
+	_, err := writer.WriteLeaderLog(ctx, &db.LeaderLog{
+		PoolID: poolA,
+		Epoch:  327,
+		Blocks: []db.AssignedBlock{{
+			Epoch:     327,
+			No:        1,
+			EpochSlot: 100,
+			Slot:      55900900,
+			Timestamp: time.Unix(1647467191, 0),
+		}},
+		ExpectedBlockNumber: 1,
+		MaxPerformance:      1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, c := range replicas {
+		msg := awaitNotification(t, name, c)
+		want := db.LeaderLogRef{PoolID: poolA, Epoch: 327}
+		if msg.Code != db.ObserveNewLeaderLog || msg.Response != want {
+			t.Errorf("replica %s expected a message about the new leader log, got %+v",
+				name, msg)
+		}
+	}
+
+	found, err := writer.DeleteLeaderLog(ctx, poolA, 327)
+	if err != nil || !found {
+		t.Fatalf("expected the leader log to be deleted (%v)", err)
+	}
+	for name, c := range replicas {
+		msg := awaitNotification(t, name, c)
+		want := db.LeaderLogRef{PoolID: poolA, Epoch: 327}
+		if msg.Code != db.ObserveDeletedLeaderLog || msg.Response != want {
+			t.Errorf("replica %s expected a message about the deleted leader log, got %+v",
+				name, msg)
+		}
+	}
+}
+
+func TestDecodeNotification(t *testing.T) {
+	messages := []db.ObserverMessage{
+		{Code: db.ObserveNewLeaderLog,
+			Response: db.LeaderLogRef{PoolID: poolA, Epoch: 327}},
+		{Code: db.ObserveDeletedLeaderLog,
+			Response: db.LeaderLogRef{PoolID: poolA, Epoch: 327}},
+		{Code: db.ObserveUpdatedBlockStatus,
+			Response: db.AssignmentRef{PoolID: poolA, Epoch: 327, No: 2}},
+		{Code: db.ObserveReplacedLeaderLog,
+			Response: &db.LeaderLogDiff{PoolID: poolA, Epoch: 327,
+				Replaced: true, Added: []uint{55900900}}},
+		{Code: db.ObserveReclassifiedBlock,
+			Response: &db.StatusTransition{PoolID: poolA, Epoch: 327, No: 1,
+				Slot: 55900900, From: db.Minted, To: db.HeightBattle,
+				FromHash: "0a02", Timestamp: time.Unix(1647467191, 0).UTC()}},
+	}
+	for _, msg := range messages {
+		payload, err := encodeNotification(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := decodeNotification(payload)
+		if err != nil {
+			t.Fatalf("couldn't decode the notification %s: %s", payload,
+				err.Error())
+		}
+		if !reflect.DeepEqual(*decoded, msg) {
+			t.Errorf("expected the message %+v, got %+v", msg, *decoded)
+		}
+	}
+	_, err := decodeNotification(`{"code":42,"response":{}}`)
+	if err == nil {
+		t.Error("expected an unknown code to be rejected")
+	}
+}
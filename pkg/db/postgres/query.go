@@ -0,0 +1,518 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// queryAndScanLeaderLogIDs queries for assigned blocks with the specified query
+// and scans the result set. If the scanning has been successful, then an array
+// of leader log IDs is returned. Otherwise, an error will be returned, if the
+// querying or the scanning fails.
+func (l *PostgresDB) queryAndScanLeaderLogIDs(ctx context.Context, query string,
+	args ...interface{}) ([]uint, error) {
+
+	rows, err := l.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	epochs := make([]uint, 0)
+	for rows.Next() {
+		var epoch uint
+		err = rows.Scan(&epoch)
+		if err != nil {
+			return nil, err
+		}
+		epochs = append(epochs, epoch)
+	}
+	return epochs, err
+}
+
+func (l *PostgresDB) GetRegisteredEpochs(ctx context.Context, poolID string,
+	ordering db.Ordering, limit uint) ([]uint, error) {
+
+	orderingString := "ASC"
+	if ordering == db.OrderingDesc {
+		orderingString = "DESC"
+	}
+	query := fmt.Sprintf(`SELECT epoch FROM LeaderLog WHERE poolID = $1 ORDER BY epoch %s LIMIT $2`, orderingString)
+	ids, err := l.queryAndScanLeaderLogIDs(ctx, query, poolID, limit)
+	if err != nil {
+		log.Errorf("querying for the registered epochs of pool=%s failed: %s",
+			poolID, err.Error())
+		return nil, db.ReadError
+	}
+	return ids, nil
+}
+
+// queryAndScanAssignedBlocksWithMintedBlock queries for assigned blocks with
+// the specified query and scans the result set. If the scanning has been
+// successful, then an array of assigned blocks is returned. Otherwise, an error
+// will be returned, if the querying or the scanning fails.
+func (l *PostgresDB) queryAndScanAssignedBlocksWithMintedBlock(ctx context.Context,
+	query string, args ...interface{}) ([]db.AssignedBlock, error) {
+
+	rows, err := l.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	blocks := make([]db.AssignedBlock, 0)
+	for rows.Next() {
+		block := db.AssignedBlock{}
+		var unixTimestamp int64
+		var id, epoch, epochSlot, slot, height sql.NullInt64
+		var hash, poolID, poolTicker, poolName sql.NullString
+		err = rows.Scan(&block.Epoch, &block.No, &block.Slot, &block.EpochSlot,
+			&unixTimestamp, &block.Status, &id, &epoch, &epochSlot, &slot,
+			&hash, &height, &poolID, &poolTicker, &poolName)
+		if err != nil {
+			return nil, err
+		}
+		block.Timestamp = time.Unix(unixTimestamp, 0)
+		if id.Valid {
+			mID := uint(id.Int64)
+			mEpoch := uint(epoch.Int64)
+			mEpochSlot := uint(epochSlot.Int64)
+			mSlot := uint(slot.Int64)
+			mHeight := uint(height.Int64)
+			block.RelevantBlock = &db.MintedBlock{
+				ID:         &mID,
+				Epoch:      mEpoch,
+				EpochSlot:  mEpochSlot,
+				Slot:       mSlot,
+				Hash:       hash.String,
+				Height:     mHeight,
+				PoolID:     poolID.String,
+				PoolTicker: poolTicker.String,
+				PoolName:   poolName.String,
+			}
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// queryAndScanAssignedBlocks queries for assigned blocks with the specified
+// query and scans the result set. If the scanning has been successful, then an
+// array of assigned blocks is returned. Otherwise, an error will be returned,
+// if the querying or the scanning fails.
+func (l *PostgresDB) queryAndScanAssignedBlocks(ctx context.Context, query string,
+	args ...interface{}) ([]db.AssignedBlock, error) {
+
+	rows, err := l.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	blocks := make([]db.AssignedBlock, 0)
+	for rows.Next() {
+		block := db.AssignedBlock{}
+		var unixTimestamp int64
+		err = rows.Scan(&block.Epoch, &block.No, &block.Slot, &block.EpochSlot,
+			&unixTimestamp, &block.Status)
+		if err != nil {
+			return nil, err
+		}
+		block.Timestamp = time.Unix(unixTimestamp, 0)
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// queryAndScanLeaderLogs queries for a specific leaderlog with the given query
+// and scans the  result set for one entry. If the scanning has been
+// successfully, this one leader log will be returned. Otherwise, an error will
+// be returned, if the scanning or querying failed.
+func (l *PostgresDB) queryAndScanLeaderLogs(ctx context.Context, q string,
+	args ...interface{}) (*db.LeaderLog, error) {
+
+	logRows, err := l.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer logRows.Close()
+	var leaderLog *db.LeaderLog
+	if logRows.Next() {
+		leaderLog = &db.LeaderLog{}
+		err = logRows.Scan(&leaderLog.Epoch, &leaderLog.PoolID,
+			&leaderLog.ExpectedBlockNumber, &leaderLog.MaxPerformance)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return leaderLog, nil
+}
+
+func (l *PostgresDB) GetLeaderLog(ctx context.Context, poolID string,
+	epoch uint) (*db.LeaderLog, error) {
+
+	leaderLog, err := l.queryAndScanLeaderLogs(ctx, `
+SELECT epoch, poolID, expectedBlockNr, maxPerformance FROM LeaderLog WHERE poolID = $1 and epoch = $2;
+`, poolID, epoch)
+	if err != nil {
+		log.Errorf("querying the leaderlog of pool=%s and epoch=%d failed: %s",
+			poolID, epoch, err.Error())
+		return nil, db.ReadError
+	}
+	if leaderLog != nil {
+		blocks, err := l.queryAndScanAssignedBlocks(ctx, `
+SELECT epoch, no, slotNr, slotInEpochNr, timestamp, status FROM AssignedBlock
+WHERE poolID = $1 and epoch = $2
+ORDER BY timestamp ASC;
+`, poolID, epoch)
+		if err != nil {
+			log.Errorf("querying the blocks for leaderlog of epoch=%d failed: %s",
+				leaderLog.Epoch, err.Error())
+			return nil, db.ReadError
+		}
+		leaderLog.Blocks = blocks
+	}
+	return leaderLog, nil
+}
+
+func (l *PostgresDB) GetPerformance(ctx context.Context, poolID string, from,
+	to uint) ([]db.EpochPerformance, error) {
+
+	rows, err := l.db.QueryContext(ctx, `
+SELECT l.epoch, l.expectedBlockNr, COUNT(b.no),
+	COALESCE(SUM(CASE WHEN b.status = 0 THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN b.status = 1 THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN b.status = 2 THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN b.status = 3 THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN b.status = 4 THEN 1 ELSE 0 END), 0)
+FROM LeaderLog l LEFT JOIN AssignedBlock b ON b.poolID = l.poolID AND b.epoch = l.epoch
+WHERE l.poolID = $1 AND l.epoch >= $2 AND l.epoch <= $3
+GROUP BY l.epoch, l.expectedBlockNr
+ORDER BY l.epoch ASC;
+`, poolID, from, to)
+	if err != nil {
+		log.Errorf("querying the performance of pool=%s for the epochs %d to %d failed: %s",
+			poolID, from, to, err.Error())
+		return nil, db.ReadError
+	}
+	defer rows.Close()
+	performances := make([]db.EpochPerformance, 0)
+	for rows.Next() {
+		var counts [db.GHOSTED + 1]uint
+		performance := db.EpochPerformance{}
+		err = rows.Scan(&performance.Epoch, &performance.ExpectedBlockNumber,
+			&performance.AssignedBlocks, &counts[db.NotMinted],
+			&counts[db.Minted], &counts[db.DoubleAssignment],
+			&counts[db.HeightBattle], &counts[db.GHOSTED])
+		if err != nil {
+			log.Errorf("scanning the performance of pool=%s failed: %s",
+				poolID, err.Error())
+			return nil, db.ReadError
+		}
+		performance.StatusCounts = make(map[db.BlockStatus]uint, len(counts))
+		for status, count := range counts {
+			performance.StatusCounts[db.BlockStatus(status)] = count
+		}
+		performances = append(performances, performance)
+	}
+	if err = rows.Err(); err != nil {
+		log.Errorf("scanning the performance of pool=%s failed: %s",
+			poolID, err.Error())
+		return nil, db.ReadError
+	}
+	return performances, nil
+}
+
+func (l *PostgresDB) GetAssignedBlocksAfterNow(ctx context.Context,
+	poolID string) ([]db.AssignedBlock, error) {
+
+	now := l.clock.Now()
+	blocks, err := l.queryAndScanAssignedBlocks(ctx, `
+SELECT epoch, no, slotNr, slotInEpochNr, timestamp, status FROM AssignedBlock
+WHERE poolID = $1 and timestamp > $2
+ORDER BY timestamp ASC;
+`, poolID, now.Unix())
+	if err != nil {
+		log.Errorf("querying the blocks of pool=%s after now=%v failed: %s",
+			poolID, now, err.Error())
+		return nil, db.ReadError
+	}
+	return blocks, nil
+}
+
+func (l *PostgresDB) GetAssignedBlocksBeforeNow(ctx context.Context,
+	poolID string, epoch uint) ([]db.AssignedBlock, error) {
+
+	now := l.clock.Now()
+	blocks, err := l.queryAndScanAssignedBlocksWithMintedBlock(ctx, `
+SELECT a.epoch, a.no, a.slotNr, a.slotInEpochNr, a.timestamp, a.status, m.id, m.epoch, m.slotNr, m.slotInEpochNr,
+	m.hash, m.height, m.poolID, m.poolTicker, m.poolName
+FROM AssignedBlock a LEFT JOIN MintedBlock m on a.relevant = m.id
+WHERE a.poolID = $1 and a.epoch = $2 and a.timestamp <= $3
+ORDER BY a.timestamp ASC;
+`, poolID, epoch, now.Unix())
+	if err != nil {
+		log.Errorf("querying the blocks of pool=%s and epoch=%d before now=%v failed: %s",
+			poolID, epoch, now, err.Error())
+		return nil, db.ReadError
+	}
+	return blocks, nil
+}
+
+func (l *PostgresDB) GetAssignedBlocksWithStatusBeforeNow(ctx context.Context,
+	poolID string, status db.BlockStatus, offset,
+	limit uint) ([]db.AssignedBlock, error) {
+
+	now := l.clock.Now()
+	blocks, err := l.queryAndScanAssignedBlocks(ctx, `
+SELECT epoch, no, slotNr, slotInEpochNr, timestamp, status FROM AssignedBlock
+WHERE poolID = $1 and timestamp <= $2 and status = $3
+ORDER BY timestamp ASC
+LIMIT $4
+OFFSET $5;
+`, poolID, now.Unix(), status, limit, offset)
+	if err != nil {
+		log.Errorf("querying the blocks (%d,%d) of pool=%s with status=%d before now=%v failed: %s",
+			offset, limit, poolID, status, now, err.Error())
+		return nil, db.ReadError
+	}
+	return blocks, nil
+}
+
+func (l *PostgresDB) GetClassifiedBlocksWithinWindow(ctx context.Context,
+	poolID string, height, slot uint) ([]db.AssignedBlock, error) {
+
+	blocks, err := l.queryAndScanAssignedBlocksWithMintedBlock(ctx, `
+SELECT a.epoch, a.no, a.slotNr, a.slotInEpochNr, a.timestamp, a.status, m.id, m.epoch, m.slotNr, m.slotInEpochNr,
+	m.hash, m.height, m.poolID, m.poolTicker, m.poolName
+FROM AssignedBlock a LEFT JOIN MintedBlock m on a.relevant = m.id
+WHERE a.poolID = $1 and a.status <> $2 and ((m.id IS NOT NULL and m.height >= $3) or (m.id IS NULL and a.slotNr >= $4))
+ORDER BY a.timestamp ASC;
+`, poolID, db.NotMinted, height, slot)
+	if err != nil {
+		log.Errorf("querying the classified blocks of pool=%s since height=%d and slot=%d failed: %s",
+			poolID, height, slot, err.Error())
+		return nil, db.ReadError
+	}
+	return blocks, nil
+}
+
+func (l *PostgresDB) GetStatusTransitions(ctx context.Context, poolID string,
+	epoch uint) ([]db.StatusTransition, error) {
+
+	rows, err := l.db.QueryContext(ctx, `
+SELECT id, poolID, epoch, no, slotNr, fromStatus, toStatus, fromHash, toHash, timestamp
+FROM StatusTransition
+WHERE poolID = $1 and epoch = $2
+ORDER BY id ASC;
+`, poolID, epoch)
+	if err != nil {
+		log.Errorf("querying the status transitions of epoch %d of pool=%s failed: %s",
+			epoch, poolID, err.Error())
+		return nil, db.ReadError
+	}
+	defer rows.Close()
+	transitions := make([]db.StatusTransition, 0)
+	for rows.Next() {
+		transition := db.StatusTransition{}
+		var fromHash, toHash sql.NullString
+		var unixTimestamp int64
+		err = rows.Scan(&transition.ID, &transition.PoolID, &transition.Epoch,
+			&transition.No, &transition.Slot, &transition.From, &transition.To,
+			&fromHash, &toHash, &unixTimestamp)
+		if err != nil {
+			log.Errorf("scanning the status transitions of pool=%s failed: %s",
+				poolID, err.Error())
+			return nil, db.ReadError
+		}
+		transition.FromHash = fromHash.String
+		transition.ToHash = toHash.String
+		transition.Timestamp = time.Unix(unixTimestamp, 0)
+		transitions = append(transitions, transition)
+	}
+	return transitions, nil
+}
+
+// queryAndScanRetryEntries queries for retry entries with the specified query
+// and scans the result set. If the scanning has been successful, then an array
+// of retry entries is returned. Otherwise, an error will be returned, if the
+// querying or the scanning fails.
+func (l *PostgresDB) queryAndScanRetryEntries(ctx context.Context, query string,
+	args ...interface{}) ([]db.RetryEntry, error) {
+
+	rows, err := l.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := make([]db.RetryEntry, 0)
+	for rows.Next() {
+		entry := db.RetryEntry{}
+		var unixTimestamp, unixNextAttempt int64
+		err = rows.Scan(&entry.Block.Epoch, &entry.Block.No, &entry.Block.Slot,
+			&entry.Block.EpochSlot, &unixTimestamp, &entry.Block.Status,
+			&entry.Attempts, &unixNextAttempt, &entry.LastError)
+		if err != nil {
+			return nil, err
+		}
+		entry.Block.Timestamp = time.Unix(unixTimestamp, 0)
+		if unixNextAttempt > 0 {
+			entry.NextAttempt = time.Unix(unixNextAttempt, 0)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (l *PostgresDB) GetRetryEntries(ctx context.Context,
+	poolID string) ([]db.RetryEntry, error) {
+
+	entries, err := l.queryAndScanRetryEntries(ctx, `
+SELECT a.epoch, a.no, a.slotNr, a.slotInEpochNr, a.timestamp, a.status, r.attempts, r.nextAttempt, r.lastError
+FROM RetryQueue r JOIN AssignedBlock a on r.poolID = a.poolID and r.epoch = a.epoch and r.slotNr = a.slotNr
+WHERE r.poolID = $1
+ORDER BY r.nextAttempt ASC;
+`, poolID)
+	if err != nil {
+		log.Errorf("querying the queued retries of pool=%s failed: %s",
+			poolID, err.Error())
+		return nil, db.ReadError
+	}
+	return entries, nil
+}
+
+func (l *PostgresDB) GetRetryEntry(ctx context.Context, poolID string, epoch,
+	no uint) (*db.RetryEntry, error) {
+
+	entries, err := l.queryAndScanRetryEntries(ctx, `
+SELECT a.epoch, a.no, a.slotNr, a.slotInEpochNr, a.timestamp, a.status, r.attempts, r.nextAttempt, r.lastError
+FROM RetryQueue r JOIN AssignedBlock a on r.poolID = a.poolID and r.epoch = a.epoch and r.slotNr = a.slotNr
+WHERE a.poolID = $1 and a.epoch = $2 and a.no = $3;
+`, poolID, epoch, no)
+	if err != nil {
+		log.Errorf("querying the queued retry of block (%d,%d) of pool=%s failed: %s",
+			epoch, no, poolID, err.Error())
+		return nil, db.ReadError
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+func (l *PostgresDB) GetStakePool(ctx context.Context,
+	hexID string) (*db.StakePool, error) {
+
+	pool := db.StakePool{}
+	var updatedAt int64
+	err := l.db.QueryRowContext(ctx, `
+SELECT hexID, ticker, name, updatedAt FROM StakePool WHERE hexID = $1;
+`, hexID).Scan(&pool.HexID, &pool.Ticker, &pool.Name, &updatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Errorf("querying the metadata of pool=%s failed: %s", hexID,
+			err.Error())
+		return nil, db.ReadError
+	}
+	pool.UpdatedAt = time.Unix(updatedAt, 0)
+	return &pool, nil
+}
+
+func (l *PostgresDB) GetEventsAfter(ctx context.Context, poolID string, id,
+	limit uint) ([]db.Event, error) {
+
+	rows, err := l.db.QueryContext(ctx, `
+SELECT id, poolID, type, epoch, no, status, timestamp FROM Event
+WHERE poolID = $1 and id > $2
+ORDER BY id ASC
+LIMIT $3;
+`, poolID, id, limit)
+	if err != nil {
+		log.Errorf("querying the events of pool=%s after id=%d failed: %s",
+			poolID, id, err.Error())
+		return nil, db.ReadError
+	}
+	defer rows.Close()
+	events := make([]db.Event, 0)
+	for rows.Next() {
+		event := db.Event{}
+		var unixTimestamp int64
+		err = rows.Scan(&event.ID, &event.PoolID, &event.Type, &event.Epoch,
+			&event.No, &event.Status, &unixTimestamp)
+		if err != nil {
+			log.Errorf("scanning the events of pool=%s failed: %s", poolID,
+				err.Error())
+			return nil, db.ReadError
+		}
+		event.Timestamp = time.Unix(unixTimestamp, 0)
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (l *PostgresDB) GetLastEventID(ctx context.Context, poolID string) (uint, error) {
+	var id sql.NullInt64
+	err := l.db.QueryRowContext(ctx, `
+SELECT MAX(id) FROM Event WHERE poolID = $1;
+`, poolID).Scan(&id)
+	if err != nil {
+		log.Errorf("querying the last event of pool=%s failed: %s", poolID,
+			err.Error())
+		return 0, db.ReadError
+	}
+	return uint(id.Int64), nil
+}
+
+// webhookDeliveryColumns are the columns of a webhook delivery in the order,
+// in which they are scanned by scanWebhookDeliveries.
+const webhookDeliveryColumns = `id, url, poolID, epoch, no, status, transitionID, payload, state, attempts,
+	nextAttempt, lastError, responseStatus, createdAt, updatedAt`
+
+// scanWebhookDeliveries scans the given result set of webhook deliveries,
+// whose columns are the webhookDeliveryColumns. An error will be returned, if
+// the scanning fails.
+func scanWebhookDeliveries(rows *sql.Rows) ([]db.WebhookDelivery, error) {
+	deliveries := make([]db.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery := db.WebhookDelivery{}
+		var nextAttempt, createdAt, updatedAt int64
+		err := rows.Scan(&delivery.ID, &delivery.URL, &delivery.PoolID,
+			&delivery.Epoch, &delivery.No, &delivery.Status,
+			&delivery.TransitionID, &delivery.Payload, &delivery.State,
+			&delivery.Attempts, &nextAttempt, &delivery.LastError,
+			&delivery.ResponseStatus, &createdAt, &updatedAt)
+		if err != nil {
+			return nil, err
+		}
+		delivery.NextAttempt = time.Unix(nextAttempt, 0)
+		delivery.CreatedAt = time.Unix(createdAt, 0)
+		delivery.UpdatedAt = time.Unix(updatedAt, 0)
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (l *PostgresDB) GetWebhookDeliveries(ctx context.Context, offset,
+	limit uint) ([]db.WebhookDelivery, error) {
+
+	rows, err := l.db.QueryContext(ctx, `
+SELECT `+webhookDeliveryColumns+` FROM WebhookDelivery
+ORDER BY id DESC
+LIMIT $1 OFFSET $2;
+`, limit, offset)
+	if err != nil {
+		log.Errorf("querying the webhook deliveries failed: %s", err.Error())
+		return nil, db.ReadError
+	}
+	defer rows.Close()
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		log.Errorf("scanning the webhook deliveries failed: %s", err.Error())
+		return nil, db.ReadError
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,605 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// insertMintedBlock inserts the given minted block within the given
+// transaction, and returns the ID of the inserted row.
+func insertMintedBlock(ctx context.Context, tx *sql.Tx,
+	block *db.MintedBlock) (uint, error) {
+
+	var id int64
+	err := tx.QueryRowContext(ctx, `
+INSERT INTO MintedBlock (epoch, slotNr, slotInEpochNr, hash, height, poolID, poolTicker, poolName)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id;
+`, block.Epoch, block.Slot, block.EpochSlot, block.Hash, block.Height,
+		block.PoolID, block.PoolTicker, block.PoolName).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// assignmentState is the synced state of an assigned block, which shall be
+// preserved when a leader log is replaced.
+type assignmentState struct {
+	status   db.BlockStatus
+	relevant *uint
+}
+
+// queryAssignmentStates queries the state of all the assigned blocks of the
+// given pool and epoch within the given transaction. The states are mapped to
+// the slot number of the assigned block.
+func queryAssignmentStates(ctx context.Context, tx *sql.Tx, poolID string,
+	epoch uint) (map[uint]assignmentState, error) {
+
+	rows, err := tx.QueryContext(ctx, `
+SELECT slotNr, status, relevant FROM AssignedBlock WHERE poolID = $1 and epoch = $2;
+`, poolID, epoch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	states := make(map[uint]assignmentState)
+	for rows.Next() {
+		var slot uint
+		var state assignmentState
+		var relevant sql.NullInt64
+		err = rows.Scan(&slot, &state.status, &relevant)
+		if err != nil {
+			return nil, err
+		}
+		if relevant.Valid {
+			id := uint(relevant.Int64)
+			state.relevant = &id
+		}
+		states[slot] = state
+	}
+	return states, rows.Err()
+}
+
+func (l *PostgresDB) WriteLeaderLog(ctx context.Context,
+	leaderLog *db.LeaderLog) (*db.LeaderLogDiff, error) {
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Errorf("couldn't start a transaction to write the leaderlog of epoch '%d': %s",
+			leaderLog.Epoch, err.Error())
+		return nil, db.WriteError
+	}
+	_, err = tx.ExecContext(ctx, `
+SELECT 1 FROM LeaderLog WHERE poolID = $1 and epoch = $2 FOR UPDATE;
+`, leaderLog.PoolID, leaderLog.Epoch)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("locking the leaderlog of epoch '%d' failed: %s",
+			leaderLog.Epoch, err.Error())
+		return nil, db.WriteError
+	}
+	states, err := queryAssignmentStates(ctx, tx, leaderLog.PoolID,
+		leaderLog.Epoch)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("querying the assigned blocks of epoch '%d' failed: %s",
+			leaderLog.Epoch, err.Error())
+		return nil, db.WriteError
+	}
+	result, err := tx.ExecContext(ctx, `
+UPDATE LeaderLog SET expectedBlockNr = $1, maxPerformance = $2 WHERE poolID = $3 and epoch = $4;
+`, leaderLog.ExpectedBlockNumber, leaderLog.MaxPerformance, leaderLog.PoolID,
+		leaderLog.Epoch)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("updating the leaderlog of epoch '%d' failed: %s",
+			leaderLog.Epoch, err.Error())
+		return nil, db.WriteError
+	}
+	updatedRows, err := result.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("updating the leaderlog of epoch '%d' failed: %s",
+			leaderLog.Epoch, err.Error())
+		return nil, db.WriteError
+	}
+	diff := &db.LeaderLogDiff{
+		PoolID:   leaderLog.PoolID,
+		Epoch:    leaderLog.Epoch,
+		Replaced: updatedRows > 0,
+		Added:    []uint{},
+		Removed:  []uint{},
+		Kept:     []uint{},
+	}
+	if diff.Replaced {
+		_, err = tx.ExecContext(ctx, `
+DELETE FROM AssignedBlock WHERE poolID = $1 and epoch = $2;
+`, leaderLog.PoolID, leaderLog.Epoch)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+INSERT INTO LeaderLog (poolID, epoch, expectedBlockNr, maxPerformance) VALUES ($1, $2, $3, $4)
+`, leaderLog.PoolID, leaderLog.Epoch, leaderLog.ExpectedBlockNumber,
+			leaderLog.MaxPerformance)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("writing the leaderlog of epoch '%d' failed: %s",
+			leaderLog.Epoch, err.Error())
+		return nil, db.WriteError
+	}
+	insertAssignmentStmt, err := tx.PrepareContext(ctx, `
+INSERT INTO AssignedBlock (poolID, epoch, no, slotNr, slotInEpochNr, timestamp, status, relevant) VALUES ($1,$2,$3,$4,$5,$6,$7,$8);
+`)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("preparing the query for assigned block insertion for epoch '%d' failed: %s",
+			leaderLog.Epoch, err.Error())
+		return nil, db.WriteError
+	}
+	defer insertAssignmentStmt.Close()
+	for _, block := range leaderLog.Blocks {
+		state, found := states[block.Slot]
+		if found {
+			delete(states, block.Slot)
+			diff.Kept = append(diff.Kept, block.Slot)
+		} else {
+			diff.Added = append(diff.Added, block.Slot)
+		}
+		_, err = insertAssignmentStmt.ExecContext(ctx, leaderLog.PoolID,
+			leaderLog.Epoch, block.No, block.Slot, block.EpochSlot, block.Timestamp.Unix(),
+			state.status, state.relevant)
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("assigned block insertion for epoch '%d' failed: %s",
+				leaderLog.Epoch, err.Error())
+			return nil, db.WriteError
+		}
+	}
+	for slot, state := range states {
+		diff.Removed = append(diff.Removed, slot)
+		_, err = tx.ExecContext(ctx, `
+DELETE FROM StatusTransition WHERE poolID = $1 and epoch = $2 and slotNr = $3;
+`, leaderLog.PoolID, leaderLog.Epoch, slot)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, `
+DELETE FROM RetryQueue WHERE poolID = $1 and epoch = $2 and slotNr = $3;
+`, leaderLog.PoolID, leaderLog.Epoch, slot)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("removing the history of removed slot %d failed: %s",
+				slot, err.Error())
+			return nil, db.WriteError
+		}
+		if state.relevant == nil {
+			continue
+		}
+		_, err = tx.ExecContext(ctx, `
+DELETE FROM MintedBlock WHERE id = $1;
+`, *state.relevant)
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("removing the minted block of removed slot %d failed: %s",
+				slot, err.Error())
+			return nil, db.WriteError
+		}
+	}
+	sortSlots(diff.Added)
+	sortSlots(diff.Removed)
+	sortSlots(diff.Kept)
+	msg := db.ObserverMessage{
+		Code: db.ObserveNewLeaderLog,
+		Response: db.LeaderLogRef{
+			PoolID: leaderLog.PoolID,
+			Epoch:  leaderLog.Epoch,
+		},
+	}
+	if diff.Replaced {
+		msg = db.ObserverMessage{
+			Code:     db.ObserveReplacedLeaderLog,
+			Response: diff,
+		}
+	}
+	err = recordEvent(ctx, tx, &db.Event{
+		PoolID:    leaderLog.PoolID,
+		Type:      db.EventEpochRegistered,
+		Epoch:     leaderLog.Epoch,
+		Timestamp: l.clock.Now(),
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("recording the registration of epoch '%d' failed: %s",
+			leaderLog.Epoch, err.Error())
+		return nil, db.WriteError
+	}
+	err = notify(ctx, tx, msg)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("notifying about the leaderlog of epoch '%d' failed: %s",
+			leaderLog.Epoch, err.Error())
+		return nil, db.WriteError
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, db.WriteError
+	}
+	return diff, nil
+}
+
+// sortSlots sorts the given list of slots in ascending order.
+func sortSlots(slots []uint) {
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i] < slots[j]
+	})
+}
+
+func (l *PostgresDB) WriteRetryEntry(ctx context.Context, poolID string,
+	entry *db.RetryEntry) error {
+
+	var nextAttempt int64
+	if !entry.NextAttempt.IsZero() {
+		nextAttempt = entry.NextAttempt.Unix()
+	}
+	_, err := l.db.ExecContext(ctx, `
+INSERT INTO RetryQueue (poolID, epoch, slotNr, attempts, nextAttempt, lastError)
+SELECT poolID, epoch, slotNr, $1::bigint, $2::bigint, $3::text FROM AssignedBlock WHERE poolID = $4 and epoch = $5 and no = $6
+ON CONFLICT (poolID, epoch, slotNr) DO UPDATE SET attempts = excluded.attempts,
+	nextAttempt = excluded.nextAttempt, lastError = excluded.lastError;
+`, entry.Attempts, nextAttempt, entry.LastError, poolID, entry.Block.Epoch,
+		entry.Block.No)
+	if err != nil {
+		log.Errorf("queueing the retry of block (%d,%d) of pool=%s failed: %s",
+			entry.Block.Epoch, entry.Block.No, poolID, err.Error())
+		return db.WriteError
+	}
+	return nil
+}
+
+func (l *PostgresDB) UpdateStatusForAssignment(ctx context.Context,
+	poolID string, epoch, no uint, status db.BlockStatus,
+	mintedBlock *db.MintedBlock) error {
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Errorf("couldn't start a transaction to update status of block (%d,%d): %s",
+			epoch, no, err.Error())
+		return db.WriteError
+	}
+	var slot uint
+	var fromStatus db.BlockStatus
+	var fromID sql.NullInt64
+	var fromHash sql.NullString
+	err = tx.QueryRowContext(ctx, `
+SELECT a.slotNr, a.status, a.relevant, m.hash
+FROM AssignedBlock a LEFT JOIN MintedBlock m on a.relevant = m.id
+WHERE a.poolID = $1 and a.epoch = $2 and a.no = $3
+FOR UPDATE OF a;
+`, poolID, epoch, no).Scan(&slot, &fromStatus, &fromID, &fromHash)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("querying the status of block (%d,%d) failed: %s",
+			epoch, no, err.Error())
+		return db.WriteError
+	}
+	var mintedBlockID *uint
+	var toHash sql.NullString
+	if mintedBlock != nil {
+		id, err := insertMintedBlock(ctx, tx, mintedBlock)
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("inserting minted block with hash '%s' failed: %s",
+				mintedBlock.Hash, err.Error())
+			return db.WriteError
+		}
+		mintedBlockID = &id
+		toHash = sql.NullString{String: mintedBlock.Hash, Valid: true}
+	}
+	_, err = tx.ExecContext(ctx, `
+UPDATE AssignedBlock SET status = $1, relevant = $2 WHERE poolID = $3 and epoch = $4 and no = $5;
+`, status, mintedBlockID, poolID, epoch, no)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("setting the status=%v of block (%d,%d) failed: %s",
+			status, epoch, no, err.Error())
+		return db.WriteError
+	}
+	if fromID.Valid && (mintedBlockID == nil || uint(fromID.Int64) != *mintedBlockID) {
+		_, err = tx.ExecContext(ctx, `
+DELETE FROM MintedBlock WHERE id = $1;
+`, fromID.Int64)
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("removing the replaced minted block of block (%d,%d) failed: %s",
+				epoch, no, err.Error())
+			return db.WriteError
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+DELETE FROM RetryQueue WHERE poolID = $1 and epoch = $2 and slotNr = $3;
+`, poolID, epoch, slot)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("resolving the queued retry of block (%d,%d) failed: %s",
+			epoch, no, err.Error())
+		return db.WriteError
+	}
+	transition := &db.StatusTransition{
+		PoolID:    poolID,
+		Epoch:     epoch,
+		No:        no,
+		Slot:      slot,
+		From:      fromStatus,
+		To:        status,
+		FromHash:  fromHash.String,
+		ToHash:    toHash.String,
+		Timestamp: l.clock.Now(),
+	}
+	changed := transition.From != transition.To ||
+		transition.FromHash != transition.ToHash
+	if changed {
+		err = tx.QueryRowContext(ctx, `
+INSERT INTO StatusTransition (poolID, epoch, no, slotNr, fromStatus, toStatus, fromHash, toHash, timestamp) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+RETURNING id;
+`, poolID, epoch, no, slot, transition.From, transition.To, fromHash, toHash,
+			transition.Timestamp.Unix()).Scan(&transition.ID)
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("recording the status transition of block (%d,%d) failed: %s",
+				epoch, no, err.Error())
+			return db.WriteError
+		}
+	}
+	if changed || fromStatus == db.NotMinted {
+		err = recordEvent(ctx, tx, &db.Event{
+			PoolID:    poolID,
+			Type:      db.EventBlockStatus,
+			Epoch:     epoch,
+			No:        no,
+			Status:    status,
+			Timestamp: transition.Timestamp,
+		})
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("recording the status event of block (%d,%d) failed: %s",
+				epoch, no, err.Error())
+			return db.WriteError
+		}
+	}
+	if fromStatus != db.NotMinted {
+		if changed {
+			err = notify(ctx, tx, db.ObserverMessage{
+				Code:     db.ObserveReclassifiedBlock,
+				Response: transition,
+			})
+		}
+	} else {
+		err = notify(ctx, tx, db.ObserverMessage{
+			Code: db.ObserveUpdatedBlockStatus,
+			Response: db.AssignmentRef{
+				PoolID: poolID,
+				Epoch:  epoch,
+				No:     no,
+			},
+		})
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("notifying about the status of block (%d,%d) failed: %s",
+			epoch, no, err.Error())
+		return db.WriteError
+	}
+	err = tx.Commit()
+	if err != nil {
+		return db.WriteError
+	}
+	return nil
+}
+
+func (l *PostgresDB) DeleteLeaderLog(ctx context.Context, poolID string,
+	epoch uint) (bool, error) {
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Errorf("couldn't start a transaction to delete the leaderlog of epoch '%d': %s",
+			epoch, err.Error())
+		return false, db.WriteError
+	}
+	states, err := queryAssignmentStates(ctx, tx, poolID, epoch)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("querying the assigned blocks of epoch '%d' failed: %s",
+			epoch, err.Error())
+		return false, db.WriteError
+	}
+	_, err = tx.ExecContext(ctx, `
+DELETE FROM AssignedBlock WHERE poolID = $1 and epoch = $2;
+`, poolID, epoch)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("deleting the assigned blocks of epoch '%d' failed: %s",
+			epoch, err.Error())
+		return false, db.WriteError
+	}
+	for _, state := range states {
+		if state.relevant == nil {
+			continue
+		}
+		_, err = tx.ExecContext(ctx, `
+DELETE FROM MintedBlock WHERE id = $1;
+`, *state.relevant)
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("deleting the minted blocks of epoch '%d' failed: %s",
+				epoch, err.Error())
+			return false, db.WriteError
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+DELETE FROM StatusTransition WHERE poolID = $1 and epoch = $2;
+`, poolID, epoch)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, `
+DELETE FROM RetryQueue WHERE poolID = $1 and epoch = $2;
+`, poolID, epoch)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("deleting the history of epoch '%d' failed: %s",
+			epoch, err.Error())
+		return false, db.WriteError
+	}
+	result, err := tx.ExecContext(ctx, `
+DELETE FROM LeaderLog WHERE poolID = $1 and epoch = $2;
+`, poolID, epoch)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("deleting the leaderlog of epoch '%d' failed: %s",
+			epoch, err.Error())
+		return false, db.WriteError
+	}
+	deletedRows, err := result.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("deleting the leaderlog of epoch '%d' failed: %s",
+			epoch, err.Error())
+		return false, db.WriteError
+	}
+	if deletedRows > 0 {
+		err = recordEvent(ctx, tx, &db.Event{
+			PoolID:    poolID,
+			Type:      db.EventEpochDeleted,
+			Epoch:     epoch,
+			Timestamp: l.clock.Now(),
+		})
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("recording the deletion of epoch '%d' failed: %s",
+				epoch, err.Error())
+			return false, db.WriteError
+		}
+		err = notify(ctx, tx, db.ObserverMessage{
+			Code: db.ObserveDeletedLeaderLog,
+			Response: db.LeaderLogRef{
+				PoolID: poolID,
+				Epoch:  epoch,
+			},
+		})
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("notifying about the deletion of epoch '%d' failed: %s",
+				epoch, err.Error())
+			return false, db.WriteError
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return false, db.WriteError
+	}
+	return deletedRows > 0, nil
+}
+
+func (l *PostgresDB) WriteStakePool(ctx context.Context, pool *db.StakePool) error {
+	_, err := l.db.ExecContext(ctx, `
+INSERT INTO StakePool (hexID, ticker, name, updatedAt) VALUES ($1, $2, $3, $4)
+ON CONFLICT (hexID) DO UPDATE SET ticker = excluded.ticker, name = excluded.name,
+	updatedAt = excluded.updatedAt;
+`, pool.HexID, pool.Ticker, pool.Name, pool.UpdatedAt.Unix())
+	if err != nil {
+		log.Errorf("writing the metadata of pool=%s failed: %s", pool.HexID,
+			err.Error())
+		return db.WriteError
+	}
+	return nil
+}
+
+// recordEvent records the given event within the given transaction. The event
+// is only recorded, if the transaction is committed.
+func recordEvent(ctx context.Context, tx *sql.Tx, event *db.Event) error {
+	_, err := tx.ExecContext(ctx, `
+INSERT INTO Event (poolID, type, epoch, no, status, timestamp) VALUES ($1, $2, $3, $4, $5, $6);
+`, event.PoolID, event.Type, event.Epoch, event.No, event.Status,
+		event.Timestamp.Unix())
+	return err
+}
+
+func (l *PostgresDB) QueueWebhookDelivery(ctx context.Context,
+	delivery *db.WebhookDelivery) (bool, error) {
+
+	now := l.clock.Now().Unix()
+	result, err := l.db.ExecContext(ctx, `
+INSERT INTO WebhookDelivery (url, poolID, epoch, no, status, transitionID, payload, state, attempts, nextAttempt,
+	lastError, responseStatus, createdAt, updatedAt)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 0, $9, '', 0, $9, $9)
+ON CONFLICT (url, poolID, epoch, no, status, transitionID) DO NOTHING;
+`, delivery.URL, delivery.PoolID, delivery.Epoch, delivery.No,
+		delivery.Status, delivery.TransitionID, delivery.Payload,
+		db.DeliveryPending, now)
+	if err != nil {
+		log.Errorf("queueing the webhook delivery for block (%d,%d) of pool=%s failed: %s",
+			delivery.Epoch, delivery.No, delivery.PoolID, err.Error())
+		return false, db.WriteError
+	}
+	queuedRows, err := result.RowsAffected()
+	if err != nil {
+		log.Errorf("queueing the webhook delivery for block (%d,%d) of pool=%s failed: %s",
+			delivery.Epoch, delivery.No, delivery.PoolID, err.Error())
+		return false, db.WriteError
+	}
+	return queuedRows > 0, nil
+}
+
+func (l *PostgresDB) ClaimWebhookDeliveries(ctx context.Context,
+	lease time.Duration, limit uint) ([]db.WebhookDelivery, error) {
+
+	now := l.clock.Now()
+	rows, err := l.db.QueryContext(ctx, `
+UPDATE WebhookDelivery SET nextAttempt = $1
+WHERE id IN (
+	SELECT id FROM WebhookDelivery
+	WHERE state = $2 and nextAttempt <= $3
+	ORDER BY nextAttempt ASC, id ASC
+	LIMIT $4
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING `+webhookDeliveryColumns+`;
+`, now.Add(lease).Unix(), db.DeliveryPending, now.Unix(), limit)
+	if err != nil {
+		log.Errorf("claiming the due webhook deliveries failed: %s", err.Error())
+		return nil, db.WriteError
+	}
+	defer rows.Close()
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		log.Errorf("scanning the claimed webhook deliveries failed: %s",
+			err.Error())
+		return nil, db.WriteError
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ID < deliveries[j].ID
+	})
+	return deliveries, nil
+}
+
+func (l *PostgresDB) UpdateWebhookDelivery(ctx context.Context,
+	delivery *db.WebhookDelivery) error {
+
+	_, err := l.db.ExecContext(ctx, `
+UPDATE WebhookDelivery SET state = $1, attempts = $2, nextAttempt = $3, lastError = $4, responseStatus = $5,
+	updatedAt = $6
+WHERE id = $7;
+`, delivery.State, delivery.Attempts, delivery.NextAttempt.Unix(),
+		delivery.LastError, delivery.ResponseStatus, l.clock.Now().Unix(),
+		delivery.ID)
+	if err != nil {
+		log.Errorf("updating the webhook delivery with id=%d failed: %s",
+			delivery.ID, err.Error())
+		return db.WriteError
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// migrationFiles are the up-migrations of the schema. The name of each file
+// starts with the version of the schema, to which the file migrates, followed
+// by an underscore and a short description (e.g. '0002_status_transitions').
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is a migration of the database schema to a certain version.
+type Migration struct {
+	// Version is the version of the schema after this migration.
+	Version uint
+	// Name is a short description of the migration.
+	Name string
+	// SQL are the statements of the migration.
+	SQL string
+}
+
+// loadMigrations loads all the embedded migrations in ascending order of their
+// version. An error will be returned, if the name of a file doesn't follow the
+// naming scheme, or if a version isn't unique.
+func loadMigrations() ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		parts := strings.SplitN(name, "_", 2)
+		version, err := strconv.ParseUint(parts[0], 10, 32)
+		if err != nil || len(parts) != 2 || version == 0 {
+			return nil, fmt.Errorf("the migration file '%s' must be named '<version>_<name>.sql'",
+				entry.Name())
+		}
+		content, err := migrationFiles.ReadFile(path.Join("migrations",
+			entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{
+			Version: uint(version),
+			Name:    parts[1],
+			SQL:     string(content),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("the migration version %d isn't unique",
+				migrations[i].Version)
+		}
+	}
+	return migrations, nil
+}
+
+// hasColumn checks whether the table with the given name has a column with the
+// given name. False is returned, if the table doesn't exist.
+func hasColumn(ctx context.Context, tx *sql.Tx, table, column string) (bool, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT name FROM pragma_table_info(?);`,
+		table)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// hasTable checks whether a table with the given name exists.
+func hasTable(ctx context.Context, tx *sql.Tx, table string) (bool, error) {
+	var n int
+	err := tx.QueryRowContext(ctx, `
+SELECT count(*) FROM sqlite_master WHERE type = 'table' and name = ?;
+`, table).Scan(&n)
+	return n > 0, err
+}
+
+// detectLegacyVersion detects the version of a schema that has been created
+// before the versions of the schema have been tracked. Zero is returned, if
+// the database is empty.
+func detectLegacyVersion(ctx context.Context, tx *sql.Tx) (uint, error) {
+	checks := []struct {
+		version uint
+		check   func() (bool, error)
+	}{
+		{4, func() (bool, error) {
+			return hasColumn(ctx, tx, "AssignedBlock", "poolID")
+		}},
+		{3, func() (bool, error) { return hasTable(ctx, tx, "RetryQueue") }},
+		{2, func() (bool, error) { return hasTable(ctx, tx, "StatusTransition") }},
+		{1, func() (bool, error) { return hasTable(ctx, tx, "LeaderLog") }},
+	}
+	for _, c := range checks {
+		found, err := c.check()
+		if err != nil {
+			return 0, err
+		}
+		if found {
+			return c.version, nil
+		}
+	}
+	return 0, nil
+}
+
+// schemaVersion gets the current version of the schema within the given
+// transaction. The version of a legacy schema is detected and recorded, if the
+// versions haven't been tracked yet, unless the given transaction shall only
+// be read.
+func schemaVersion(ctx context.Context, tx *sql.Tx, migrations []Migration,
+	readOnly bool) (uint, error) {
+
+	tracked, err := hasTable(ctx, tx, "schema_version")
+	if err != nil {
+		return 0, err
+	}
+	if tracked {
+		var version sql.NullInt64
+		err = tx.QueryRowContext(ctx, `
+SELECT max(version) FROM schema_version;
+`).Scan(&version)
+		return uint(version.Int64), err
+	}
+	version, err := detectLegacyVersion(ctx, tx)
+	if err != nil || readOnly {
+		return version, err
+	}
+	_, err = tx.ExecContext(ctx, `
+CREATE TABLE schema_version (
+	version INTEGER NOT NULL PRIMARY KEY,
+	name TEXT NOT NULL,
+	appliedAt INTEGER NOT NULL
+);
+`)
+	if err != nil {
+		return 0, err
+	}
+	if version > 0 {
+		log.Infof("detected the untracked schema version %d", version)
+	}
+	for _, migration := range migrations {
+		if migration.Version > version {
+			break
+		}
+		err = recordMigration(ctx, tx, migration)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return version, nil
+}
+
+// recordMigration records the given migration as applied.
+func recordMigration(ctx context.Context, tx *sql.Tx, migration Migration) error {
+	_, err := tx.ExecContext(ctx, `
+INSERT INTO schema_version (version, name, appliedAt) VALUES (?, ?, ?);
+`, migration.Version, migration.Name, time.Now().Unix())
+	return err
+}
+
+// migrate applies all the pending migrations to the given database in
+// ascending order of their version. Each migration is applied in its own
+// transaction. The pending migrations are only returned without being applied,
+// if dryRun is true. An error will be returned, if a migration failed, and the
+// database then stays at the version of the last successful migration.
+func migrate(sqlDB *sql.DB, dryRun bool) ([]Migration, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	version, err := schemaVersion(ctx, tx, migrations, dryRun)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]Migration, 0)
+	for _, migration := range migrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+	if dryRun {
+		return pending, nil
+	}
+	for _, migration := range pending {
+		err = applyMigration(ctx, sqlDB, migration)
+		if err != nil {
+			return nil, fmt.Errorf("migration to version %d (%s) failed: %s",
+				migration.Version, migration.Name, err.Error())
+		}
+		log.Infof("migrated the database to version %d (%s)",
+			migration.Version, migration.Name)
+	}
+	return pending, nil
+}
+
+// applyMigration applies the given migration in a transaction, and records it
+// as applied.
+func applyMigration(ctx context.Context, sqlDB *sql.DB, migration Migration) error {
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, migration.SQL)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	err = recordMigration(ctx, tx, migration)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Migrate applies all the pending migrations to the SQLite database at the
+// given path, and returns them. The pending migrations are only returned
+// without being applied, if dryRun is true. The database is created, if it
+// doesn't exist yet.
+func Migrate(path string, dryRun bool) ([]Migration, error) {
+	sqlDB, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDB.Close()
+	return migrate(sqlDB, dryRun)
+}
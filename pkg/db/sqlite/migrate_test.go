@@ -0,0 +1,146 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+)
+
+const poolA = "cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214b"
+
+// newBaselineDB creates a database in a new directory, which is seeded with
+// the baseline fixture in testdata, and returns the path of the directory.
+func newBaselineDB(t *testing.T) string {
+	statements, err := os.ReadFile("testdata/baseline.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := t.TempDir()
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(path, "sql.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	_, err = sqlDB.Exec(string(statements))
+	if err != nil {
+		t.Fatalf("couldn't seed the baseline fixture: %s", err.Error())
+	}
+	return path
+}
+
+// latestVersion returns the version of the latest migration.
+func latestVersion(t *testing.T) uint {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// versions returns the versions of the given migrations.
+func versions(migrations []Migration) []uint {
+	v := make([]uint, len(migrations))
+	for i, migration := range migrations {
+		v[i] = migration.Version
+	}
+	return v
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	path := newBaselineDB(t)
+	applied, err := Migrate(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := latestVersion(t)
+	if len(applied) != int(latest)-1 || applied[0].Version != 2 {
+		t.Fatalf("expected the migrations 2 to %d to be applied, got %v",
+			latest, versions(applied))
+	}
+	pending, err := Migrate(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("expected no pending migrations, got %v", versions(pending))
+	}
+
+	clk := clock.NewFake(time.Unix(1647467241, 0))
+	idb, err := NewSQLiteDB(path, clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idb.Close()
+	ctx := context.Background()
+	leaderLog, err := idb.GetLeaderLog(ctx, poolA, 327)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaderLog == nil || leaderLog.ExpectedBlockNumber != 2.5 ||
+		leaderLog.MaxPerformance != 1.2 || len(leaderLog.Blocks) != 2 {
+		t.Fatalf("expected the leader log of epoch 327 to be readable, got %+v",
+			leaderLog)
+	}
+	blocks, err := idb.GetAssignedBlocksBeforeNow(ctx, poolA, 327)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 1 {
+		t.Fatalf("expected one assigned block before now, got %+v", blocks)
+	}
+	block := blocks[0]
+	if block.No != 1 || block.Slot != 55900900 || block.Status != db.Minted ||
+		block.Timestamp.Unix() != 1647467191 {
+		t.Errorf("expected the minted block in slot 55900900, got %+v", block)
+	}
+	relevant := block.RelevantBlock
+	if relevant == nil || relevant.Hash != "0a02" ||
+		relevant.Height != 7000001 || relevant.PoolID != poolA {
+		t.Errorf("expected the relevant block 0a02 of pool %s, got %+v", poolA,
+			relevant)
+	}
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	path := newBaselineDB(t)
+	pending, err := Migrate(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := latestVersion(t)
+	if len(pending) != int(latest)-1 || pending[0].Version != 2 {
+		t.Fatalf("expected the migrations 2 to %d to be pending, got %v",
+			latest, versions(pending))
+	}
+	// the dry run mustn't change the database, not even record the detected
+	// version of the schema.
+	sqlDB, err := openFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	var n int
+	err = sqlDB.QueryRow(`
+SELECT count(*) FROM sqlite_master WHERE type = 'table';
+`).Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Errorf("expected the baseline tables to be unchanged, got %d tables", n)
+	}
+	pending, err = Migrate(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != int(latest)-1 {
+		t.Errorf("expected the migrations to be still pending, got %v",
+			versions(pending))
+	}
+}
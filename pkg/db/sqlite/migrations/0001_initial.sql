@@ -0,0 +1,29 @@
+CREATE TABLE LeaderLog (
+	epoch INTEGER NOT NULL PRIMARY KEY,
+	poolID TEXT NOT NULL,
+	expectedBlockNr REAL NOT NULL,
+	maxPerformance REAL NOT NULL
+);
+
+CREATE TABLE MintedBlock (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	epoch INTEGER NOT NULL,
+	slotNr INTEGER NOT NULL,
+	slotInEpochNr INTEGER NOT NULL,
+	hash TEXT NOT NULL,
+	height INTEGER NOT NULL,
+	poolID TEXT NOT NULL
+);
+
+CREATE TABLE AssignedBlock (
+	epoch INTEGER NOT NULL,
+	no INTEGER NOT NULL,
+	slotNr INTEGER NOT NULL,
+	slotInEpochNr INTEGER NOT NULL,
+	timestamp INTEGER NOT NULL,
+	status INTEGER DEFAULT 0,
+	relevant INTEGER,
+	PRIMARY KEY(epoch, no),
+	FOREIGN KEY (epoch) REFERENCES LeaderLog(epoch) ON DELETE CASCADE,
+	FOREIGN KEY (relevant) REFERENCES MintedBlock(id) ON DELETE CASCADE
+);
@@ -0,0 +1,11 @@
+CREATE TABLE StatusTransition (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	epoch INTEGER NOT NULL,
+	no INTEGER NOT NULL,
+	slotNr INTEGER NOT NULL,
+	fromStatus INTEGER NOT NULL,
+	toStatus INTEGER NOT NULL,
+	fromHash TEXT,
+	toHash TEXT,
+	timestamp INTEGER NOT NULL
+);
@@ -0,0 +1,8 @@
+CREATE TABLE RetryQueue (
+	epoch INTEGER NOT NULL,
+	slotNr INTEGER NOT NULL,
+	attempts INTEGER NOT NULL,
+	nextAttempt INTEGER NOT NULL,
+	lastError TEXT NOT NULL,
+	PRIMARY KEY(epoch, slotNr)
+);
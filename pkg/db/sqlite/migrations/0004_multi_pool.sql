@@ -0,0 +1,71 @@
+-- leader logs and everything linked to them are keyed by the pool ID and
+-- epoch. The pool ID is taken from the leader log of the epoch.
+ALTER TABLE LeaderLog RENAME TO LeaderLogOld;
+ALTER TABLE AssignedBlock RENAME TO AssignedBlockOld;
+ALTER TABLE StatusTransition RENAME TO StatusTransitionOld;
+ALTER TABLE RetryQueue RENAME TO RetryQueueOld;
+
+CREATE TABLE LeaderLog (
+	poolID TEXT NOT NULL,
+	epoch INTEGER NOT NULL,
+	expectedBlockNr REAL NOT NULL,
+	maxPerformance REAL NOT NULL,
+	PRIMARY KEY(poolID, epoch)
+);
+
+CREATE TABLE AssignedBlock (
+	poolID TEXT NOT NULL,
+	epoch INTEGER NOT NULL,
+	no INTEGER NOT NULL,
+	slotNr INTEGER NOT NULL,
+	slotInEpochNr INTEGER NOT NULL,
+	timestamp INTEGER NOT NULL,
+	status INTEGER DEFAULT 0,
+	relevant INTEGER,
+	PRIMARY KEY(poolID, epoch, no),
+	FOREIGN KEY (poolID, epoch) REFERENCES LeaderLog(poolID, epoch) ON DELETE CASCADE,
+	FOREIGN KEY (relevant) REFERENCES MintedBlock(id) ON DELETE CASCADE
+);
+
+CREATE TABLE StatusTransition (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	poolID TEXT NOT NULL,
+	epoch INTEGER NOT NULL,
+	no INTEGER NOT NULL,
+	slotNr INTEGER NOT NULL,
+	fromStatus INTEGER NOT NULL,
+	toStatus INTEGER NOT NULL,
+	fromHash TEXT,
+	toHash TEXT,
+	timestamp INTEGER NOT NULL
+);
+
+CREATE TABLE RetryQueue (
+	poolID TEXT NOT NULL,
+	epoch INTEGER NOT NULL,
+	slotNr INTEGER NOT NULL,
+	attempts INTEGER NOT NULL,
+	nextAttempt INTEGER NOT NULL,
+	lastError TEXT NOT NULL,
+	PRIMARY KEY(poolID, epoch, slotNr)
+);
+
+INSERT INTO LeaderLog (poolID, epoch, expectedBlockNr, maxPerformance)
+SELECT poolID, epoch, expectedBlockNr, maxPerformance FROM LeaderLogOld;
+
+INSERT INTO AssignedBlock (poolID, epoch, no, slotNr, slotInEpochNr, timestamp, status, relevant)
+SELECT l.poolID, a.epoch, a.no, a.slotNr, a.slotInEpochNr, a.timestamp, a.status, a.relevant
+FROM AssignedBlockOld a JOIN LeaderLogOld l on a.epoch = l.epoch;
+
+INSERT INTO StatusTransition (id, poolID, epoch, no, slotNr, fromStatus, toStatus, fromHash, toHash, timestamp)
+SELECT t.id, l.poolID, t.epoch, t.no, t.slotNr, t.fromStatus, t.toStatus, t.fromHash, t.toHash, t.timestamp
+FROM StatusTransitionOld t JOIN LeaderLogOld l on t.epoch = l.epoch;
+
+INSERT INTO RetryQueue (poolID, epoch, slotNr, attempts, nextAttempt, lastError)
+SELECT l.poolID, r.epoch, r.slotNr, r.attempts, r.nextAttempt, r.lastError
+FROM RetryQueueOld r JOIN LeaderLogOld l on r.epoch = l.epoch;
+
+DROP TABLE RetryQueueOld;
+DROP TABLE StatusTransitionOld;
+DROP TABLE AssignedBlockOld;
+DROP TABLE LeaderLogOld;
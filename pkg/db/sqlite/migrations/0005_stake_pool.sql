@@ -0,0 +1,6 @@
+CREATE TABLE StakePool (
+	hexID TEXT PRIMARY KEY NOT NULL,
+	ticker TEXT NOT NULL,
+	name TEXT NOT NULL,
+	updatedAt INTEGER NOT NULL
+);
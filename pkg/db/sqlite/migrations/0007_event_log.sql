@@ -0,0 +1,11 @@
+CREATE TABLE Event (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	poolID TEXT NOT NULL,
+	type TEXT NOT NULL,
+	epoch INTEGER NOT NULL,
+	no INTEGER NOT NULL,
+	status INTEGER NOT NULL,
+	timestamp INTEGER NOT NULL
+);
+
+CREATE INDEX EventPoolIdx ON Event (poolID, id);
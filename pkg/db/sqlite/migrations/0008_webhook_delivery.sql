@@ -0,0 +1,19 @@
+CREATE TABLE WebhookDelivery (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	url TEXT NOT NULL,
+	poolID TEXT NOT NULL,
+	epoch INTEGER NOT NULL,
+	no INTEGER NOT NULL,
+	status INTEGER NOT NULL,
+	payload TEXT NOT NULL,
+	state TEXT NOT NULL,
+	attempts INTEGER NOT NULL,
+	nextAttempt INTEGER NOT NULL,
+	lastError TEXT NOT NULL,
+	responseStatus INTEGER NOT NULL,
+	createdAt INTEGER NOT NULL,
+	updatedAt INTEGER NOT NULL,
+	UNIQUE (url, poolID, epoch, no, status)
+);
+
+CREATE INDEX WebhookDeliveryPendingIdx ON WebhookDelivery (state, nextAttempt);
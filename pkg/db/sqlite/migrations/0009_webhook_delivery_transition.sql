@@ -0,0 +1,34 @@
+-- deliveries are unique per status transition, such that a block, which is
+-- reclassified back to an earlier status, is notified again. The deliveries
+-- queued before are linked to no transition.
+ALTER TABLE WebhookDelivery RENAME TO WebhookDeliveryOld;
+DROP INDEX WebhookDeliveryPendingIdx;
+
+CREATE TABLE WebhookDelivery (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	url TEXT NOT NULL,
+	poolID TEXT NOT NULL,
+	epoch INTEGER NOT NULL,
+	no INTEGER NOT NULL,
+	status INTEGER NOT NULL,
+	transitionID INTEGER NOT NULL,
+	payload TEXT NOT NULL,
+	state TEXT NOT NULL,
+	attempts INTEGER NOT NULL,
+	nextAttempt INTEGER NOT NULL,
+	lastError TEXT NOT NULL,
+	responseStatus INTEGER NOT NULL,
+	createdAt INTEGER NOT NULL,
+	updatedAt INTEGER NOT NULL,
+	UNIQUE (url, poolID, epoch, no, status, transitionID)
+);
+
+CREATE INDEX WebhookDeliveryPendingIdx ON WebhookDelivery (state, nextAttempt);
+
+INSERT INTO WebhookDelivery (id, url, poolID, epoch, no, status, transitionID, payload, state, attempts, nextAttempt,
+	lastError, responseStatus, createdAt, updatedAt)
+SELECT id, url, poolID, epoch, no, status, 0, payload, state, attempts, nextAttempt, lastError, responseStatus,
+	createdAt, updatedAt
+FROM WebhookDeliveryOld;
+
+DROP TABLE WebhookDeliveryOld;
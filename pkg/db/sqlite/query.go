@@ -34,17 +34,18 @@ func (l *SQLiteDB) queryAndScanLeaderLogIDs(ctx context.Context, query string,
 	return epochs, err
 }
 
-func (l *SQLiteDB) GetRegisteredEpochs(ctx context.Context,
+func (l *SQLiteDB) GetRegisteredEpochs(ctx context.Context, poolID string,
 	ordering db.Ordering, limit uint) ([]uint, error) {
 
 	orderingString := "ASC"
 	if ordering == db.OrderingDesc {
 		orderingString = "DESC"
 	}
-	query := fmt.Sprintf(`SELECT epoch FROM LeaderLog ORDER BY epoch %s LIMIT ?`, orderingString)
-	ids, err := l.queryAndScanLeaderLogIDs(ctx, query, limit)
+	query := fmt.Sprintf(`SELECT epoch FROM LeaderLog WHERE poolID = ? ORDER BY epoch %s LIMIT ?`, orderingString)
+	ids, err := l.queryAndScanLeaderLogIDs(ctx, query, poolID, limit)
 	if err != nil {
-		log.Errorf("querying for the registered epochs failed: %s", err.Error())
+		log.Errorf("querying for the registered epochs of pool=%s failed: %s",
+			poolID, err.Error())
 		return nil, db.ReadError
 	}
 	return ids, nil
@@ -67,10 +68,10 @@ func (l *SQLiteDB) queryAndScanAssignedBlocksWithMintedBlock(ctx context.Context
 		block := db.AssignedBlock{}
 		var unixTimestamp int64
 		var id, epoch, epochSlot, slot, height sql.NullInt64
-		var hash, poolID sql.NullString
+		var hash, poolID, poolTicker, poolName sql.NullString
 		err = rows.Scan(&block.Epoch, &block.No, &block.Slot, &block.EpochSlot,
 			&unixTimestamp, &block.Status, &id, &epoch, &epochSlot, &slot,
-			&hash, &height, &poolID)
+			&hash, &height, &poolID, &poolTicker, &poolName)
 		if err != nil {
 			return nil, err
 		}
@@ -82,13 +83,15 @@ func (l *SQLiteDB) queryAndScanAssignedBlocksWithMintedBlock(ctx context.Context
 			mSlot := uint(slot.Int64)
 			mHeight := uint(height.Int64)
 			block.RelevantBlock = &db.MintedBlock{
-				ID:        &mID,
-				Epoch:     mEpoch,
-				EpochSlot: mEpochSlot,
-				Slot:      mSlot,
-				Hash:      hash.String,
-				Height:    mHeight,
-				PoolID:    poolID.String,
+				ID:         &mID,
+				Epoch:      mEpoch,
+				EpochSlot:  mEpochSlot,
+				Slot:       mSlot,
+				Hash:       hash.String,
+				Height:     mHeight,
+				PoolID:     poolID.String,
+				PoolTicker: poolTicker.String,
+				PoolName:   poolName.String,
 			}
 		}
 		blocks = append(blocks, block)
@@ -147,23 +150,23 @@ func (l *SQLiteDB) queryAndScanLeaderLogs(ctx context.Context, q string,
 	return leaderLog, nil
 }
 
-func (l *SQLiteDB) GetLeaderLog(ctx context.Context,
+func (l *SQLiteDB) GetLeaderLog(ctx context.Context, poolID string,
 	epoch uint) (*db.LeaderLog, error) {
 
 	leaderLog, err := l.queryAndScanLeaderLogs(ctx, `
-SELECT epoch, poolID, expectedBlockNr, maxPerformance FROM LeaderLog WHERE epoch = ?;
-`, epoch)
+SELECT epoch, poolID, expectedBlockNr, maxPerformance FROM LeaderLog WHERE poolID = ? and epoch = ?;
+`, poolID, epoch)
 	if err != nil {
-		log.Errorf("querying the leaderlog of epoch=%d failed: %s",
-			epoch, err.Error())
+		log.Errorf("querying the leaderlog of pool=%s and epoch=%d failed: %s",
+			poolID, epoch, err.Error())
 		return nil, db.ReadError
 	}
 	if leaderLog != nil {
 		blocks, err := l.queryAndScanAssignedBlocks(ctx, `
 SELECT epoch, no, slotNr, slotInEpochNr, timestamp, status FROM AssignedBlock
-WHERE epoch = ?
+WHERE poolID = ? and epoch = ?
 ORDER BY timestamp ASC;
-`, epoch)
+`, poolID, epoch)
 		if err != nil {
 			log.Errorf("querying the blocks for leaderlog of epoch=%d failed: %s",
 				leaderLog.Epoch, err.Error())
@@ -174,55 +177,342 @@ ORDER BY timestamp ASC;
 	return leaderLog, nil
 }
 
-func (l *SQLiteDB) GetAssignedBlocksAfterNow(ctx context.Context) ([]db.AssignedBlock, error) {
-	now := time.Now()
+func (l *SQLiteDB) GetPerformance(ctx context.Context, poolID string, from,
+	to uint) ([]db.EpochPerformance, error) {
+
+	rows, err := l.db.QueryContext(ctx, `
+SELECT l.epoch, l.expectedBlockNr, COUNT(b.no),
+	COALESCE(SUM(CASE WHEN b.status = 0 THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN b.status = 1 THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN b.status = 2 THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN b.status = 3 THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN b.status = 4 THEN 1 ELSE 0 END), 0)
+FROM LeaderLog l LEFT JOIN AssignedBlock b ON b.poolID = l.poolID AND b.epoch = l.epoch
+WHERE l.poolID = ? AND l.epoch >= ? AND l.epoch <= ?
+GROUP BY l.epoch, l.expectedBlockNr
+ORDER BY l.epoch ASC;
+`, poolID, from, to)
+	if err != nil {
+		log.Errorf("querying the performance of pool=%s for the epochs %d to %d failed: %s",
+			poolID, from, to, err.Error())
+		return nil, db.ReadError
+	}
+	defer rows.Close()
+	performances := make([]db.EpochPerformance, 0)
+	for rows.Next() {
+		var counts [db.GHOSTED + 1]uint
+		performance := db.EpochPerformance{}
+		err = rows.Scan(&performance.Epoch, &performance.ExpectedBlockNumber,
+			&performance.AssignedBlocks, &counts[db.NotMinted],
+			&counts[db.Minted], &counts[db.DoubleAssignment],
+			&counts[db.HeightBattle], &counts[db.GHOSTED])
+		if err != nil {
+			log.Errorf("scanning the performance of pool=%s failed: %s",
+				poolID, err.Error())
+			return nil, db.ReadError
+		}
+		performance.StatusCounts = make(map[db.BlockStatus]uint, len(counts))
+		for status, count := range counts {
+			performance.StatusCounts[db.BlockStatus(status)] = count
+		}
+		performances = append(performances, performance)
+	}
+	if err = rows.Err(); err != nil {
+		log.Errorf("scanning the performance of pool=%s failed: %s",
+			poolID, err.Error())
+		return nil, db.ReadError
+	}
+	return performances, nil
+}
+
+func (l *SQLiteDB) GetAssignedBlocksAfterNow(ctx context.Context,
+	poolID string) ([]db.AssignedBlock, error) {
+
+	now := l.clock.Now()
 	blocks, err := l.queryAndScanAssignedBlocks(ctx, `
 SELECT epoch, no, slotNr, slotInEpochNr, timestamp, status FROM AssignedBlock
-WHERE timestamp > ?
+WHERE poolID = ? and timestamp > ?
 ORDER BY timestamp ASC;
-`, now.Unix())
+`, poolID, now.Unix())
 	if err != nil {
-		log.Errorf("querying the blocks after now=%v failed: %s",
-			now, err.Error())
+		log.Errorf("querying the blocks of pool=%s after now=%v failed: %s",
+			poolID, now, err.Error())
 		return nil, db.ReadError
 	}
 	return blocks, nil
 }
 
 func (l *SQLiteDB) GetAssignedBlocksBeforeNow(ctx context.Context,
-	epoch uint) ([]db.AssignedBlock, error) {
+	poolID string, epoch uint) ([]db.AssignedBlock, error) {
 
-	now := time.Now()
+	now := l.clock.Now()
 	blocks, err := l.queryAndScanAssignedBlocksWithMintedBlock(ctx, `
 SELECT a.epoch, a.no, a.slotNr, a.slotInEpochNr, a.timestamp, a.status, m.id, m.epoch, m.slotNr, m.slotInEpochNr,
-	m.hash, m.height, m.poolID
+	m.hash, m.height, m.poolID, m.poolTicker, m.poolName
 FROM AssignedBlock a LEFT JOIN MintedBlock m on a.relevant = m.id
-WHERE a.epoch = ? and a.timestamp <= ?
+WHERE a.poolID = ? and a.epoch = ? and a.timestamp <= ?
 ORDER BY a.timestamp ASC;
-`, epoch, now.Unix())
+`, poolID, epoch, now.Unix())
 	if err != nil {
-		log.Errorf("querying the blocks of epoch=%d before now=%v failed: %s",
-			epoch, now, err.Error())
+		log.Errorf("querying the blocks of pool=%s and epoch=%d before now=%v failed: %s",
+			poolID, epoch, now, err.Error())
 		return nil, db.ReadError
 	}
 	return blocks, nil
 }
 
 func (l *SQLiteDB) GetAssignedBlocksWithStatusBeforeNow(ctx context.Context,
-	status db.BlockStatus, offset, limit uint) ([]db.AssignedBlock, error) {
+	poolID string, status db.BlockStatus, offset,
+	limit uint) ([]db.AssignedBlock, error) {
 
-	now := time.Now()
+	now := l.clock.Now()
 	blocks, err := l.queryAndScanAssignedBlocks(ctx, `
 SELECT epoch, no, slotNr, slotInEpochNr, timestamp, status FROM AssignedBlock
-WHERE timestamp <= ? and status = ?
+WHERE poolID = ? and timestamp <= ? and status = ?
 ORDER BY timestamp ASC
 LIMIT ?
 OFFSET ?;
-`, now.Unix(), status, limit, offset)
+`, poolID, now.Unix(), status, limit, offset)
+	if err != nil {
+		log.Errorf("querying the blocks (%d,%d) of pool=%s with status=%d before now=%v failed: %s",
+			offset, limit, poolID, status, now, err.Error())
+		return nil, db.ReadError
+	}
+	return blocks, nil
+}
+
+func (l *SQLiteDB) GetClassifiedBlocksWithinWindow(ctx context.Context,
+	poolID string, height, slot uint) ([]db.AssignedBlock, error) {
+
+	blocks, err := l.queryAndScanAssignedBlocksWithMintedBlock(ctx, `
+SELECT a.epoch, a.no, a.slotNr, a.slotInEpochNr, a.timestamp, a.status, m.id, m.epoch, m.slotNr, m.slotInEpochNr,
+	m.hash, m.height, m.poolID, m.poolTicker, m.poolName
+FROM AssignedBlock a LEFT JOIN MintedBlock m on a.relevant = m.id
+WHERE a.poolID = ? and a.status <> ? and ((m.id IS NOT NULL and m.height >= ?) or (m.id IS NULL and a.slotNr >= ?))
+ORDER BY a.timestamp ASC;
+`, poolID, db.NotMinted, height, slot)
 	if err != nil {
-		log.Errorf("querying the blocks (%d,%d) with status=%d before now=%v failed: %s",
-			offset, limit, status, now, err.Error())
+		log.Errorf("querying the classified blocks of pool=%s since height=%d and slot=%d failed: %s",
+			poolID, height, slot, err.Error())
 		return nil, db.ReadError
 	}
 	return blocks, nil
 }
+
+func (l *SQLiteDB) GetStatusTransitions(ctx context.Context, poolID string,
+	epoch uint) ([]db.StatusTransition, error) {
+
+	rows, err := l.db.QueryContext(ctx, `
+SELECT id, poolID, epoch, no, slotNr, fromStatus, toStatus, fromHash, toHash, timestamp
+FROM StatusTransition
+WHERE poolID = ? and epoch = ?
+ORDER BY id ASC;
+`, poolID, epoch)
+	if err != nil {
+		log.Errorf("querying the status transitions of epoch %d of pool=%s failed: %s",
+			epoch, poolID, err.Error())
+		return nil, db.ReadError
+	}
+	defer rows.Close()
+	transitions := make([]db.StatusTransition, 0)
+	for rows.Next() {
+		transition := db.StatusTransition{}
+		var fromHash, toHash sql.NullString
+		var unixTimestamp int64
+		err = rows.Scan(&transition.ID, &transition.PoolID, &transition.Epoch,
+			&transition.No, &transition.Slot, &transition.From, &transition.To,
+			&fromHash, &toHash, &unixTimestamp)
+		if err != nil {
+			log.Errorf("scanning the status transitions of pool=%s failed: %s",
+				poolID, err.Error())
+			return nil, db.ReadError
+		}
+		transition.FromHash = fromHash.String
+		transition.ToHash = toHash.String
+		transition.Timestamp = time.Unix(unixTimestamp, 0)
+		transitions = append(transitions, transition)
+	}
+	return transitions, nil
+}
+
+// queryAndScanRetryEntries queries for retry entries with the specified query
+// and scans the result set. If the scanning has been successful, then an array
+// of retry entries is returned. Otherwise, an error will be returned, if the
+// querying or the scanning fails.
+func (l *SQLiteDB) queryAndScanRetryEntries(ctx context.Context, query string,
+	args ...interface{}) ([]db.RetryEntry, error) {
+
+	rows, err := l.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := make([]db.RetryEntry, 0)
+	for rows.Next() {
+		entry := db.RetryEntry{}
+		var unixTimestamp, unixNextAttempt int64
+		err = rows.Scan(&entry.Block.Epoch, &entry.Block.No, &entry.Block.Slot,
+			&entry.Block.EpochSlot, &unixTimestamp, &entry.Block.Status,
+			&entry.Attempts, &unixNextAttempt, &entry.LastError)
+		if err != nil {
+			return nil, err
+		}
+		entry.Block.Timestamp = time.Unix(unixTimestamp, 0)
+		if unixNextAttempt > 0 {
+			entry.NextAttempt = time.Unix(unixNextAttempt, 0)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (l *SQLiteDB) GetRetryEntries(ctx context.Context,
+	poolID string) ([]db.RetryEntry, error) {
+
+	entries, err := l.queryAndScanRetryEntries(ctx, `
+SELECT a.epoch, a.no, a.slotNr, a.slotInEpochNr, a.timestamp, a.status, r.attempts, r.nextAttempt, r.lastError
+FROM RetryQueue r JOIN AssignedBlock a on r.poolID = a.poolID and r.epoch = a.epoch and r.slotNr = a.slotNr
+WHERE r.poolID = ?
+ORDER BY r.nextAttempt ASC;
+`, poolID)
+	if err != nil {
+		log.Errorf("querying the queued retries of pool=%s failed: %s",
+			poolID, err.Error())
+		return nil, db.ReadError
+	}
+	return entries, nil
+}
+
+func (l *SQLiteDB) GetRetryEntry(ctx context.Context, poolID string, epoch,
+	no uint) (*db.RetryEntry, error) {
+
+	entries, err := l.queryAndScanRetryEntries(ctx, `
+SELECT a.epoch, a.no, a.slotNr, a.slotInEpochNr, a.timestamp, a.status, r.attempts, r.nextAttempt, r.lastError
+FROM RetryQueue r JOIN AssignedBlock a on r.poolID = a.poolID and r.epoch = a.epoch and r.slotNr = a.slotNr
+WHERE a.poolID = ? and a.epoch = ? and a.no = ?;
+`, poolID, epoch, no)
+	if err != nil {
+		log.Errorf("querying the queued retry of block (%d,%d) of pool=%s failed: %s",
+			epoch, no, poolID, err.Error())
+		return nil, db.ReadError
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+func (l *SQLiteDB) GetStakePool(ctx context.Context,
+	hexID string) (*db.StakePool, error) {
+
+	pool := db.StakePool{}
+	var updatedAt int64
+	err := l.db.QueryRowContext(ctx, `
+SELECT hexID, ticker, name, updatedAt FROM StakePool WHERE hexID = ?;
+`, hexID).Scan(&pool.HexID, &pool.Ticker, &pool.Name, &updatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Errorf("querying the metadata of pool=%s failed: %s", hexID,
+			err.Error())
+		return nil, db.ReadError
+	}
+	pool.UpdatedAt = time.Unix(updatedAt, 0)
+	return &pool, nil
+}
+
+func (l *SQLiteDB) GetEventsAfter(ctx context.Context, poolID string, id,
+	limit uint) ([]db.Event, error) {
+
+	rows, err := l.db.QueryContext(ctx, `
+SELECT id, poolID, type, epoch, no, status, timestamp FROM Event
+WHERE poolID = ? and id > ?
+ORDER BY id ASC
+LIMIT ?;
+`, poolID, id, limit)
+	if err != nil {
+		log.Errorf("querying the events of pool=%s after id=%d failed: %s",
+			poolID, id, err.Error())
+		return nil, db.ReadError
+	}
+	defer rows.Close()
+	events := make([]db.Event, 0)
+	for rows.Next() {
+		event := db.Event{}
+		var unixTimestamp int64
+		err = rows.Scan(&event.ID, &event.PoolID, &event.Type, &event.Epoch,
+			&event.No, &event.Status, &unixTimestamp)
+		if err != nil {
+			log.Errorf("scanning the events of pool=%s failed: %s", poolID,
+				err.Error())
+			return nil, db.ReadError
+		}
+		event.Timestamp = time.Unix(unixTimestamp, 0)
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (l *SQLiteDB) GetLastEventID(ctx context.Context, poolID string) (uint, error) {
+	var id sql.NullInt64
+	err := l.db.QueryRowContext(ctx, `
+SELECT MAX(id) FROM Event WHERE poolID = ?;
+`, poolID).Scan(&id)
+	if err != nil {
+		log.Errorf("querying the last event of pool=%s failed: %s", poolID,
+			err.Error())
+		return 0, db.ReadError
+	}
+	return uint(id.Int64), nil
+}
+
+// webhookDeliveryColumns are the columns of a webhook delivery in the order,
+// in which they are scanned by scanWebhookDeliveries.
+const webhookDeliveryColumns = `id, url, poolID, epoch, no, status, transitionID, payload, state, attempts,
+	nextAttempt, lastError, responseStatus, createdAt, updatedAt`
+
+// scanWebhookDeliveries scans the given result set of webhook deliveries,
+// whose columns are the webhookDeliveryColumns. An error will be returned, if
+// the scanning fails.
+func scanWebhookDeliveries(rows *sql.Rows) ([]db.WebhookDelivery, error) {
+	deliveries := make([]db.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery := db.WebhookDelivery{}
+		var nextAttempt, createdAt, updatedAt int64
+		err := rows.Scan(&delivery.ID, &delivery.URL, &delivery.PoolID,
+			&delivery.Epoch, &delivery.No, &delivery.Status,
+			&delivery.TransitionID, &delivery.Payload, &delivery.State,
+			&delivery.Attempts, &nextAttempt, &delivery.LastError,
+			&delivery.ResponseStatus, &createdAt, &updatedAt)
+		if err != nil {
+			return nil, err
+		}
+		delivery.NextAttempt = time.Unix(nextAttempt, 0)
+		delivery.CreatedAt = time.Unix(createdAt, 0)
+		delivery.UpdatedAt = time.Unix(updatedAt, 0)
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (l *SQLiteDB) GetWebhookDeliveries(ctx context.Context, offset,
+	limit uint) ([]db.WebhookDelivery, error) {
+
+	rows, err := l.db.QueryContext(ctx, `
+SELECT `+webhookDeliveryColumns+` FROM WebhookDelivery
+ORDER BY id DESC
+LIMIT ? OFFSET ?;
+`, limit, offset)
+	if err != nil {
+		log.Errorf("querying the webhook deliveries failed: %s", err.Error())
+		return nil, db.ReadError
+	}
+	defer rows.Close()
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		log.Errorf("scanning the webhook deliveries failed: %s", err.Error())
+		return nil, db.ReadError
+	}
+	return deliveries, nil
+}
@@ -1,69 +1,79 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
-	"errors"
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
 	"github.com/blockblu-io/leaderlog-api/pkg/db"
 	_ "github.com/mattn/go-sqlite3"
+	log "github.com/sirupsen/logrus"
 	"os"
 	"path/filepath"
 )
 
 // SQLiteDB is a db.DB making use of sqlite3 database.
 type SQLiteDB struct {
-	db  *sql.DB
-	obv *db.Observer
+	db    *sql.DB
+	obv   *db.Observer
+	clock clock.Clock
 }
 
 // NewSQLiteDB opens a new SQLite database. This method should only be called
-// once. It returns an DB instance with which the database can be queried, or an
-// error, if opening the database has failed.
-func NewSQLiteDB(path string) (db.DB, error) {
-	dbFilePath := filepath.Join(path, "sql.db")
-	needsToBeInitialized, err := prepareFile(dbFilePath)
+// once. The schema of the database is migrated to the latest version. The
+// given clock.Clock tells the time, which is "now" for the queries. It returns
+// an DB instance with which the database can be queried, or an error, if
+// opening or migrating the database has failed.
+func NewSQLiteDB(path string, clk clock.Clock) (db.DB, error) {
+	sqlDB, err := openFile(path)
 	if err != nil {
 		return nil, err
 	}
-	sqlDB, err := sql.Open("sqlite3", dbFilePath)
+	_, err = migrate(sqlDB, false)
 	if err != nil {
+		_ = sqlDB.Close()
 		return nil, err
 	}
-	if needsToBeInitialized {
-		err = createTables(sqlDB)
-		if err != nil {
-			return nil, err
-		}
-	}
 	return &SQLiteDB{
-		db:  sqlDB,
-		obv: &db.Observer{},
+		db:    sqlDB,
+		obv:   &db.Observer{},
+		clock: clk,
 	}, nil
 }
 
-// prepareFile prepares the database file for sqlite such that it can be
-// properly used and accessed. This method returns a boolean indicating whether
-// the database file was newly created and hence, needs to be initialized or
-// not. "True" will be returned, if it needs to be initialized. An error will be
-// instead returned, if the path cannot be prepared for SQLite for some reason.
-func prepareFile(dbFilePath string) (bool, error) {
-	err := os.MkdirAll(filepath.Dir(dbFilePath), 0755)
+// openFile opens the database file for sqlite in the directory at the given
+// path. The directory is created, if it doesn't exist yet. An error will be
+// returned, if the path cannot be prepared for SQLite for some reason.
+func openFile(path string) (*sql.DB, error) {
+	err := os.MkdirAll(path, 0755)
 	if err != nil {
-		return false, err
-	}
-	_, err = os.Stat(dbFilePath)
-	needsToBeInitialized := errors.Is(err, os.ErrNotExist)
-	if needsToBeInitialized {
-		_, err := os.Create(dbFilePath)
-		return true, err
-	} else {
-		return false, nil
+		return nil, err
 	}
+	return sql.Open("sqlite3", filepath.Join(path, "sql.db"))
 }
 
 func (l *SQLiteDB) Observer() *db.Observer {
 	return l.obv
 }
 
+// Ping checks whether the database can be reached, and makes a statement,
+// which would write to the database, but doesn't match any rows. This
+// statement fails, if the database is read-only.
+func (l *SQLiteDB) Ping(ctx context.Context) error {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Errorf("couldn't start a transaction to ping the database: %s",
+			err.Error())
+		return db.WriteError
+	}
+	defer func() { _ = tx.Rollback() }()
+	_, err = tx.ExecContext(ctx, "DELETE FROM LeaderLog WHERE 1 = 0")
+	if err != nil {
+		log.Errorf("pinging the database failed: %s", err.Error())
+		return db.WriteError
+	}
+	return nil
+}
+
 func (l *SQLiteDB) Close() error {
 	l.obv.Close()
 	return l.db.Close()
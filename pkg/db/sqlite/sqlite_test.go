@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	"github.com/blockblu-io/leaderlog-api/pkg/db/dbtest"
+)
+
+func TestSQLite(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T, clk clock.Clock) db.DB {
+		idb, err := NewSQLiteDB(t.TempDir(), clk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return idb
+	})
+}
+
+// countMintedBlocks returns the number of minted blocks stored in the given
+// database.
+func countMintedBlocks(t *testing.T, idb db.DB) int {
+	var n int
+	err := idb.(*SQLiteDB).db.QueryRow(`SELECT COUNT(*) FROM MintedBlock;`).
+		Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestStatusUpdateLeavesNoMintedBlockBehind(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 3, 17, 12, 0, 0, 0, time.UTC)
+	idb, err := NewSQLiteDB(t.TempDir(), clock.NewFake(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idb.Close()
+	_, err = idb.WriteLeaderLog(ctx, &db.LeaderLog{
+		PoolID: poolA,
+		Epoch:  327,
+		Blocks: []db.AssignedBlock{{Epoch: 327, No: 1, EpochSlot: 100,
+			Slot: 55900900, Timestamp: now.Add(-time.Hour)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	minted := func(hash string) *db.MintedBlock {
+		return &db.MintedBlock{Epoch: 327, EpochSlot: 100, Slot: 55900900,
+			Hash: hash, Height: 7000001, PoolID: poolA}
+	}
+	err = idb.UpdateStatusForAssignment(ctx, poolA, 327, 2, db.Minted,
+		minted("unknown"))
+	if err == nil {
+		t.Fatal("expected the update of an unknown block to fail")
+	}
+	if n := countMintedBlocks(t, idb); n != 0 {
+		t.Fatalf("expected no minted block after the failed update, got %d", n)
+	}
+	for _, hash := range []string{"first", "first", "second"} {
+		err = idb.UpdateStatusForAssignment(ctx, poolA, 327, 1, db.Minted,
+			minted(hash))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := countMintedBlocks(t, idb); n != 1 {
+			t.Fatalf("expected only the linked minted block, got %d", n)
+		}
+	}
+}
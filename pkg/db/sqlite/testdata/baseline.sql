@@ -0,0 +1,41 @@
+-- a database with the schema, which has been created before the versions of
+-- the schema have been tracked, and the leader log of epoch 327.
+CREATE TABLE LeaderLog (
+	epoch INTEGER NOT NULL PRIMARY KEY,
+	poolID TEXT NOT NULL,
+	expectedBlockNr REAL NOT NULL,
+	maxPerformance REAL NOT NULL
+);
+
+CREATE TABLE MintedBlock (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	epoch INTEGER NOT NULL,
+	slotNr INTEGER NOT NULL,
+	slotInEpochNr INTEGER NOT NULL,
+	hash TEXT NOT NULL,
+	height INTEGER NOT NULL,
+	poolID TEXT NOT NULL
+);
+
+CREATE TABLE AssignedBlock (
+	epoch INTEGER NOT NULL,
+	no INTEGER NOT NULL,
+	slotNr INTEGER NOT NULL,
+	slotInEpochNr INTEGER NOT NULL,
+	timestamp INTEGER NOT NULL,
+	status INTEGER DEFAULT 0,
+	relevant INTEGER,
+	PRIMARY KEY(epoch, no),
+	FOREIGN KEY (epoch) REFERENCES LeaderLog(epoch) ON DELETE CASCADE,
+	FOREIGN KEY (relevant) REFERENCES MintedBlock(id) ON DELETE CASCADE
+);
+
+INSERT INTO LeaderLog (epoch, poolID, expectedBlockNr, maxPerformance)
+VALUES (327, 'cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214b', 2.5, 1.2);
+
+INSERT INTO MintedBlock (id, epoch, slotNr, slotInEpochNr, hash, height, poolID)
+VALUES (1, 327, 55900900, 100, '0a02', 7000001, 'cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214b');
+
+INSERT INTO AssignedBlock (epoch, no, slotNr, slotInEpochNr, timestamp, status, relevant)
+VALUES (327, 1, 55900900, 100, 1647467191, 1, 1),
+       (327, 2, 55901000, 200, 1647467291, 0, NULL);
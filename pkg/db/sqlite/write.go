@@ -2,96 +2,255 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"sort"
+	"time"
 
 	"github.com/blockblu-io/leaderlog-api/pkg/db"
 	log "github.com/sirupsen/logrus"
 )
 
-func (l *SQLiteDB) WriteMintedBlock(ctx context.Context,
-	block *db.MintedBlock) (*uint, error) {
+// insertMintedBlock inserts the given minted block within the given
+// transaction, and returns the ID of the inserted row.
+func insertMintedBlock(ctx context.Context, tx *sql.Tx,
+	block *db.MintedBlock) (uint, error) {
 
-	tx, err := l.db.BeginTx(ctx, nil)
-	if err != nil {
-		log.Errorf("couldn't start a transaction to write the minted block with hash '%s': %s",
-			block.Hash, err.Error())
-		return nil, db.WriteError
-	}
-	result, err := tx.Exec(`
-INSERT INTO MintedBlock (epoch, slotNr, slotInEpochNr, hash, height, poolID) VALUES (?, ?, ?, ?, ?, ?)
+	result, err := tx.ExecContext(ctx, `
+INSERT INTO MintedBlock (epoch, slotNr, slotInEpochNr, hash, height, poolID, poolTicker, poolName)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 `, block.Epoch, block.Slot, block.EpochSlot, block.Hash, block.Height,
-		block.PoolID)
+		block.PoolID, block.PoolTicker, block.PoolName)
 	if err != nil {
-		_ = tx.Rollback()
-		log.Errorf("inserting minted block with hash '%s' failed: %s",
-			block.Hash, err.Error())
-		return nil, db.WriteError
+		return 0, err
 	}
-	blockId, err := result.LastInsertId()
+	id, err := result.LastInsertId()
 	if err != nil {
-		_ = tx.Rollback()
-		log.Errorf("getting the ID of inserted minted block with hash '%s' failed: %s",
-			block.Hash, err.Error())
-		return nil, db.WriteError
+		return 0, err
 	}
-	err = tx.Commit()
+	return uint(id), nil
+}
+
+// assignmentState is the synced state of an assigned block, which shall be
+// preserved when a leader log is replaced.
+type assignmentState struct {
+	status   db.BlockStatus
+	relevant *uint
+}
+
+// queryAssignmentStates queries the state of all the assigned blocks of the
+// given pool and epoch within the given transaction. The states are mapped to
+// the slot number of the assigned block.
+func queryAssignmentStates(ctx context.Context, tx *sql.Tx, poolID string,
+	epoch uint) (map[uint]assignmentState, error) {
+
+	rows, err := tx.QueryContext(ctx, `
+SELECT slotNr, status, relevant FROM AssignedBlock WHERE poolID = ? and epoch = ?;
+`, poolID, epoch)
 	if err != nil {
-		return nil, db.WriteError
+		return nil, err
+	}
+	defer rows.Close()
+	states := make(map[uint]assignmentState)
+	for rows.Next() {
+		var slot uint
+		var state assignmentState
+		var relevant sql.NullInt64
+		err = rows.Scan(&slot, &state.status, &relevant)
+		if err != nil {
+			return nil, err
+		}
+		if relevant.Valid {
+			id := uint(relevant.Int64)
+			state.relevant = &id
+		}
+		states[slot] = state
 	}
-	b := uint(blockId)
-	return &b, nil
+	return states, rows.Err()
 }
 
 func (l *SQLiteDB) WriteLeaderLog(ctx context.Context,
-	leaderLog *db.LeaderLog) error {
+	leaderLog *db.LeaderLog) (*db.LeaderLogDiff, error) {
 
 	tx, err := l.db.BeginTx(ctx, nil)
 	if err != nil {
 		log.Errorf("couldn't start a transaction to write the leaderlog of epoch '%d': %s",
 			leaderLog.Epoch, err.Error())
-		return db.WriteError
+		return nil, db.WriteError
 	}
-	_, err = tx.Exec(`
-INSERT INTO LeaderLog (epoch, poolID, expectedBlockNr, maxPerformance) VALUES (?, ?, ?, ?)
-`, leaderLog.Epoch, leaderLog.PoolID, leaderLog.ExpectedBlockNumber,
-		leaderLog.MaxPerformance)
+	states, err := queryAssignmentStates(ctx, tx, leaderLog.PoolID,
+		leaderLog.Epoch)
 	if err != nil {
 		_ = tx.Rollback()
-		log.Errorf("inserting the leaderlog of epoch '%d' failed: %s",
+		log.Errorf("querying the assigned blocks of epoch '%d' failed: %s",
 			leaderLog.Epoch, err.Error())
-		return db.WriteError
+		return nil, db.WriteError
+	}
+	result, err := tx.ExecContext(ctx, `
+UPDATE LeaderLog SET expectedBlockNr = ?, maxPerformance = ? WHERE poolID = ? and epoch = ?;
+`, leaderLog.ExpectedBlockNumber, leaderLog.MaxPerformance, leaderLog.PoolID,
+		leaderLog.Epoch)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("updating the leaderlog of epoch '%d' failed: %s",
+			leaderLog.Epoch, err.Error())
+		return nil, db.WriteError
+	}
+	updatedRows, err := result.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("updating the leaderlog of epoch '%d' failed: %s",
+			leaderLog.Epoch, err.Error())
+		return nil, db.WriteError
+	}
+	diff := &db.LeaderLogDiff{
+		PoolID:   leaderLog.PoolID,
+		Epoch:    leaderLog.Epoch,
+		Replaced: updatedRows > 0,
+		Added:    []uint{},
+		Removed:  []uint{},
+		Kept:     []uint{},
+	}
+	if diff.Replaced {
+		_, err = tx.ExecContext(ctx, `
+DELETE FROM AssignedBlock WHERE poolID = ? and epoch = ?;
+`, leaderLog.PoolID, leaderLog.Epoch)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+INSERT INTO LeaderLog (poolID, epoch, expectedBlockNr, maxPerformance) VALUES (?, ?, ?, ?)
+`, leaderLog.PoolID, leaderLog.Epoch, leaderLog.ExpectedBlockNumber,
+			leaderLog.MaxPerformance)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("writing the leaderlog of epoch '%d' failed: %s",
+			leaderLog.Epoch, err.Error())
+		return nil, db.WriteError
 	}
-	insertAssignmentStmt, err := tx.Prepare(`
-INSERT INTO AssignedBlock (epoch, no, slotNr, slotInEpochNr, timestamp) VALUES (?,?,?,?,?);
+	insertAssignmentStmt, err := tx.PrepareContext(ctx, `
+INSERT INTO AssignedBlock (poolID, epoch, no, slotNr, slotInEpochNr, timestamp, status, relevant) VALUES (?,?,?,?,?,?,?,?);
 `)
 	if err != nil {
 		_ = tx.Rollback()
 		log.Errorf("preparing the query for assigned block insertion for epoch '%d' failed: %s",
 			leaderLog.Epoch, err.Error())
-		return db.WriteError
+		return nil, db.WriteError
 	}
+	defer insertAssignmentStmt.Close()
 	for _, block := range leaderLog.Blocks {
-		_, err = insertAssignmentStmt.ExecContext(ctx, leaderLog.Epoch,
-			block.No, block.Slot, block.EpochSlot, block.Timestamp.Unix())
+		state, found := states[block.Slot]
+		if found {
+			delete(states, block.Slot)
+			diff.Kept = append(diff.Kept, block.Slot)
+		} else {
+			diff.Added = append(diff.Added, block.Slot)
+		}
+		_, err = insertAssignmentStmt.ExecContext(ctx, leaderLog.PoolID,
+			leaderLog.Epoch, block.No, block.Slot, block.EpochSlot, block.Timestamp.Unix(),
+			state.status, state.relevant)
 		if err != nil {
 			_ = tx.Rollback()
 			log.Errorf("assigned block insertion for epoch '%d' failed: %s",
 				leaderLog.Epoch, err.Error())
-			return db.WriteError
+			return nil, db.WriteError
 		}
 	}
+	for slot, state := range states {
+		diff.Removed = append(diff.Removed, slot)
+		_, err = tx.ExecContext(ctx, `
+DELETE FROM StatusTransition WHERE poolID = ? and epoch = ? and slotNr = ?;
+`, leaderLog.PoolID, leaderLog.Epoch, slot)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, `
+DELETE FROM RetryQueue WHERE poolID = ? and epoch = ? and slotNr = ?;
+`, leaderLog.PoolID, leaderLog.Epoch, slot)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("removing the history of removed slot %d failed: %s",
+				slot, err.Error())
+			return nil, db.WriteError
+		}
+		if state.relevant == nil {
+			continue
+		}
+		_, err = tx.ExecContext(ctx, `
+DELETE FROM MintedBlock WHERE id = ?;
+`, *state.relevant)
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("removing the minted block of removed slot %d failed: %s",
+				slot, err.Error())
+			return nil, db.WriteError
+		}
+	}
+	err = recordEvent(ctx, tx, &db.Event{
+		PoolID:    leaderLog.PoolID,
+		Type:      db.EventEpochRegistered,
+		Epoch:     leaderLog.Epoch,
+		Timestamp: l.clock.Now(),
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("recording the registration of epoch '%d' failed: %s",
+			leaderLog.Epoch, err.Error())
+		return nil, db.WriteError
+	}
 	err = tx.Commit()
 	if err != nil {
-		return db.WriteError
+		return nil, db.WriteError
 	}
-	go l.obv.Pub(db.ObserverMessage{
-		Code:     db.ObserveNewLeaderLog,
-		Response: leaderLog.Epoch,
+	sortSlots(diff.Added)
+	sortSlots(diff.Removed)
+	sortSlots(diff.Kept)
+	if diff.Replaced {
+		go l.obv.Pub(db.ObserverMessage{
+			Code:     db.ObserveReplacedLeaderLog,
+			Response: diff,
+		})
+	} else {
+		go l.obv.Pub(db.ObserverMessage{
+			Code: db.ObserveNewLeaderLog,
+			Response: db.LeaderLogRef{
+				PoolID: leaderLog.PoolID,
+				Epoch:  leaderLog.Epoch,
+			},
+		})
+	}
+	return diff, nil
+}
+
+// sortSlots sorts the given list of slots in ascending order.
+func sortSlots(slots []uint) {
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i] < slots[j]
 	})
+}
+
+func (l *SQLiteDB) WriteRetryEntry(ctx context.Context, poolID string,
+	entry *db.RetryEntry) error {
+
+	var nextAttempt int64
+	if !entry.NextAttempt.IsZero() {
+		nextAttempt = entry.NextAttempt.Unix()
+	}
+	_, err := l.db.ExecContext(ctx, `
+INSERT INTO RetryQueue (poolID, epoch, slotNr, attempts, nextAttempt, lastError)
+SELECT poolID, epoch, slotNr, ?, ?, ? FROM AssignedBlock WHERE poolID = ? and epoch = ? and no = ?
+ON CONFLICT (poolID, epoch, slotNr) DO UPDATE SET attempts = excluded.attempts,
+	nextAttempt = excluded.nextAttempt, lastError = excluded.lastError;
+`, entry.Attempts, nextAttempt, entry.LastError, poolID, entry.Block.Epoch,
+		entry.Block.No)
+	if err != nil {
+		log.Errorf("queueing the retry of block (%d,%d) of pool=%s failed: %s",
+			entry.Block.Epoch, entry.Block.No, poolID, err.Error())
+		return db.WriteError
+	}
 	return nil
 }
 
-func (l *SQLiteDB) UpdateStatusForAssignment(ctx context.Context, epoch,
-	no uint, status db.BlockStatus, mintedBlockID *uint) error {
+func (l *SQLiteDB) UpdateStatusForAssignment(ctx context.Context,
+	poolID string, epoch, no uint, status db.BlockStatus,
+	mintedBlock *db.MintedBlock) error {
 
 	tx, err := l.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -99,22 +258,346 @@ func (l *SQLiteDB) UpdateStatusForAssignment(ctx context.Context, epoch,
 			epoch, no, err.Error())
 		return db.WriteError
 	}
+	var slot uint
+	var fromStatus db.BlockStatus
+	var fromID sql.NullInt64
+	var fromHash sql.NullString
+	err = tx.QueryRowContext(ctx, `
+SELECT a.slotNr, a.status, a.relevant, m.hash
+FROM AssignedBlock a LEFT JOIN MintedBlock m on a.relevant = m.id
+WHERE a.poolID = ? and a.epoch = ? and a.no = ?;
+`, poolID, epoch, no).Scan(&slot, &fromStatus, &fromID, &fromHash)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("querying the status of block (%d,%d) failed: %s",
+			epoch, no, err.Error())
+		return db.WriteError
+	}
+	var mintedBlockID *uint
+	var toHash sql.NullString
+	if mintedBlock != nil {
+		id, err := insertMintedBlock(ctx, tx, mintedBlock)
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("inserting minted block with hash '%s' failed: %s",
+				mintedBlock.Hash, err.Error())
+			return db.WriteError
+		}
+		mintedBlockID = &id
+		toHash = sql.NullString{String: mintedBlock.Hash, Valid: true}
+	}
 	_, err = tx.ExecContext(ctx, `
-UPDATE AssignedBlock SET status = ?, relevant = ? WHERE epoch = ? and no = ?;
-`, status, mintedBlockID, epoch, no)
+UPDATE AssignedBlock SET status = ?, relevant = ? WHERE poolID = ? and epoch = ? and no = ?;
+`, status, mintedBlockID, poolID, epoch, no)
 	if err != nil {
 		_ = tx.Rollback()
 		log.Errorf("setting the status=%v of block (%d,%d) failed: %s",
 			status, epoch, no, err.Error())
 		return db.WriteError
 	}
+	if fromID.Valid && (mintedBlockID == nil || uint(fromID.Int64) != *mintedBlockID) {
+		_, err = tx.ExecContext(ctx, `
+DELETE FROM MintedBlock WHERE id = ?;
+`, fromID.Int64)
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("removing the replaced minted block of block (%d,%d) failed: %s",
+				epoch, no, err.Error())
+			return db.WriteError
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+DELETE FROM RetryQueue WHERE poolID = ? and epoch = ? and slotNr = ?;
+`, poolID, epoch, slot)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("resolving the queued retry of block (%d,%d) failed: %s",
+			epoch, no, err.Error())
+		return db.WriteError
+	}
+	transition := &db.StatusTransition{
+		PoolID:    poolID,
+		Epoch:     epoch,
+		No:        no,
+		Slot:      slot,
+		From:      fromStatus,
+		To:        status,
+		FromHash:  fromHash.String,
+		ToHash:    toHash.String,
+		Timestamp: l.clock.Now(),
+	}
+	changed := transition.From != transition.To ||
+		transition.FromHash != transition.ToHash
+	if changed {
+		var result sql.Result
+		result, err = tx.ExecContext(ctx, `
+INSERT INTO StatusTransition (poolID, epoch, no, slotNr, fromStatus, toStatus, fromHash, toHash, timestamp) VALUES (?,?,?,?,?,?,?,?,?);
+`, poolID, epoch, no, slot, transition.From, transition.To, fromHash, toHash,
+			transition.Timestamp.Unix())
+		if err == nil {
+			var id int64
+			id, err = result.LastInsertId()
+			transition.ID = uint(id)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("recording the status transition of block (%d,%d) failed: %s",
+				epoch, no, err.Error())
+			return db.WriteError
+		}
+	}
+	if changed || fromStatus == db.NotMinted {
+		err = recordEvent(ctx, tx, &db.Event{
+			PoolID:    poolID,
+			Type:      db.EventBlockStatus,
+			Epoch:     epoch,
+			No:        no,
+			Status:    status,
+			Timestamp: transition.Timestamp,
+		})
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("recording the status event of block (%d,%d) failed: %s",
+				epoch, no, err.Error())
+			return db.WriteError
+		}
+	}
 	err = tx.Commit()
 	if err != nil {
 		return db.WriteError
 	}
+	if fromStatus != db.NotMinted {
+		if changed {
+			go l.obv.Pub(db.ObserverMessage{
+				Code:     db.ObserveReclassifiedBlock,
+				Response: transition,
+			})
+		}
+	} else {
+		go l.obv.Pub(db.ObserverMessage{
+			Code: db.ObserveUpdatedBlockStatus,
+			Response: db.AssignmentRef{
+				PoolID: poolID,
+				Epoch:  epoch,
+				No:     no,
+			},
+		})
+	}
+	return nil
+}
+
+func (l *SQLiteDB) DeleteLeaderLog(ctx context.Context, poolID string,
+	epoch uint) (bool, error) {
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Errorf("couldn't start a transaction to delete the leaderlog of epoch '%d': %s",
+			epoch, err.Error())
+		return false, db.WriteError
+	}
+	states, err := queryAssignmentStates(ctx, tx, poolID, epoch)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("querying the assigned blocks of epoch '%d' failed: %s",
+			epoch, err.Error())
+		return false, db.WriteError
+	}
+	_, err = tx.ExecContext(ctx, `
+DELETE FROM AssignedBlock WHERE poolID = ? and epoch = ?;
+`, poolID, epoch)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("deleting the assigned blocks of epoch '%d' failed: %s",
+			epoch, err.Error())
+		return false, db.WriteError
+	}
+	for _, state := range states {
+		if state.relevant == nil {
+			continue
+		}
+		_, err = tx.ExecContext(ctx, `
+DELETE FROM MintedBlock WHERE id = ?;
+`, *state.relevant)
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("deleting the minted blocks of epoch '%d' failed: %s",
+				epoch, err.Error())
+			return false, db.WriteError
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+DELETE FROM StatusTransition WHERE poolID = ? and epoch = ?;
+`, poolID, epoch)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, `
+DELETE FROM RetryQueue WHERE poolID = ? and epoch = ?;
+`, poolID, epoch)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("deleting the history of epoch '%d' failed: %s",
+			epoch, err.Error())
+		return false, db.WriteError
+	}
+	result, err := tx.ExecContext(ctx, `
+DELETE FROM LeaderLog WHERE poolID = ? and epoch = ?;
+`, poolID, epoch)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("deleting the leaderlog of epoch '%d' failed: %s",
+			epoch, err.Error())
+		return false, db.WriteError
+	}
+	deletedRows, err := result.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("deleting the leaderlog of epoch '%d' failed: %s",
+			epoch, err.Error())
+		return false, db.WriteError
+	}
+	if deletedRows > 0 {
+		err = recordEvent(ctx, tx, &db.Event{
+			PoolID:    poolID,
+			Type:      db.EventEpochDeleted,
+			Epoch:     epoch,
+			Timestamp: l.clock.Now(),
+		})
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("recording the deletion of epoch '%d' failed: %s",
+				epoch, err.Error())
+			return false, db.WriteError
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return false, db.WriteError
+	}
+	if deletedRows == 0 {
+		return false, nil
+	}
 	go l.obv.Pub(db.ObserverMessage{
-		Code:     db.ObserveUpdatedBlockStatus,
-		Response: []uint{epoch, no},
+		Code: db.ObserveDeletedLeaderLog,
+		Response: db.LeaderLogRef{
+			PoolID: poolID,
+			Epoch:  epoch,
+		},
 	})
+	return true, nil
+}
+
+func (l *SQLiteDB) WriteStakePool(ctx context.Context, pool *db.StakePool) error {
+	_, err := l.db.ExecContext(ctx, `
+INSERT INTO StakePool (hexID, ticker, name, updatedAt) VALUES (?, ?, ?, ?)
+ON CONFLICT (hexID) DO UPDATE SET ticker = excluded.ticker, name = excluded.name,
+	updatedAt = excluded.updatedAt;
+`, pool.HexID, pool.Ticker, pool.Name, pool.UpdatedAt.Unix())
+	if err != nil {
+		log.Errorf("writing the metadata of pool=%s failed: %s", pool.HexID,
+			err.Error())
+		return db.WriteError
+	}
+	return nil
+}
+
+// recordEvent records the given event within the given transaction. The event
+// is only recorded, if the transaction is committed.
+func recordEvent(ctx context.Context, tx *sql.Tx, event *db.Event) error {
+	_, err := tx.ExecContext(ctx, `
+INSERT INTO Event (poolID, type, epoch, no, status, timestamp) VALUES (?, ?, ?, ?, ?, ?);
+`, event.PoolID, event.Type, event.Epoch, event.No, event.Status,
+		event.Timestamp.Unix())
+	return err
+}
+
+func (l *SQLiteDB) QueueWebhookDelivery(ctx context.Context,
+	delivery *db.WebhookDelivery) (bool, error) {
+
+	now := l.clock.Now().Unix()
+	result, err := l.db.ExecContext(ctx, `
+INSERT INTO WebhookDelivery (url, poolID, epoch, no, status, transitionID, payload, state, attempts, nextAttempt,
+	lastError, responseStatus, createdAt, updatedAt)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, 0, ?, '', 0, ?, ?)
+ON CONFLICT (url, poolID, epoch, no, status, transitionID) DO NOTHING;
+`, delivery.URL, delivery.PoolID, delivery.Epoch, delivery.No,
+		delivery.Status, delivery.TransitionID, delivery.Payload,
+		db.DeliveryPending, now, now, now)
+	if err != nil {
+		log.Errorf("queueing the webhook delivery for block (%d,%d) of pool=%s failed: %s",
+			delivery.Epoch, delivery.No, delivery.PoolID, err.Error())
+		return false, db.WriteError
+	}
+	queuedRows, err := result.RowsAffected()
+	if err != nil {
+		log.Errorf("queueing the webhook delivery for block (%d,%d) of pool=%s failed: %s",
+			delivery.Epoch, delivery.No, delivery.PoolID, err.Error())
+		return false, db.WriteError
+	}
+	return queuedRows > 0, nil
+}
+
+func (l *SQLiteDB) ClaimWebhookDeliveries(ctx context.Context,
+	lease time.Duration, limit uint) ([]db.WebhookDelivery, error) {
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Errorf("couldn't start a transaction to claim webhook deliveries: %s",
+			err.Error())
+		return nil, db.WriteError
+	}
+	now := l.clock.Now()
+	rows, err := tx.QueryContext(ctx, `
+SELECT `+webhookDeliveryColumns+` FROM WebhookDelivery
+WHERE state = ? and nextAttempt <= ?
+ORDER BY nextAttempt ASC, id ASC
+LIMIT ?;
+`, db.DeliveryPending, now.Unix(), limit)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("querying the due webhook deliveries failed: %s", err.Error())
+		return nil, db.WriteError
+	}
+	deliveries, err := scanWebhookDeliveries(rows)
+	_ = rows.Close()
+	if err != nil {
+		_ = tx.Rollback()
+		log.Errorf("scanning the due webhook deliveries failed: %s", err.Error())
+		return nil, db.WriteError
+	}
+	leasedUntil := now.Add(lease).Unix()
+	for i := range deliveries {
+		_, err = tx.ExecContext(ctx, `
+UPDATE WebhookDelivery SET nextAttempt = ? WHERE id = ?;
+`, leasedUntil, deliveries[i].ID)
+		if err != nil {
+			_ = tx.Rollback()
+			log.Errorf("claiming the webhook delivery with id=%d failed: %s",
+				deliveries[i].ID, err.Error())
+			return nil, db.WriteError
+		}
+		deliveries[i].NextAttempt = time.Unix(leasedUntil, 0)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, db.WriteError
+	}
+	return deliveries, nil
+}
+
+func (l *SQLiteDB) UpdateWebhookDelivery(ctx context.Context,
+	delivery *db.WebhookDelivery) error {
+
+	_, err := l.db.ExecContext(ctx, `
+UPDATE WebhookDelivery SET state = ?, attempts = ?, nextAttempt = ?, lastError = ?, responseStatus = ?,
+	updatedAt = ?
+WHERE id = ?;
+`, delivery.State, delivery.Attempts, delivery.NextAttempt.Unix(),
+		delivery.LastError, delivery.ResponseStatus, l.clock.Now().Unix(),
+		delivery.ID)
+	if err != nil {
+		log.Errorf("updating the webhook delivery with id=%d failed: %s",
+			delivery.ID, err.Error())
+		return db.WriteError
+	}
 	return nil
 }
@@ -0,0 +1,170 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the text format of Prometheus, in which
+// the metrics are written by the Writer.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Type is the type of metric.
+type Type string
+
+const (
+	TypeCounter   Type = "counter"
+	TypeGauge     Type = "gauge"
+	TypeHistogram Type = "histogram"
+)
+
+// Label is a dimension of a metric with its value.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// HistogramSnapshot is the state of a Histogram at a certain time.
+type HistogramSnapshot struct {
+	// Bounds are the upper bounds of the buckets in ascending order. The
+	// bucket with the bound +Inf is implicit.
+	Bounds []float64
+	// Counts are the cumulative numbers of observations, which are less than
+	// or equal to the bound with the same index.
+	Counts []uint64
+	// Count is the total number of observations.
+	Count uint64
+	// Sum is the sum of all observations.
+	Sum float64
+}
+
+// Histogram counts the observations of a value in buckets, which are
+// bounded by the given upper bounds.
+type Histogram struct {
+	lock   sync.Mutex
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram creates a new Histogram with buckets for the given upper
+// bounds.
+func NewHistogram(bounds ...float64) *Histogram {
+	sorted := append([]float64(nil), bounds...)
+	sort.Float64s(sorted)
+	return &Histogram{
+		bounds: sorted,
+		counts: make([]uint64, len(sorted)),
+	}
+}
+
+// Observe counts the given value in all buckets, whose bound is greater than
+// or equal to it.
+func (h *Histogram) Observe(value float64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for i, bound := range h.bounds {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+// Snapshot returns a copy of the current state of this histogram.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return HistogramSnapshot{
+		Bounds: append([]float64(nil), h.bounds...),
+		Counts: append([]uint64(nil), h.counts...),
+		Count:  h.count,
+		Sum:    h.sum,
+	}
+}
+
+// Writer writes metrics in the text format of Prometheus. The samples of a
+// metric must be written right after its header. The first error, which
+// occurred while writing, is kept and returned by Err, and nothing is written
+// after it.
+type Writer struct {
+	w   io.Writer
+	err error
+}
+
+// NewWriter creates a new Writer writing to the given io.Writer.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Header writes the help text and the type of the metric with the given name.
+func (w *Writer) Header(name string, kind Type, help string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	w.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// Sample writes a sample of the metric with the given name and labels.
+func (w *Writer) Sample(name string, value float64, labels ...Label) {
+	w.printf("%s%s %s\n", name, formatLabels(labels), formatValue(value))
+}
+
+// Histogram writes the buckets, the sum and the count of the given histogram
+// with the given name and labels.
+func (w *Writer) Histogram(name string, h HistogramSnapshot, labels ...Label) {
+	for i, bound := range h.Bounds {
+		w.Sample(name+"_bucket", float64(h.Counts[i]), append(labels,
+			Label{Name: "le", Value: formatValue(bound)})...)
+	}
+	w.Sample(name+"_bucket", float64(h.Count), append(labels,
+		Label{Name: "le", Value: formatValue(math.Inf(1))})...)
+	w.Sample(name+"_sum", h.Sum, labels...)
+	w.Sample(name+"_count", float64(h.Count), labels...)
+}
+
+// Err returns the first error, which occurred while writing.
+func (w *Writer) Err() error {
+	return w.err
+}
+
+// printf writes the formatted text, if no error occurred before.
+func (w *Writer) printf(format string, a ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = fmt.Fprintf(w.w, format, a...)
+}
+
+// formatLabels formats the given labels in the text format. An empty string
+// is returned, if no labels are given.
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	escaper := strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	pairs := make([]string, len(labels))
+	for i, label := range labels {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, label.Name,
+			escaper.Replace(label.Value))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatValue formats the given value in the text format.
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var buffer bytes.Buffer
+	w := NewWriter(&buffer)
+	w.Header("blocks", TypeGauge, "Number of blocks\nwith a \\ in the help.")
+	w.Sample("blocks", 2, Label{Name: "pool_id", Value: "a"},
+		Label{Name: "status", Value: "\"minted\"\n\\"})
+	w.Sample("blocks", 0.5)
+	w.Header("requests_total", TypeCounter, "Number of requests.")
+	w.Sample("requests_total", 1e21)
+	w.Sample("requests_total", math.Inf(1))
+	w.Sample("requests_total", math.Inf(-1))
+	w.Sample("requests_total", math.NaN())
+	if w.Err() != nil {
+		t.Fatal(w.Err())
+	}
+	expected := `# HELP blocks Number of blocks\nwith a \\ in the help.
+# TYPE blocks gauge
+blocks{pool_id="a",status="\"minted\"\n\\"} 2
+blocks 0.5
+# HELP requests_total Number of requests.
+# TYPE requests_total counter
+requests_total 1e+21
+requests_total +Inf
+requests_total -Inf
+requests_total NaN
+`
+	if buffer.String() != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, buffer.String())
+	}
+}
+
+func TestWriterHistogram(t *testing.T) {
+	h := NewHistogram(60, 10, 3600)
+	for _, value := range []float64{5, 10, 30, 7200} {
+		h.Observe(value)
+	}
+	var buffer bytes.Buffer
+	w := NewWriter(&buffer)
+	w.Header("latency_seconds", TypeHistogram, "Latency.")
+	w.Histogram("latency_seconds", h.Snapshot(),
+		Label{Name: "pool_id", Value: "a"})
+	if w.Err() != nil {
+		t.Fatal(w.Err())
+	}
+	expected := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{pool_id="a",le="10"} 2
+latency_seconds_bucket{pool_id="a",le="60"} 3
+latency_seconds_bucket{pool_id="a",le="3600"} 3
+latency_seconds_bucket{pool_id="a",le="+Inf"} 4
+latency_seconds_sum{pool_id="a"} 7245
+latency_seconds_count{pool_id="a"} 4
+`
+	if buffer.String() != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, buffer.String())
+	}
+}
+
+// failingWriter is an io.Writer, which accepts the given number of writes,
+// before it fails.
+type failingWriter struct {
+	writes int
+	err    error
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.writes == 0 {
+		return 0, w.err
+	}
+	w.writes--
+	return len(p), nil
+}
+
+func TestWriterKeepsFirstError(t *testing.T) {
+	fw := &failingWriter{writes: 1, err: errors.New("closed")}
+	w := NewWriter(fw)
+	w.Header("blocks", TypeGauge, "Number of blocks.")
+	w.Sample("blocks", 1)
+	if w.Err() != fw.err {
+		t.Fatalf("expected the error of the writing, got %v", w.Err())
+	}
+	fw.err = errors.New("other")
+	w.Sample("blocks", 2)
+	if w.Err() == fw.err {
+		t.Fatal("expected the first error to be kept")
+	}
+}
@@ -0,0 +1,411 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	DefaultConfig = &Configuration{
+		RetryBaseDelay:   time.Minute,
+		RetryMaxDelay:    time.Hour,
+		RetryMaxAttempts: 10,
+		Timeout:          10 * time.Second,
+		PollInterval:     30 * time.Second,
+		Clock:            clock.Real,
+	}
+)
+
+const (
+	// SignatureHeader is the header of a notification, which carries the
+	// HMAC-SHA256 signature of its body in the format 'sha256=<hex>'.
+	SignatureHeader = "X-Leaderlog-Signature"
+	// DeliveryHeader is the header of a notification, which carries the ID of
+	// the delivery. It is the same for all attempts of a delivery.
+	DeliveryHeader = "X-Leaderlog-Delivery"
+)
+
+// batchSize is the number of due deliveries, which are claimed and attempted
+// at once.
+const batchSize uint = 10
+
+// eventNames maps the statuses of assigned blocks to the names of the events,
+// about which webhooks are notified.
+var eventNames = map[db.BlockStatus]string{
+	db.Minted:           "minted",
+	db.DoubleAssignment: "double-assigned",
+	db.HeightBattle:     "height-battle",
+	db.GHOSTED:          "ghosted",
+}
+
+// Webhook is a URL, which is notified about the outcome of assigned blocks.
+type Webhook struct {
+	// URL is the URL, to which the notifications are posted.
+	URL string
+	// Statuses are the statuses of assigned blocks, about which the webhook is
+	// notified. It is notified about all outcomes, if it is empty.
+	Statuses []db.BlockStatus
+}
+
+// accepts checks whether this webhook shall be notified about blocks with the
+// given status.
+func (w *Webhook) accepts(status db.BlockStatus) bool {
+	if status == db.NotMinted {
+		return false
+	}
+	if len(w.Statuses) == 0 {
+		return true
+	}
+	for _, s := range w.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseWebhook parses the given textual representation of a webhook. It is
+// the URL of the webhook, which can be prefixed with a comma-separated list
+// of events and '=' (e.g. 'minted,height-battle=https://example.com/hook').
+// The following events are supported: "minted", "double-assigned",
+// "height-battle" and "ghosted". An error will be returned, if the value
+// can't be parsed.
+func ParseWebhook(value string) (*Webhook, error) {
+	webhook := &Webhook{URL: value}
+	if !strings.HasPrefix(value, "http://") &&
+		!strings.HasPrefix(value, "https://") {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("the webhook '%s' must be a HTTP(S) URL", value)
+		}
+		webhook.URL = parts[1]
+		for _, name := range strings.Split(parts[0], ",") {
+			status, found := parseEventName(strings.TrimSpace(name))
+			if !found {
+				return nil, fmt.Errorf("the event '%s' of webhook '%s' is unknown",
+					name, value)
+			}
+			webhook.Statuses = append(webhook.Statuses, status)
+		}
+	}
+	if !strings.HasPrefix(webhook.URL, "http://") &&
+		!strings.HasPrefix(webhook.URL, "https://") {
+		return nil, fmt.Errorf("the webhook '%s' must be a HTTP(S) URL", value)
+	}
+	return webhook, nil
+}
+
+// parseEventName returns the status of assigned blocks for the event with the
+// given name. False is returned, if the name is unknown.
+func parseEventName(name string) (db.BlockStatus, bool) {
+	for status, eventName := range eventNames {
+		if eventName == name {
+			return status, true
+		}
+	}
+	return db.NotMinted, false
+}
+
+// Sign computes the signature of the given body of a notification with the
+// given secret, which is sent in the SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Configuration configures the behaviour of the Dispatcher.
+type Configuration struct {
+	// Webhooks are the webhooks, which are notified.
+	Webhooks []Webhook
+	// Secret is the key with which the body of each notification is signed.
+	Secret string
+	// RetryBaseDelay is the delay before the first retry of a failed delivery.
+	// The delay is doubled for each further failed attempt.
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay is the maximal delay between two retries.
+	RetryMaxDelay time.Duration
+	// RetryMaxAttempts is the number of failed attempts after which no
+	// further retry is made for a delivery.
+	RetryMaxAttempts uint
+	// Timeout is the duration after which an attempt is cancelled.
+	Timeout time.Duration
+	// PollInterval is the interval in which due deliveries are looked up.
+	PollInterval time.Duration
+	// Clock tells the time for the Dispatcher. The time of the system is
+	// used, if it is nil.
+	Clock clock.Clock
+}
+
+// Payload is the body of a notification.
+type Payload struct {
+	// Event is the name of the outcome of the assigned block (e.g. 'minted').
+	Event string
+	// PoolID is the id in hex format of the pool, to which the block has been
+	// assigned.
+	PoolID string
+	// Block is the assigned block with the minted block, which explains its
+	// status.
+	Block db.AssignedBlock
+}
+
+// Dispatcher notifies webhooks about the outcome of assigned blocks. The
+// notifications are queued as deliveries in the db.DB, once the status of an
+// assigned block has been gathered, and they are retried with an exponential
+// backoff, until they have been accepted by the receiver.
+type Dispatcher struct {
+	db     db.DB
+	config *Configuration
+	client *http.Client
+	wakeUp chan struct{}
+}
+
+// NewDispatcher creates a new Dispatcher, which queues the deliveries in the
+// given db.DB. The default configuration is used, if the given Configuration
+// is nil.
+func NewDispatcher(idb db.DB, config *Configuration) *Dispatcher {
+	if config == nil {
+		config = DefaultConfig
+	}
+	if config.Clock == nil {
+		withClock := *config
+		withClock.Clock = clock.Real
+		config = &withClock
+	}
+	return &Dispatcher{
+		db:     idb,
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		wakeUp: make(chan struct{}, 1),
+	}
+}
+
+// Run queues the notifications about updated block statuses, including the
+// reclassifications of blocks, and attempts the due deliveries. This method
+// is running until the given context has been cancelled or the db.DB has been
+// closed.
+func (d *Dispatcher) Run(ctx context.Context) {
+	listener := make(chan db.ObserverMessage)
+	d.db.Observer().Sub(listener)
+	defer d.db.Observer().Unsub(listener)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go d.deliverDue(ctx)
+	for {
+		select {
+		case msg, ok := <-listener:
+			if !ok {
+				return
+			}
+			switch response := msg.Response.(type) {
+			case db.AssignmentRef:
+				if msg.Code == db.ObserveUpdatedBlockStatus {
+					d.queue(ctx, response)
+				}
+			case *db.StatusTransition:
+				if msg.Code == db.ObserveReclassifiedBlock {
+					d.queue(ctx, db.AssignmentRef{
+						PoolID: response.PoolID,
+						Epoch:  response.Epoch,
+						No:     response.No,
+					})
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// queue queues the notifications about the status of the referenced block
+// for all the webhooks, which accept it. The notifications are linked to the
+// last status transition of the block, such that a block, which has been
+// reclassified back to an earlier status, is notified again.
+func (d *Dispatcher) queue(ctx context.Context, ref db.AssignmentRef) {
+	blocks, err := d.db.GetAssignedBlocksBeforeNow(ctx, ref.PoolID, ref.Epoch)
+	if err != nil {
+		log.Errorf("couldn't look up block (%d,%d) of pool=%s for the webhooks: %s",
+			ref.Epoch, ref.No, ref.PoolID, err.Error())
+		return
+	}
+	var block *db.AssignedBlock
+	for i := range blocks {
+		if blocks[i].No == ref.No {
+			block = &blocks[i]
+		}
+	}
+	if block == nil || block.Status == db.NotMinted {
+		return
+	}
+	transitions, err := d.db.GetStatusTransitions(ctx, ref.PoolID, ref.Epoch)
+	if err != nil {
+		log.Errorf("couldn't look up the status transitions of block (%d,%d) of pool=%s for the webhooks: %s",
+			ref.Epoch, ref.No, ref.PoolID, err.Error())
+		return
+	}
+	var transitionID uint
+	for _, transition := range transitions {
+		if transition.Slot == block.Slot && transition.ID > transitionID {
+			transitionID = transition.ID
+		}
+	}
+	payload, err := json.Marshal(&Payload{
+		Event:  eventNames[block.Status],
+		PoolID: ref.PoolID,
+		Block:  *block,
+	})
+	if err != nil {
+		log.Errorf("couldn't encode the notification about block (%d,%d): %s",
+			ref.Epoch, ref.No, err.Error())
+		return
+	}
+	queued := false
+	for _, webhook := range d.config.Webhooks {
+		if !webhook.accepts(block.Status) {
+			continue
+		}
+		ok, err := d.db.QueueWebhookDelivery(ctx, &db.WebhookDelivery{
+			URL:          webhook.URL,
+			PoolID:       ref.PoolID,
+			Epoch:        ref.Epoch,
+			No:           ref.No,
+			Status:       block.Status,
+			TransitionID: transitionID,
+			Payload:      string(payload),
+		})
+		if err != nil {
+			log.Errorf("couldn't queue the notification of webhook '%s' about block (%d,%d) of pool=%s: %s",
+				webhook.URL, ref.Epoch, ref.No, ref.PoolID, err.Error())
+			continue
+		}
+		queued = queued || ok
+	}
+	if queued {
+		select {
+		case d.wakeUp <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// deliverDue attempts the due deliveries, whenever a delivery has been queued
+// or the poll interval has passed, until the given context is cancelled.
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	ticker := d.config.Clock.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			deliveries, err := d.db.ClaimWebhookDeliveries(ctx,
+				2*d.config.Timeout, batchSize)
+			if err != nil || len(deliveries) == 0 {
+				break
+			}
+			var wg sync.WaitGroup
+			for i := range deliveries {
+				wg.Add(1)
+				go func(delivery *db.WebhookDelivery) {
+					defer wg.Done()
+					d.deliver(ctx, delivery)
+				}(&deliveries[i])
+			}
+			wg.Wait()
+		}
+		select {
+		case <-d.wakeUp:
+		case <-ticker.C():
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver makes an attempt to deliver the given delivery, and updates its
+// state with the outcome.
+func (d *Dispatcher) deliver(ctx context.Context, delivery *db.WebhookDelivery) {
+	responseStatus, err := d.post(ctx, delivery)
+	if ctx.Err() != nil {
+		return
+	}
+	delivery.ResponseStatus = responseStatus
+	if err == nil {
+		log.Infof("notified webhook '%s' about block (%d,%d) of pool=%s",
+			delivery.URL, delivery.Epoch, delivery.No, delivery.PoolID)
+		delivery.State = db.DeliveryDelivered
+		delivery.LastError = ""
+	} else {
+		delivery.Attempts++
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= d.config.RetryMaxAttempts {
+			log.Errorf("notifying webhook '%s' about block (%d,%d) of pool=%s failed for the last time: %s",
+				delivery.URL, delivery.Epoch, delivery.No, delivery.PoolID,
+				err.Error())
+			delivery.State = db.DeliveryFailed
+		} else {
+			delay := d.retryDelay(delivery.Attempts)
+			log.Warnf("notifying webhook '%s' about block (%d,%d) of pool=%s failed, retrying in %s: %s",
+				delivery.URL, delivery.Epoch, delivery.No, delivery.PoolID,
+				delay, err.Error())
+			delivery.NextAttempt = d.config.Clock.Now().Add(delay)
+		}
+	}
+	err = d.db.UpdateWebhookDelivery(ctx, delivery)
+	if err != nil {
+		log.Errorf("couldn't update the delivery %d to webhook '%s': %s",
+			delivery.ID, delivery.URL, err.Error())
+	}
+}
+
+// post posts the payload of the given delivery to its webhook, and returns
+// the status code of the response. An error will be returned, if the request
+// failed or the receiver didn't accept the notification.
+func (d *Dispatcher) post(ctx context.Context,
+	delivery *db.WebhookDelivery) (int, error) {
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL,
+		bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "leaderlog-api")
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(SignatureHeader, Sign(d.config.Secret, body))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d",
+			resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryDelay computes the delay before the next attempt of a delivery, which
+// failed the given number of times.
+func (d *Dispatcher) retryDelay(attempts uint) time.Duration {
+	delay := d.config.RetryBaseDelay
+	for i := uint(1); i < attempts && delay < d.config.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.config.RetryMaxDelay {
+		delay = d.config.RetryMaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,387 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blockblu-io/leaderlog-api/pkg/clock"
+	"github.com/blockblu-io/leaderlog-api/pkg/db"
+	"github.com/blockblu-io/leaderlog-api/pkg/db/memdb"
+)
+
+const (
+	poolA  = "cdae4a1a08974113e77ea332cb1da97d9e3fca5cf797f9394739214b"
+	poolB  = "1d9302a3fb4b3b1935e02b27f0339798d3f08a55fbfdcd43a449a96f"
+	secret = "s3cret"
+	// awaitTimeout is the duration to wait for the dispatcher to react.
+	awaitTimeout = 5 * time.Second
+)
+
+// startTime is the time to which the clock is set at the start of a test.
+var startTime = time.Date(2022, 3, 17, 12, 0, 0, 0, time.UTC)
+
+// request is a notification received by a receiver.
+type request struct {
+	path     string
+	delivery string
+	signed   bool
+	payload  Payload
+}
+
+// receiver is a HTTP server receiving notifications, which fails the given
+// number of requests to a path with status 503 before accepting them. The
+// requests to a path fail forever, if the number is negative.
+type receiver struct {
+	*httptest.Server
+	lock     sync.Mutex
+	failures map[string]int
+	requests []request
+}
+
+// newReceiver starts a new receiver, which fails requests to paths as given.
+func newReceiver(t *testing.T, failures map[string]int) *receiver {
+	r := &receiver{failures: failures}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.handle))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) handle(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	received := request{
+		path:     req.URL.Path,
+		delivery: req.Header.Get(DeliveryHeader),
+		signed: hmac.Equal([]byte(signature),
+			[]byte(req.Header.Get(SignatureHeader))),
+	}
+	_ = json.Unmarshal(body, &received.payload)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.requests = append(r.requests, received)
+	if n := r.failures[req.URL.Path]; n != 0 {
+		if n > 0 {
+			r.failures[req.URL.Path] = n - 1
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// received returns the requests received for the given path.
+func (r *receiver) received(path string) []request {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	requests := make([]request, 0)
+	for _, req := range r.requests {
+		if req.path == path {
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+// startDispatcher runs a dispatcher for the given webhooks, and waits until it
+// observes the given database.
+func startDispatcher(t *testing.T, idb db.DB, clk *clock.Fake,
+	webhooks ...Webhook) {
+
+	d := NewDispatcher(idb, &Configuration{
+		Webhooks:         webhooks,
+		Secret:           secret,
+		RetryBaseDelay:   time.Minute,
+		RetryMaxDelay:    time.Hour,
+		RetryMaxAttempts: 3,
+		Timeout:          awaitTimeout,
+		PollInterval:     30 * time.Second,
+		Clock:            clk,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go d.Run(ctx)
+	// the ticker for polling the due deliveries is created after subscribing
+	// to the observer.
+	done := make(chan struct{})
+	go func() {
+		clk.BlockUntil(1)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(awaitTimeout):
+		t.Fatal("the dispatcher hasn't been started")
+	}
+}
+
+// newTestDB creates a database with a leader log of poolA in epoch 327, whose
+// two blocks are in the past of the given clock.
+func newTestDB(t *testing.T, clk clock.Clock) db.DB {
+	idb := memdb.NewMemDB(clk)
+	past := clk.Now().Add(-time.Hour)
+	_, err := idb.WriteLeaderLog(context.Background(), &db.LeaderLog{
+		PoolID: poolA,
+		Epoch:  327,
+		Blocks: []db.AssignedBlock{
+			{Epoch: 327, No: 1, EpochSlot: 100, Slot: 55900900, Timestamp: past},
+			{Epoch: 327, No: 2, EpochSlot: 200, Slot: 55901000, Timestamp: past},
+		},
+		ExpectedBlockNumber: 2,
+		MaxPerformance:      1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idb
+}
+
+// updateStatus updates the status of the given block of poolA in epoch 327,
+// which is explained by a block minted by the given pool, if the hash isn't
+// empty.
+func updateStatus(t *testing.T, idb db.DB, no uint, status db.BlockStatus,
+	hash, poolID string) {
+
+	var minted *db.MintedBlock
+	if hash != "" {
+		minted = &db.MintedBlock{
+			Epoch:     327,
+			EpochSlot: 100,
+			Slot:      55900900,
+			Hash:      hash,
+			Height:    7000001,
+			PoolID:    poolID,
+		}
+	}
+	err := idb.UpdateStatusForAssignment(context.Background(), poolA, 327, no,
+		status, minted)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// awaitDeliveries waits until the logged deliveries, which are listed from
+// the latest one, satisfy the given condition. The test fails, if they don't
+// in time.
+func awaitDeliveries(t *testing.T, idb db.DB, description string,
+	cond func(deliveries []db.WebhookDelivery) bool) []db.WebhookDelivery {
+
+	t.Helper()
+	deadline := time.Now().Add(awaitTimeout)
+	for {
+		deliveries, err := idb.GetWebhookDeliveries(context.Background(), 0,
+			100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cond(deliveries) {
+			return deliveries
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %s, got %+v", description, deliveries)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// allInState checks whether the given number of deliveries have been logged,
+// and all of them are in the given state.
+func allInState(n int, state db.DeliveryState) func([]db.WebhookDelivery) bool {
+	return func(deliveries []db.WebhookDelivery) bool {
+		if len(deliveries) != n {
+			return false
+		}
+		for _, delivery := range deliveries {
+			if delivery.State != state {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func TestParseWebhook(t *testing.T) {
+	tests := []struct {
+		value    string
+		url      string
+		statuses []db.BlockStatus
+	}{
+		{"https://example.com/hook?a=b", "https://example.com/hook?a=b", nil},
+		{"minted,ghosted=http://example.com/hook", "http://example.com/hook",
+			[]db.BlockStatus{db.Minted, db.GHOSTED}},
+		{"double-assigned, height-battle=https://example.com/hook",
+			"https://example.com/hook",
+			[]db.BlockStatus{db.DoubleAssignment, db.HeightBattle}},
+	}
+	for _, tc := range tests {
+		webhook, err := ParseWebhook(tc.value)
+		if err != nil {
+			t.Fatalf("couldn't parse '%s': %s", tc.value, err.Error())
+		}
+		if webhook.URL != tc.url || len(webhook.Statuses) != len(tc.statuses) {
+			t.Fatalf("expected '%s' to be parsed as %s %v, got %+v", tc.value,
+				tc.url, tc.statuses, webhook)
+		}
+		for i := range tc.statuses {
+			if webhook.Statuses[i] != tc.statuses[i] {
+				t.Errorf("expected '%s' to accept %v, got %v", tc.value,
+					tc.statuses, webhook.Statuses)
+			}
+		}
+	}
+	for _, value := range []string{"example.com", "unknown=http://example.com",
+		"minted=ftp://example.com"} {
+		if _, err := ParseWebhook(value); err == nil {
+			t.Errorf("expected '%s' to be rejected", value)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	d := NewDispatcher(memdb.NewMemDB(clock.Real), &Configuration{
+		RetryBaseDelay: time.Minute,
+		RetryMaxDelay:  time.Hour,
+	})
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute,
+		8 * time.Minute, 16 * time.Minute, 32 * time.Minute, time.Hour,
+		time.Hour}
+	for i, delay := range want {
+		if got := d.retryDelay(uint(i + 1)); got != delay {
+			t.Errorf("expected a delay of %s after %d attempts, got %s", delay,
+				i+1, got)
+		}
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	idb := newTestDB(t, clk)
+	recv := newReceiver(t, map[string]int{"/all": 1})
+	startDispatcher(t, idb, clk,
+		Webhook{URL: recv.URL + "/all"},
+		Webhook{URL: recv.URL + "/ghosted", Statuses: []db.BlockStatus{db.GHOSTED}},
+	)
+
+	// the first attempt fails, and the delivery is retried after the base
+	// delay.
+	updateStatus(t, idb, 1, db.Minted, "0a01", poolA)
+	deliveries := awaitDeliveries(t, idb, "a failed attempt",
+		func(deliveries []db.WebhookDelivery) bool {
+			return len(deliveries) == 1 && deliveries[0].Attempts == 1
+		})
+	delivery := deliveries[0]
+	if delivery.State != db.DeliveryPending ||
+		delivery.ResponseStatus != http.StatusServiceUnavailable ||
+		!delivery.NextAttempt.Equal(startTime.Add(time.Minute)) ||
+		!strings.Contains(delivery.LastError, "503") {
+		t.Errorf("expected the delivery to be retried in a minute, got %+v",
+			delivery)
+	}
+	clk.Advance(time.Minute)
+	deliveries = awaitDeliveries(t, idb, "the minted block to be delivered",
+		allInState(1, db.DeliveryDelivered))
+	if deliveries[0].ResponseStatus != http.StatusNoContent ||
+		deliveries[0].LastError != "" {
+		t.Errorf("expected the delivery to be accepted, got %+v", deliveries[0])
+	}
+	requests := recv.received("/all")
+	if len(requests) != 2 {
+		t.Fatalf("expected two attempts, got %+v", requests)
+	}
+	for _, req := range requests {
+		if !req.signed || req.delivery != "1" ||
+			req.payload.Event != "minted" || req.payload.PoolID != poolA ||
+			req.payload.Block.No != 1 ||
+			req.payload.Block.RelevantBlock == nil ||
+			req.payload.Block.RelevantBlock.Hash != "0a01" {
+			t.Errorf("expected a signed notification about the minted block, got %+v",
+				req)
+		}
+	}
+
+	// a ghosted block is notified to both webhooks.
+	updateStatus(t, idb, 2, db.GHOSTED, "", "")
+	awaitDeliveries(t, idb, "the ghosted block to be delivered twice",
+		allInState(3, db.DeliveryDelivered))
+	requests = recv.received("/ghosted")
+	if len(requests) != 1 || requests[0].payload.Event != "ghosted" ||
+		requests[0].payload.Block.No != 2 {
+		t.Errorf("expected a notification about the ghosted block, got %+v",
+			requests)
+	}
+
+	// the minted block has been rolled back, and lost a height battle.
+	updateStatus(t, idb, 1, db.HeightBattle, "0b01", poolB)
+	awaitDeliveries(t, idb, "the reclassified block to be delivered",
+		allInState(4, db.DeliveryDelivered))
+	requests = recv.received("/all")
+	last := requests[len(requests)-1]
+	if len(requests) != 4 || last.payload.Event != "height-battle" ||
+		last.payload.Block.No != 1 ||
+		last.payload.Block.RelevantBlock.PoolID != poolB {
+		t.Errorf("expected a notification about the reclassified block, got %+v",
+			requests)
+	}
+	if n := len(recv.received("/ghosted")); n != 1 {
+		t.Errorf("expected the filtered webhook to be notified once, got %d",
+			n)
+	}
+
+	// the block has been reclassified back to minted, which is notified
+	// again.
+	updateStatus(t, idb, 1, db.Minted, "0a01", poolA)
+	awaitDeliveries(t, idb, "the block reclassified back to be delivered",
+		allInState(5, db.DeliveryDelivered))
+	requests = recv.received("/all")
+	last = requests[len(requests)-1]
+	if len(requests) != 5 || last.payload.Event != "minted" ||
+		last.payload.Block.No != 1 ||
+		last.payload.Block.RelevantBlock.Hash != "0a01" {
+		t.Errorf("expected a notification about the block reclassified back, got %+v",
+			requests)
+	}
+}
+
+func TestDispatchGivesUp(t *testing.T) {
+	clk := clock.NewFake(startTime)
+	idb := newTestDB(t, clk)
+	recv := newReceiver(t, map[string]int{"/down": -1})
+	startDispatcher(t, idb, clk, Webhook{URL: recv.URL + "/down"})
+
+	updateStatus(t, idb, 1, db.Minted, "0a01", poolA)
+	for attempts := uint(1); attempts < 3; attempts++ {
+		awaitDeliveries(t, idb, "another failed attempt",
+			func(deliveries []db.WebhookDelivery) bool {
+				return len(deliveries) == 1 &&
+					deliveries[0].Attempts == attempts
+			})
+		clk.Advance(time.Duration(attempts) * time.Minute)
+	}
+	deliveries := awaitDeliveries(t, idb, "the delivery to be given up",
+		allInState(1, db.DeliveryFailed))
+	if deliveries[0].Attempts != 3 ||
+		deliveries[0].ResponseStatus != http.StatusServiceUnavailable {
+		t.Errorf("expected the delivery to fail after 3 attempts, got %+v",
+			deliveries[0])
+	}
+	clk.Advance(time.Hour)
+	time.Sleep(50 * time.Millisecond)
+	if n := len(recv.received("/down")); n != 3 {
+		t.Errorf("expected no attempt after giving up, got %d attempts", n)
+	}
+}